@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"syscall"
+
+	"port-monitor/api"
+	"port-monitor/scanner"
+)
+
+// defaultAgentSocket is where the agent listens and the TUI connects by default.
+func defaultAgentSocket() string {
+	if runtime.GOOS == "linux" {
+		return "/run/port-monitor.sock"
+	}
+	return "/var/run/port-monitor.sock"
+}
+
+// runAgent serves scans and kills over a unix socket, so it can run as root
+// while the TUI connects to it unprivileged with --agent.
+func runAgent(args []string) error {
+	fs := flag.NewFlagSet("agent", flag.ExitOnError)
+	socket := fs.String("socket", defaultAgentSocket(), "unix socket to listen on")
+	owner := fs.Int("owner", -1, "uid allowed to use the socket (default: the user who ran sudo)")
+	fs.Parse(args)
+
+	uid := *owner
+	if uid < 0 {
+		if sudoUID, err := strconv.Atoi(os.Getenv("SUDO_UID")); err == nil {
+			uid = sudoUID
+		}
+	}
+
+	if err := os.Remove(*socket); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+	l, err := net.Listen("unix", *socket)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", *socket, err)
+	}
+	defer os.Remove(*socket)
+
+	// Only the owner may talk to the agent, since it can kill anything
+	if err := os.Chmod(*socket, 0o600); err != nil {
+		return fmt.Errorf("failed to restrict socket: %w", err)
+	}
+	if uid >= 0 {
+		if err := os.Chown(*socket, uid, -1); err != nil {
+			return fmt.Errorf("failed to hand socket to uid %d: %w", uid, err)
+		}
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-stop
+		l.Close()
+	}()
+
+	log.Printf("agent listening on %s", *socket)
+	err = http.Serve(l, api.NewHandler(newLocalSource(scanner.Options{})))
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
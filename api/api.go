@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"port-monitor/scanner"
+)
+
+// Source is what the API serves: scans to read and processes to kill.
+type Source interface {
+	ScanProcesses() ([]scanner.ProcessInfo, error)
+	KillProcess(pid int32) error
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewHandler exposes a Source over HTTP:
+//
+//	GET  /processes     every process with its connections
+//	GET  /ports/{port}  processes using a local port, listeners first
+//	POST /kill/{pid}    kill a process
+func NewHandler(src Source) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /processes", func(w http.ResponseWriter, r *http.Request) {
+		procs, err := src.ScanProcesses()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse{err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, procs)
+	})
+
+	mux.HandleFunc("GET /ports/{port}", func(w http.ResponseWriter, r *http.Request) {
+		port, err := strconv.ParseUint(r.PathValue("port"), 10, 16)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{"invalid port"})
+			return
+		}
+		procs, err := src.ScanProcesses()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse{err.Error()})
+			return
+		}
+
+		var listeners, others []scanner.ProcessInfo
+		for _, p := range procs {
+			listening, using := false, false
+			for _, c := range p.Connections {
+				if c.Port == uint32(port) {
+					using = true
+					listening = listening || c.Status == "LISTEN"
+				}
+			}
+			switch {
+			case listening:
+				listeners = append(listeners, p)
+			case using:
+				others = append(others, p)
+			}
+		}
+		matches := append(listeners, others...)
+		if len(matches) == 0 {
+			writeJSON(w, http.StatusNotFound, errorResponse{"port not in use"})
+			return
+		}
+		writeJSON(w, http.StatusOK, matches)
+	})
+
+	mux.HandleFunc("POST /kill/{pid}", func(w http.ResponseWriter, r *http.Request) {
+		pid, err := strconv.ParseInt(r.PathValue("pid"), 10, 32)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{"invalid pid"})
+			return
+		}
+		if err := src.KillProcess(int32(pid)); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse{err.Error()})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
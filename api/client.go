@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"port-monitor/scanner"
+)
+
+// Client talks to a Handler, usually a privileged agent listening on a
+// unix socket, and can be used wherever a local scanner would be.
+type Client struct {
+	http *http.Client
+	base string
+}
+
+// NewUnixClient connects to an agent listening on a unix socket.
+func NewUnixClient(socketPath string) *Client {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}
+	return &Client{
+		http: &http.Client{Transport: transport, Timeout: 30 * time.Second},
+		base: "http://agent",
+	}
+}
+
+func (c *Client) ScanProcesses() ([]scanner.ProcessInfo, error) {
+	resp, err := c.http.Get(c.base + "/processes")
+	if err != nil {
+		return nil, fmt.Errorf("agent unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
+
+	var procs []scanner.ProcessInfo
+	if err := json.NewDecoder(resp.Body).Decode(&procs); err != nil {
+		return nil, fmt.Errorf("invalid agent response: %w", err)
+	}
+	return procs, nil
+}
+
+func (c *Client) KillProcess(pid int32) error {
+	resp, err := c.http.Post(fmt.Sprintf("%s/kill/%d", c.base, pid), "", nil)
+	if err != nil {
+		return fmt.Errorf("agent unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	return checkStatus(resp)
+}
+
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	var e errorResponse
+	if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Error != "" {
+		return fmt.Errorf("agent: %s", e.Error)
+	}
+	return fmt.Errorf("agent: %s", resp.Status)
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"port-monitor/scanner"
+)
+
+// Hub fans scan events out to every connected WebSocket client.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[chan []byte]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[chan []byte]struct{})}
+}
+
+// Publish sends events to all clients. Clients that fall behind are
+// disconnected rather than slowing down the scan loop.
+func (h *Hub) Publish(events []scanner.Event) {
+	if len(events) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, e := range events {
+		msg, err := json.Marshal(e)
+		if err != nil {
+			continue
+		}
+		for ch := range h.subs {
+			select {
+			case ch <- msg:
+			default:
+				delete(h.subs, ch)
+				close(ch)
+			}
+		}
+	}
+}
+
+func (h *Hub) subscribe() chan []byte {
+	ch := make(chan []byte, 64)
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch
+}
+
+func (h *Hub) unsubscribe(ch chan []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[ch]; ok {
+		delete(h.subs, ch)
+		close(ch)
+	}
+}
+
+// ServeHTTP upgrades the request to a WebSocket and streams one JSON
+// event per message until the client disconnects.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, rw, err := upgrade(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer conn.Close()
+
+	ch := h.subscribe()
+	defer h.unsubscribe(ch)
+
+	var writeMu sync.Mutex
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			op, payload, err := readFrame(rw)
+			if err != nil || op == opClose {
+				return
+			}
+			if op == opPing {
+				writeMu.Lock()
+				writeFrame(rw, opPong, payload)
+				writeMu.Unlock()
+			}
+		}
+	}()
+
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			writeMu.Lock()
+			err := writeFrame(rw, opText, msg)
+			writeMu.Unlock()
+			if err != nil {
+				return
+			}
+		case <-done:
+			writeMu.Lock()
+			writeFrame(rw, opClose, nil)
+			writeMu.Unlock()
+			return
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// NewToken returns a random token for Guard.
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Guard protects a handler served on a TCP port. Any web page can make the
+// browser send requests to localhost, so requests need the token, as an
+// "Authorization: Bearer" header or a token query parameter (browsers can't
+// set headers on WebSockets), and their Host and Origin must be loopback,
+// which also defeats DNS rebinding.
+func Guard(h http.Handler, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !loopbackHost(r.Host) {
+			writeJSON(w, http.StatusForbidden, errorResponse{"host not allowed"})
+			return
+		}
+		if !allowedOrigin(r) {
+			writeJSON(w, http.StatusForbidden, errorResponse{"origin not allowed"})
+			return
+		}
+		if !validToken(r, token) {
+			writeJSON(w, http.StatusUnauthorized, errorResponse{"missing or invalid token"})
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func validToken(r *http.Request, token string) bool {
+	got := r.URL.Query().Get("token")
+	if auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		got = auth
+	}
+	return got != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// allowedOrigin reports whether a request comes from no web page at all,
+// like curl, or from one served on a loopback address.
+func allowedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && loopbackHost(u.Host)
+}
+
+// loopbackHost reports whether a Host header names this machine by a
+// loopback address or "localhost".
+func loopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Just enough of RFC 6455 to push text messages to browsers and notice
+// when they go away; there are no client-to-server messages to handle.
+
+const (
+	wsGUID     = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	opText     = 0x1
+	opClose    = 0x8
+	opPing     = 0x9
+	opPong     = 0xA
+	finBit     = 0x80
+	maskBit    = 0x80
+	maxPayload = 1 << 20
+)
+
+func upgrade(w http.ResponseWriter, r *http.Request) (net.Conn, *bufio.ReadWriter, error) {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return nil, nil, errors.New("not a websocket request")
+	}
+	// Browsers don't apply the same-origin policy to WebSockets
+	if !allowedOrigin(r) {
+		return nil, nil, errors.New("origin not allowed")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, nil, errors.New("missing Sec-WebSocket-Key")
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("connection can't be hijacked")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sum := sha1.Sum([]byte(key + wsGUID))
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	rw.WriteString("Upgrade: websocket\r\nConnection: Upgrade\r\n")
+	rw.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, rw, nil
+}
+
+// writeFrame sends an unmasked frame, as servers must.
+func writeFrame(w *bufio.ReadWriter, op byte, payload []byte) error {
+	w.WriteByte(finBit | op)
+	switch n := len(payload); {
+	case n < 126:
+		w.WriteByte(byte(n))
+	case n <= 0xFFFF:
+		w.WriteByte(126)
+		binary.Write(w, binary.BigEndian, uint16(n))
+	default:
+		w.WriteByte(127)
+		binary.Write(w, binary.BigEndian, uint64(n))
+	}
+	w.Write(payload)
+	return w.Flush()
+}
+
+// readFrame reads one (masked) client frame.
+func readFrame(r *bufio.ReadWriter) (byte, []byte, error) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0, nil, err
+	}
+	op := hdr[0] & 0x0F
+	n := uint64(hdr[1] &^ maskBit)
+	switch n {
+	case 126:
+		var ext uint16
+		if err := binary.Read(r, binary.BigEndian, &ext); err != nil {
+			return 0, nil, err
+		}
+		n = uint64(ext)
+	case 127:
+		if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+			return 0, nil, err
+		}
+	}
+	if n > maxPayload {
+		return 0, nil, errors.New("frame too large")
+	}
+
+	var mask [4]byte
+	if hdr[1]&maskBit != 0 {
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return op, payload, nil
+}
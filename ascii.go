@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// asciiOnly swaps box drawing, spinner, marker and ellipsis glyphs for ASCII
+// ones, for terminals that would show Unicode as mojibake (some SSH and
+// serial sessions). Set with --ascii, see setASCII.
+var asciiOnly bool
+
+// unicodeTerminal guesses whether the terminal can show Unicode: not a dumb
+// or vt100-like terminal, and not a locale with an explicit non UTF-8
+// charset such as C or ISO-8859-1. No locale at all counts as UTF-8, which
+// modern terminals default to.
+func unicodeTerminal() bool {
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	term := os.Getenv("TERM")
+	if term == "dumb" || strings.HasPrefix(term, "vt") {
+		return false
+	}
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			v = strings.ToLower(v)
+			return strings.Contains(v, "utf-8") || strings.Contains(v, "utf8")
+		}
+	}
+	return true
+}
+
+// setASCII switches the UI to ASCII glyphs. It must run before the model
+// is created, since that copies some of the styles.
+func setASCII() {
+	asciiOnly = true
+	baseStyle = baseStyle.BorderStyle(lipgloss.ASCIIBorder())
+	tabStyle = tabStyle.Border(lipgloss.ASCIIBorder(), false, false, true, false)
+	activeTabStyle = activeTabStyle.Border(lipgloss.ASCIIBorder(), false, false, true, false)
+	modalStyle = modalStyle.Border(lipgloss.ASCIIBorder())
+	sparkBlocks = []rune("_.:-=+*#")
+}
+
+// glyph picks the Unicode or the ASCII variant of a symbol.
+func glyph(unicode, ascii string) string {
+	if asciiOnly {
+		return ascii
+	}
+	return unicode
+}
+
+// ellipsis marks cut off text.
+func ellipsis() string {
+	return glyph("…", "...")
+}
+
+// ellipsize cuts s to width runes, ending it with an ellipsis when cut.
+func ellipsize(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	e := ellipsis()
+	if width <= len(e) {
+		return string(runes[:max(width, 0)])
+	}
+	return string(runes[:width-len(e)]) + e
+}
+
+// asciiRow cuts every cell to its column width, so the table doesn't cut
+// them itself with a Unicode ellipsis.
+func asciiRow(row table.Row, cols []table.Column) {
+	for i := range row {
+		if i < len(cols) {
+			row[i] = ellipsize(row[i], cols[i].Width)
+		}
+	}
+}
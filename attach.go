@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"port-monitor/scanner"
+)
+
+type attachDoneMsg struct {
+	err error
+}
+
+// defaultAttach are the debugger/tracer commands per runtime, "default"
+// being used for any other process. {pid} is replaced by the PID.
+func defaultAttach() map[string]string {
+	cmds := map[string]string{
+		"go":   "dlv attach {pid}",
+		"node": "node inspect -p {pid}",
+	}
+	switch runtime.GOOS {
+	case "linux":
+		cmds["default"] = "strace -f -p {pid}"
+	case "darwin":
+		cmds["default"] = "dtruss -f -p {pid}"
+	}
+	return cmds
+}
+
+// attachCommand picks the command to attach to p: a config entry for its
+// runtime, then the built-in one, then the default entry. Tracing
+// processes of other users needs root, so sudo is added for them.
+func attachCommand(p scanner.ProcessInfo, custom map[string]string) (string, error) {
+	var tmpl string
+	builtin := defaultAttach()
+	for _, cmds := range []map[string]string{custom, builtin} {
+		if p.Runtime != "" && cmds[p.Runtime] != "" {
+			tmpl = cmds[p.Runtime]
+			break
+		}
+	}
+	if tmpl == "" {
+		tmpl = custom["default"]
+	}
+	if tmpl == "" {
+		tmpl = builtin["default"]
+	}
+	if tmpl == "" {
+		return "", fmt.Errorf("no debugger configured for %s on %s, add one under \"attach\" in the config file", p.Name, runtime.GOOS)
+	}
+
+	cmd := strings.ReplaceAll(tmpl, "{pid}", fmt.Sprint(p.PID))
+	tool := strings.Fields(cmd)[0]
+	if _, err := exec.LookPath(tool); err != nil {
+		return "", fmt.Errorf("%s is not installed", tool)
+	}
+	// dtruss always needs root, strace and friends only for other users
+	needsRoot := p.Type == scanner.SystemProcess || tool == "dtruss"
+	if needsRoot && runtime.GOOS != "windows" && os.Geteuid() != 0 && tool != "sudo" {
+		cmd = "sudo " + cmd
+	}
+	return cmd, nil
+}
+
+// debugAttach is a debugger command waiting for confirmation.
+type debugAttach struct {
+	name    string
+	pid     int32
+	command string
+}
+
+// attachCmd suspends the TUI and runs the debugger in this terminal, until
+// it exits and Enter is pressed.
+func attachCmd(command string) tea.Cmd {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/c", command+" & pause")
+	} else {
+		// As for captures, the trap keeps the shell alive when Ctrl+C detaches
+		script := `trap : INT; echo "$ $CMD"; sh -c "$CMD"; printf "\nPress Enter to return to port-monitor"; read _`
+		cmd = exec.Command("sh", "-c", script)
+		cmd.Env = append(os.Environ(), "CMD="+command)
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return attachDoneMsg{err: err}
+	})
+}
+
+// attachInWindow runs the debugger in a new terminal window, leaving the
+// TUI running: Terminal.app on macOS, $TERMINAL or x-terminal-emulator on
+// Linux, a console window on Windows.
+func attachInWindow(command string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf(`tell application "Terminal" to do script %q`, command)
+		cmd = exec.Command("osascript", "-e", script, "-e", `tell application "Terminal" to activate`)
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "start", "cmd", "/k", command)
+	default:
+		term := os.Getenv("TERMINAL")
+		if term == "" {
+			term = "x-terminal-emulator"
+		}
+		if _, err := exec.LookPath(term); err != nil {
+			return fmt.Errorf("no terminal emulator found, set $TERMINAL")
+		}
+		cmd = exec.Command(term, "-e", "sh", "-c", command+`; printf "\nPress Enter to close"; read _`)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open a terminal: %w", err)
+	}
+	go cmd.Wait()
+	return nil
+}
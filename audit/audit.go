@@ -0,0 +1,42 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Entry records an action taken automatically on the user's behalf.
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	PID    int32     `json:"pid"`
+	Name   string    `json:"name"`
+	Port   uint32    `json:"port,omitempty"`
+	Reason string    `json:"reason"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// DefaultPath returns the audit log location inside the user's cache dir.
+func DefaultPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find cache dir: %w", err)
+	}
+	return filepath.Join(dir, "port-monitor", "audit.jsonl"), nil
+}
+
+// Append writes e as one JSON line at the end of the audit log.
+func Append(path string, e Entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create audit dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(e)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type captureDoneMsg struct {
+	file string
+	err  error
+}
+
+// captureCmd suspends the TUI and runs tcpdump on port until Ctrl+C,
+// printing packets or, when toFile is set, writing them to a pcap file in
+// the user cache dir.
+func captureCmd(port uint32, toFile bool) (tea.Cmd, error) {
+	if runtime.GOOS == "windows" {
+		return nil, fmt.Errorf("packet capture needs tcpdump, which is not available on Windows")
+	}
+	tcpdump, err := exec.LookPath("tcpdump")
+	if err != nil {
+		return nil, fmt.Errorf("packet capture needs tcpdump: %w", err)
+	}
+
+	args := []string{tcpdump, "-i", "any", "-nn"}
+	var file string
+	if toFile {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find cache dir: %w", err)
+		}
+		dir = filepath.Join(dir, "port-monitor")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create capture dir: %w", err)
+		}
+		file = filepath.Join(dir, fmt.Sprintf("capture-%d-%s.pcap", port, time.Now().Format("20060102-150405")))
+		// tcpdump drops to its own user before opening the file, which
+		// can't write to our cache dir, so have it drop to us instead
+		me, err := user.Current()
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up current user: %w", err)
+		}
+		args = append(args, "-Z", me.Username, "-w", file)
+	}
+	args = append(args, "port", fmt.Sprint(port))
+	if os.Geteuid() != 0 {
+		args = append([]string{"sudo"}, args...)
+	}
+
+	// The trap keeps the shell alive when Ctrl+C stops tcpdump, so the
+	// output stays readable until Enter is pressed. A trap handler, unlike
+	// an ignored signal, isn't inherited by tcpdump.
+	script := `trap : INT; echo "Capturing port $PORT, Ctrl+C to stop"; "$@"; printf "\nPress Enter to return to port-monitor"; read _`
+	cmd := exec.Command("sh", append([]string{"-c", script, "sh"}, args...)...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("PORT=%d", port))
+
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return captureDoneMsg{file: file, err: err}
+	}), nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"port-monitor/scanner"
+)
+
+// cgroupTTL is how long the limits of the selected process are reused
+// between renders.
+const cgroupTTL = 2 * time.Second
+
+type cgroupEntry struct {
+	cg  scanner.Cgroup
+	err error
+	at  time.Time
+}
+
+// cgroupCache holds the cgroup of recently selected processes.
+type cgroupCache struct {
+	mu      sync.Mutex
+	entries map[int32]cgroupEntry
+}
+
+func newCgroupCache() *cgroupCache {
+	return &cgroupCache{entries: make(map[int32]cgroupEntry)}
+}
+
+// of returns the cgroup of pid, reading it again once cgroupTTL passed.
+func (c *cgroupCache) of(pid int32) (scanner.Cgroup, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[pid]; ok && time.Since(e.at) < cgroupTTL {
+		return e.cg, e.err
+	}
+	cg, err := scanner.CgroupOf(pid)
+	if len(c.entries) > 64 {
+		clear(c.entries)
+	}
+	c.entries[pid] = cgroupEntry{cg: cg, err: err, at: time.Now()}
+	return cg, err
+}
+
+// cgroupLine describes the cgroup of p and the limits it runs under for
+// the detail pane, e.g. "Cgroup: /system.slice/nginx.service, Mem limit
+// 512 MB (61% used), CPU quota 0.5, throttled 1204 times (3.2s)".
+func (m *model) cgroupLine(p scanner.ProcessInfo) string {
+	if m.cgroups == nil || m.host != "" || m.replaying {
+		return ""
+	}
+	cg, err := m.cgroups.of(p.PID)
+	if err != nil || cg.Path == "" {
+		return ""
+	}
+	parts := []string{"Cgroup: " + cg.Path}
+	if cg.MemoryLimit > 0 {
+		mem := "Mem limit " + formatBytes(cg.MemoryLimit)
+		if cg.MemoryUsage > 0 {
+			mem += fmt.Sprintf(" (%.0f%% used)", 100*float64(cg.MemoryUsage)/float64(cg.MemoryLimit))
+		}
+		parts = append(parts, mem)
+	}
+	if cg.CPUQuota > 0 {
+		cpu := fmt.Sprintf("CPU quota %.2g", cg.CPUQuota)
+		if cg.Throttled > 0 {
+			cpu += fmt.Sprintf(", throttled %d times (%s)", cg.Throttled, cg.ThrottledTime.Round(100*time.Millisecond))
+		}
+		parts = append(parts, cpu)
+	}
+	if len(parts) == 1 {
+		parts = append(parts, "no memory or CPU limit")
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"port-monitor/health"
+)
+
+type healthMsg []health.Result
+
+func healthCmd(checks []health.Check) tea.Cmd {
+	return func() tea.Msg {
+		return healthMsg(health.RunAll(checks))
+	}
+}
+
+// healthMark is appended to a checked port in the Ports column.
+func healthMark(s health.Status) string {
+	switch s {
+	case health.Up:
+		return glyph("✓", "*")
+	case health.Degraded:
+		return "~"
+	case health.Down:
+		return glyph("✗", "x")
+	}
+	return ""
+}
+
+// healthNote describes a check result in the detail pane.
+func healthNote(r health.Result) string {
+	return fmt.Sprintf("health: %s %s (%s)", r.Status, r.Latency.Round(time.Millisecond), r.Detail)
+}
+
+var healthColors = map[health.Status]lipgloss.Color{
+	health.Up:       "42",
+	health.Degraded: "214",
+	health.Down:     "196",
+}
+
+// renderHealth summarizes the checks for the status bar, colored by the
+// worst result.
+func renderHealth(results map[uint32]health.Result) string {
+	if len(results) == 0 {
+		return ""
+	}
+	var up int
+	worst := health.Up
+	for _, r := range results {
+		if r.Status == health.Up {
+			up++
+		}
+		worst = max(worst, r.Status)
+	}
+	return lipgloss.NewStyle().Foreground(healthColors[worst]).Render(fmt.Sprintf("Health: %d/%d up", up, len(results)))
+}
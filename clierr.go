@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// Exit codes of the non-interactive subcommands, so scripts can branch
+// on the kind of failure.
+const (
+	exitError       = 1 // Anything else
+	exitUsage       = 2 // Bad flags or arguments, as the flag package uses
+	exitPortBusy    = 3 // A port to bind is taken
+	exitPermission  = 4 // Not allowed, retry with sudo
+	exitNoMatch     = 5 // No process, port or free port matched
+	exitPartialKill = 6 // Some processes were killed, others not
+	exitTimeout     = 7 // wait gave up
+)
+
+var exitKinds = map[int]string{
+	exitError:       "error",
+	exitUsage:       "usage",
+	exitPortBusy:    "port_busy",
+	exitPermission:  "permission_denied",
+	exitNoMatch:     "no_match",
+	exitPartialKill: "partial_kill",
+	exitTimeout:     "timeout",
+}
+
+// cliError carries the exit code of a failed subcommand.
+type cliError struct {
+	code int
+	err  error
+}
+
+func (e *cliError) Error() string { return e.err.Error() }
+func (e *cliError) Unwrap() error { return e.err }
+
+func withCode(code int, err error) error {
+	return &cliError{code: code, err: err}
+}
+
+func usageError(format string, args ...any) error {
+	return withCode(exitUsage, fmt.Errorf(format, args...))
+}
+
+// exitCode picks the exit code of err: the one it was given, or one
+// derived from the underlying OS error.
+func exitCode(err error) int {
+	var ce *cliError
+	switch {
+	case errors.As(err, &ce):
+		return ce.code
+	case errors.Is(err, syscall.EADDRINUSE):
+		return exitPortBusy
+	case errors.Is(err, fs.ErrPermission), errors.Is(err, syscall.EPERM), errors.Is(err, syscall.EACCES):
+		return exitPermission
+	}
+	return exitError
+}
+
+// exitWithError reports a subcommand failure, as JSON on stderr with
+// --json-errors, and exits with its code.
+func exitWithError(err error, jsonErrors bool) {
+	code := exitCode(err)
+	if jsonErrors {
+		json.NewEncoder(os.Stderr).Encode(struct {
+			Error string `json:"error"`
+			Kind  string `json:"kind"`
+			Code  int    `json:"code"`
+		}{err.Error(), exitKinds[code], code})
+	} else {
+		fmt.Println("Error:", err)
+	}
+	os.Exit(code)
+}
+
+// stripFlag removes a boolean flag given anywhere among args, so
+// --json-errors works before or after the subcommand's own flags.
+func stripFlag(args []string, name string) ([]string, bool) {
+	out := args[:0:0]
+	found := false
+	for _, a := range args {
+		if a == "-"+name || a == "--"+name {
+			found = true
+			continue
+		}
+		out = append(out, a)
+	}
+	return out, found
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"port-monitor/config"
+	"port-monitor/scanner"
+)
+
+// column is a table column after the fixed X, PID, Name and Ports ones,
+// which the column chooser can show, hide and move.
+type column struct {
+	id    string // Config name
+	title string
+	width int
+	cell  func(m *model, p scanner.ProcessInfo) string
+}
+
+var columnDefs = []column{
+	{"cpu", "CPU%", 6, func(m *model, p scanner.ProcessInfo) string { return fmt.Sprintf("%.1f%%", m.cpuShare(p)) }},
+	{"mem", "Mem", 10, func(_ *model, p scanner.ProcessInfo) string { return formatBytes(p.MemoryUsage) }},
+	{"mem%", "Mem%", 6, func(_ *model, p scanner.ProcessInfo) string { return fmt.Sprintf("%.1f%%", p.MemoryPercent) }},
+	{"swap", "Swap", 10, func(_ *model, p scanner.ProcessInfo) string { return formatBytes(p.SwapUsage) }},
+	{"io", "IO", 10, func(_ *model, p scanner.ProcessInfo) string { return formatBytes(p.DiskRead + p.DiskWrite) }},
+	{"conn/s", "Conn/s", 7, func(m *model, p scanner.ProcessInfo) string { return formatRate(m.connRate.of(p.PID)) }},
+	{"type", "Type", 8, func(_ *model, p scanner.ProcessInfo) string { return kindLabel(p) }},
+	{"user", "User", 10, func(_ *model, p scanner.ProcessInfo) string { return p.User }},
+	{"state", "State", 8, func(_ *model, p scanner.ProcessInfo) string { return p.State }},
+	{"manager", "Manager", 16, func(_ *model, p scanner.ProcessInfo) string { return managerLabel(p) }},
+	{"age", "Age", 8, func(_ *model, p scanner.ProcessInfo) string {
+		if p.Started.IsZero() {
+			return ""
+		}
+		return formatDuration(time.Since(p.Started))
+	}},
+	{"threads", "Threads", 7, func(_ *model, p scanner.ProcessInfo) string {
+		if p.Threads == 0 {
+			return ""
+		}
+		return strconv.Itoa(int(p.Threads))
+	}},
+	{"conns", "Conns", 6, func(_ *model, p scanner.ProcessInfo) string { return strconv.Itoa(len(p.Connections)) }},
+}
+
+// cpuCores is what CPU percentages are divided by: the number of cores
+// when shown as a share of all of them, 1 when as a share of one. Only
+// this machine's core count is known.
+func (m *model) cpuCores() int {
+	if !m.cpuAllCores || m.host != "" {
+		return 1
+	}
+	return runtime.NumCPU()
+}
+
+// cpuShare is p's CPU use in percent of one core, which exceeds 100% for
+// multithreaded processes, or of all cores.
+func (m *model) cpuShare(p scanner.ProcessInfo) float64 {
+	return p.CPUPercent / float64(m.cpuCores())
+}
+
+// cpuLabel is cpuShare for the detail pane, saying which one it is.
+func (m *model) cpuLabel(p scanner.ProcessInfo) string {
+	if n := m.cpuCores(); n > 1 {
+		return fmt.Sprintf("%.1f%% of %d cores", m.cpuShare(p), n)
+	}
+	return fmt.Sprintf("%.1f%%", m.cpuShare(p))
+}
+
+// columnSorts maps the columns that can be sorted by to their sort mode.
+var columnSorts = map[string]int{"cpu": SortCPU, "mem": SortMem, "io": SortIO, "conn/s": SortConnRate, "user": SortUser}
+
+var defaultColumns = []string{"cpu", "mem", "io", "conn/s", "type", "state", "manager"}
+
+func columnDef(id string) (column, bool) {
+	i := slices.IndexFunc(columnDefs, func(c column) bool { return c.id == id })
+	if i < 0 {
+		return column{}, false
+	}
+	return columnDefs[i], true
+}
+
+// knownColumns drops unknown and repeated ids, e.g. from a hand-edited
+// config file.
+func knownColumns(ids []string) []string {
+	var out []string
+	for _, id := range ids {
+		id = strings.ToLower(id)
+		if _, ok := columnDef(id); ok && !slices.Contains(out, id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// visibleColumns are the shown columns in order.
+func (m *model) visibleColumns() []column {
+	cols := make([]column, 0, len(m.columns))
+	for _, id := range m.columns {
+		if c, ok := columnDef(id); ok {
+			cols = append(cols, c)
+		}
+	}
+	return cols
+}
+
+// setColumns changes the shown columns. Rows may never have more cells
+// than there are columns, so rows are rebuilt first when columns go away
+// and last when they are added.
+func (m *model) setColumns(ids []string) {
+	grew := len(ids) > len(m.columns)
+	m.columns = ids
+	if grew {
+		m.layoutColumns()
+		m.updateTable()
+	} else {
+		m.updateTable()
+		m.layoutColumns()
+	}
+}
+
+// sortByColumn sorts by the nth shown column, PID being the first, or
+// reverses the order when it's the sort column already. Numbers come
+// first in descending order, names in ascending.
+func (m *model) sortByColumn(n int) tea.Cmd {
+	var shown []int
+	for i, c := range m.table.Columns() {
+		if i > 0 && c.Width > 0 { // Not the selection marks
+			shown = append(shown, i)
+		}
+	}
+	if n < 1 || n > len(shown) {
+		return nil
+	}
+	i := shown[n-1]
+	var mode int
+	switch i {
+	case 1:
+		mode = SortPID
+	case 2:
+		mode = SortName
+	case 3:
+		mode = SortPorts
+	default:
+		c := m.visibleColumns()[i-4]
+		var ok bool
+		if mode, ok = columnSorts[c.id]; !ok {
+			return m.notify(fmt.Sprintf("Can't sort by %s.", c.title))
+		}
+	}
+	if mode == m.sortBy {
+		m.sortDesc = !m.sortDesc
+	} else {
+		m.sortBy = mode
+		m.sortDesc = mode != SortPID && mode != SortName && mode != SortUser
+	}
+	m.updateTable()
+	m.layoutColumns()
+	return nil
+}
+
+// sortColumn is the index of the table column sorted by, or -1 when it
+// isn't shown.
+func (m *model) sortColumn() int {
+	switch m.sortBy {
+	case SortPID:
+		return 1
+	case SortName:
+		return 2
+	case SortPorts:
+		return 3
+	}
+	for j, c := range m.visibleColumns() {
+		if mode, ok := columnSorts[c.id]; ok && mode == m.sortBy {
+			return 4 + j
+		}
+	}
+	return -1
+}
+
+// chooserColumns lists every column for the chooser: the shown ones in
+// order, then the hidden ones.
+func (m *model) chooserColumns() []string {
+	ids := slices.Clone(m.columns)
+	for _, c := range columnDefs {
+		if !slices.Contains(ids, c.id) {
+			ids = append(ids, c.id)
+		}
+	}
+	return ids
+}
+
+// chooseColumn handles a key in the column chooser. It reports whether
+// the columns changed.
+func (m *model) chooseColumn(key string) bool {
+	ids := m.chooserColumns()
+	id := ids[m.columnCursor]
+	shown := slices.Index(m.columns, id)
+	switch key {
+	case "up", "k":
+		m.columnCursor = max(m.columnCursor-1, 0)
+	case "down", "j":
+		m.columnCursor = min(m.columnCursor+1, len(ids)-1)
+	case " ", "x":
+		if shown >= 0 {
+			m.setColumns(slices.Delete(slices.Clone(m.columns), shown, shown+1))
+		} else {
+			m.setColumns(append(slices.Clone(m.columns), id))
+		}
+		m.columnCursor = slices.Index(m.chooserColumns(), id)
+		return true
+	case "K", "shift+up", "J", "shift+down":
+		to := shown - 1
+		if key == "J" || key == "shift+down" {
+			to = shown + 1
+		}
+		if shown < 0 || to < 0 || to >= len(m.columns) {
+			return false
+		}
+		cols := slices.Clone(m.columns)
+		cols[shown], cols[to] = cols[to], cols[shown]
+		m.setColumns(cols)
+		m.columnCursor = to
+		return true
+	case "r":
+		m.setColumns(slices.Clone(defaultColumns))
+		m.columnCursor = 0
+		return true
+	}
+	return false
+}
+
+// saveColumns writes the shown columns to the config file.
+func (m *model) saveColumns() error {
+	path := m.configPath
+	if path == "" {
+		var err error
+		if path, err = config.DefaultPath(); err != nil {
+			return err
+		}
+	}
+	return config.SaveColumns(path, m.columns)
+}
+
+// columnsView is the column chooser modal.
+func (m *model) columnsView() string {
+	var b strings.Builder
+	b.WriteString("Columns (X, PID, Name and Ports are always shown)\n")
+	for i, id := range m.chooserColumns() {
+		c, _ := columnDef(id)
+		cursor, check := "  ", " "
+		if i == m.columnCursor {
+			cursor = glyph("▸ ", "> ")
+		}
+		if slices.Contains(m.columns, id) {
+			check = "x"
+		}
+		fmt.Fprintf(&b, "\n%s[%s] %s", cursor, check, c.title)
+	}
+	return b.String()
+}
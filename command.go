@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Lines the command takes in the detail pane, fixed so the layout doesn't
+// jump as the selection moves.
+const (
+	cmdWrapRows   = 3
+	cmdPerArgRows = 10
+)
+
+var (
+	cmdTextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	cmdFlagStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("75"))
+	cmdPathStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("114"))
+)
+
+// commandRows is how many lines renderCommand returns.
+func commandRows(perArg bool) int {
+	if perArg {
+		return cmdPerArgRows
+	}
+	return cmdWrapRows
+}
+
+// renderCommand lays out a command line in the detail pane, prefixed with
+// "Command: ". By default arguments are wrapped to width; perArg puts
+// each argument on its own line, keeping a flag and its value together.
+// Flags and file paths are highlighted. Every token is styled, since an
+// inner style resets the color of the surrounding footer.
+func renderCommand(cmd string, width int, perArg bool) string {
+	const prefix = "Command: "
+	indent := strings.Repeat(" ", len(prefix))
+	rows := commandRows(perArg)
+	width = max(width-len(prefix), 20)
+
+	var lines [][]string // Tokens per line
+	var lineLen []int
+	if perArg {
+		for _, arg := range groupArgs(strings.Fields(cmd)) {
+			lines = append(lines, arg)
+			lineLen = append(lineLen, 0)
+		}
+	} else {
+		for _, tok := range strings.Fields(cmd) {
+			n := len(lines) - 1
+			if n < 0 || (lineLen[n] > 0 && lineLen[n]+1+len(tok) > width) {
+				lines = append(lines, nil)
+				lineLen = append(lineLen, 0)
+				n++
+			}
+			if lineLen[n] > 0 {
+				lineLen[n]++
+			}
+			lines[n] = append(lines[n], tok)
+			lineLen[n] += len(tok)
+		}
+	}
+
+	var more string
+	if len(lines) > rows {
+		more = fmt.Sprintf("%s %d more lines, [c] to switch layout", ellipsis(), len(lines)-rows+1)
+		if perArg {
+			more = fmt.Sprintf("%s %d more arguments", ellipsis(), len(lines)-rows+1)
+		}
+		lines = lines[:rows-1]
+	}
+
+	out := make([]string, 0, rows)
+	for i, toks := range lines {
+		styled := make([]string, len(toks))
+		for j, tok := range toks {
+			styled[j] = highlightArg(tok, width)
+		}
+		lead := indent
+		if i == 0 {
+			lead = prefix
+		}
+		out = append(out, cmdTextStyle.Render(lead)+strings.Join(styled, cmdTextStyle.Render(" ")))
+	}
+	if len(out) == 0 {
+		out = append(out, cmdTextStyle.Render(prefix))
+	}
+	if more != "" {
+		out = append(out, cmdTextStyle.Render(indent+more))
+	}
+	for len(out) < rows {
+		out = append(out, "")
+	}
+	return strings.Join(out, "\n")
+}
+
+// groupArgs splits args into lines, keeping "--flag value" pairs together.
+func groupArgs(args []string) [][]string {
+	var lines [][]string
+	for i := 0; i < len(args); i++ {
+		line := []string{args[i]}
+		if isFlag(args[i]) && !strings.Contains(args[i], "=") && i+1 < len(args) && !isFlag(args[i+1]) {
+			line = append(line, args[i+1])
+			i++
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// highlightArg styles a flag, a path, or a --flag=path pair. Arguments
+// longer than width (a wrapped line) are cut.
+func highlightArg(arg string, width int) string {
+	arg = ellipsize(arg, width)
+	if isFlag(arg) {
+		if name, value, ok := strings.Cut(arg, "="); ok {
+			return cmdFlagStyle.Render(name+"=") + highlightArg(value, width)
+		}
+		return cmdFlagStyle.Render(arg)
+	}
+	if isPath(arg) {
+		return cmdPathStyle.Render(arg)
+	}
+	return cmdTextStyle.Render(arg)
+}
+
+func isFlag(arg string) bool {
+	return len(arg) > 1 && arg[0] == '-' && (arg[1] < '0' || arg[1] > '9')
+}
+
+func isPath(arg string) bool {
+	if strings.HasPrefix(arg, "/") || strings.HasPrefix(arg, "./") || strings.HasPrefix(arg, "../") || strings.HasPrefix(arg, "~/") {
+		return true
+	}
+	// Windows drive paths: C:\Program Files\...
+	return len(arg) > 2 && arg[1] == ':' && (arg[2] == '\\' || arg[2] == '/')
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"port-monitor/health"
+	"port-monitor/rules"
+	"port-monitor/scanner"
+)
+
+// Config is the optional user configuration file.
+type Config struct {
+	Rules []rules.Rule `json:"rules"`
+	Watch []uint32     `json:"watch"` // Ports with a traffic sparkline in the TUI
+
+	// Checks run against local ports on every scan
+	Health []health.Check `json:"health"`
+
+	// Extra runtime detection rules, checked before the built-in ones
+	Runtimes []scanner.RuntimeRule `json:"runtimes"`
+
+	// Debugger/tracer command per runtime, plus "default" for the rest.
+	// {pid} is replaced by the process ID.
+	Attach map[string]string `json:"attach"`
+
+	// Security view heuristics by id, false turns one off: unsigned-exposed,
+	// temp-dir, name-mismatch, deleted-exe.
+	Heuristics map[string]bool `json:"heuristics"`
+
+	// Table columns after PID, Name and Ports, in order: cpu, mem, mem%,
+	// swap, io, conn/s, type, user, state, manager, age, threads, conns. The
+	// column chooser saves its changes here.
+	Columns []string `json:"columns"`
+
+	// When CPU% and Mem cells turn yellow and red
+	Heat Heat `json:"heat"`
+}
+
+// Heat are the thresholds of the green, yellow, red coloring of CPU% and
+// Mem cells: from the first a cell is yellow, from the second red. Zeros
+// keep the defaults.
+type Heat struct {
+	CPU [2]float64 `json:"cpu"` // Percent of one core
+	Mem [2]float64 `json:"mem"` // MB of RSS
+	Off bool       `json:"off"` // No coloring at all
+}
+
+// DefaultPath returns the config file location inside the user's config dir.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find config dir: %w", err)
+	}
+	return filepath.Join(dir, "port-monitor", "config.json"), nil
+}
+
+// Load reads the config file. A missing file is not an error and yields
+// an empty config.
+func Load(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	if err := rules.Validate(cfg.Rules); err != nil {
+		return cfg, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// SaveColumns sets the columns of the config file at path, leaving the
+// rest of it as it is. The file is created when missing.
+func SaveColumns(path string, columns []string) error {
+	doc := make(map[string]json.RawMessage)
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse config %s: %w", path, err)
+		}
+	}
+	cols, err := json.Marshal(columns)
+	if err != nil {
+		return err
+	}
+	doc["columns"] = cols
+	data, err = json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	return nil
+}
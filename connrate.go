@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"port-monitor/scanner"
+)
+
+type peerKey struct {
+	port       uint32
+	remoteAddr string
+	remotePort uint32
+}
+
+// connRate tracks how many connections each process accepted per second
+// between the last two scans: established sockets on one of its listening
+// ports that weren't there before. Connections opened and closed between
+// two scans are missed, so it's a lower bound.
+type connRate struct {
+	prev  map[int32]map[peerKey]struct{}
+	at    time.Time
+	rates map[int32]float64
+}
+
+func newConnRate() connRate {
+	return connRate{rates: make(map[int32]float64)}
+}
+
+// observe compares a scan taken at now with the previous one.
+func (r *connRate) observe(procs []scanner.ProcessInfo, now time.Time) {
+	curr := make(map[int32]map[peerKey]struct{}, len(procs))
+	for _, p := range procs {
+		if peers := acceptedPeers(p); len(peers) > 0 {
+			curr[p.PID] = peers
+		}
+	}
+
+	rates := make(map[int32]float64)
+	// Stepping back through history has no meaningful rate
+	if elapsed := now.Sub(r.at).Seconds(); r.prev != nil && elapsed > 0 {
+		for pid, peers := range curr {
+			fresh := 0
+			for k := range peers {
+				if _, ok := r.prev[pid][k]; !ok {
+					fresh++
+				}
+			}
+			if fresh > 0 {
+				rates[pid] = float64(fresh) / elapsed
+			}
+		}
+	}
+	r.prev, r.at, r.rates = curr, now, rates
+}
+
+// of returns the rate of pid.
+func (r connRate) of(pid int32) float64 {
+	return r.rates[pid]
+}
+
+// acceptedPeers returns the inbound connections of p, those on one of its
+// listening ports.
+func acceptedPeers(p scanner.ProcessInfo) map[peerKey]struct{} {
+	listening := make(map[uint32]bool)
+	for _, c := range p.Connections {
+		if c.Status == "LISTEN" {
+			listening[c.Port] = true
+		}
+	}
+	peers := make(map[peerKey]struct{})
+	for _, c := range p.Connections {
+		if c.Status == "ESTABLISHED" && listening[c.Port] {
+			peers[peerKey{c.Port, c.RemoteAddr, c.RemotePort}] = struct{}{}
+		}
+	}
+	return peers
+}
+
+// formatRate shows a connection rate, blank for processes without any.
+func formatRate(r float64) string {
+	if r == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.1f", r)
+}
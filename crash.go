@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// crashGuard wraps the TUI model to write panics with their stack to a
+// crash log before Bubble Tea restores the terminal, and with --debug to
+// trace every message to the debug log.
+type crashGuard struct {
+	model     tea.Model
+	crashPath string
+	debug     bool
+}
+
+func (g crashGuard) Init() tea.Cmd {
+	defer g.recover()
+	return g.guardCmd(g.model.Init())
+}
+
+func (g crashGuard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	defer g.recover()
+	start := time.Now()
+	var cmd tea.Cmd
+	g.model, cmd = g.model.Update(msg)
+	if g.debug {
+		switch msg := msg.(type) {
+		case spinner.TickMsg:
+			// Too frequent to be useful
+		case scanMsg:
+			log.Printf("scan of %d processes took %s, update in %s", len(msg.procs), msg.took, time.Since(start))
+		default:
+			log.Printf("update %T in %s", msg, time.Since(start))
+		}
+	}
+	return g, g.guardCmd(cmd)
+}
+
+func (g crashGuard) View() string {
+	defer g.recover()
+	return g.model.View()
+}
+
+// guardCmd wraps commands, and the commands of batches they return, which
+// run in their own goroutines.
+func (g crashGuard) guardCmd(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		defer g.recover()
+		msg := cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			for i := range batch {
+				batch[i] = g.guardCmd(batch[i])
+			}
+		}
+		return msg
+	}
+}
+
+// recover logs a panic and panics again, leaving the terminal to Bubble Tea.
+func (g crashGuard) recover() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	entry := fmt.Sprintf("%s panic: %v\n\n%s\n", time.Now().Format(time.RFC3339), r, debug.Stack())
+	if g.debug {
+		log.Print(entry)
+	}
+	if f, err := os.OpenFile(g.crashPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644); err == nil {
+		f.WriteString(entry)
+		f.Close()
+	}
+	panic(r)
+}
+
+// logPath returns a log file location inside the user cache dir.
+func logPath(name string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find cache dir: %w", err)
+	}
+	dir = filepath.Join(dir, "port-monitor")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create log dir: %w", err)
+	}
+	return filepath.Join(dir, name), nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"port-monitor/audit"
+	"port-monitor/eventlog"
+	"port-monitor/history"
+	"port-monitor/metrics"
+	"port-monitor/scanner"
+)
+
+// runDaemon scans in the background without a UI and records every scan
+// to the history file, so it can be replayed later with --replay.
+// Auto-kill rules from the config file are enforced on each scan, and
+// metrics are optionally shipped to StatsD or an OTLP collector.
+func runDaemon(args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	interval := fs.Duration("interval", 10*time.Second, "time between scans")
+	historyPath := fs.String("history", "", "history file (default: user cache dir)")
+	configPath := fs.String("config", "", "config file (default: user config dir)")
+	statsdAddr := fs.String("statsd", "", "send metrics to this StatsD address (host:8125)")
+	otlpEndpoint := fs.String("otlp", "", "send metrics to this OTLP/HTTP collector (http://host:4318)")
+	logEvents := fs.String("log-events", "", "send port open/close and kill events to syslog or journald")
+	fs.Parse(args)
+
+	var events eventlog.Logger
+	if *logEvents != "" {
+		l, err := eventlog.Open(*logEvents)
+		if err != nil {
+			return err
+		}
+		defer l.Close()
+		events = l
+	}
+
+	var sinks []metrics.Sink
+	if *statsdAddr != "" {
+		s, err := metrics.NewStatsD(*statsdAddr)
+		if err != nil {
+			return err
+		}
+		sinks = append(sinks, s)
+	}
+	if *otlpEndpoint != "" {
+		sinks = append(sinks, metrics.NewOTLP(*otlpEndpoint))
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	auditPath, err := audit.DefaultPath()
+	if err != nil {
+		return err
+	}
+
+	path, err := resolveHistoryPath(*historyPath)
+	if err != nil {
+		return err
+	}
+	rec, err := history.NewRecorder(path)
+	if err != nil {
+		return err
+	}
+	defer rec.Close()
+
+	log.Printf("recording to %s every %s", path, *interval)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	src := newLocalSource(scanner.Options{})
+	enforcer := newRuleEnforcer(cfg.Rules)
+	var prev []scanner.ProcessInfo
+	for {
+		procs, err := src.ScanProcesses()
+		if err != nil {
+			log.Printf("scan failed: %v", err)
+		} else {
+			if events != nil && prev != nil {
+				logPortEvents(events, prev, procs)
+			}
+			prev = procs
+
+			if err := rec.Record(time.Now(), procs); err != nil {
+				log.Printf("record failed: %v", err)
+			}
+			for _, sink := range sinks {
+				if err := sink.Emit(procs, time.Now()); err != nil {
+					log.Printf("metrics failed: %v", err)
+				}
+			}
+			for _, msg := range enforcer.enforce(src, "", procs, auditPath, events) {
+				log.Print(msg)
+			}
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// logPortEvents sends listening ports opened or closed since the previous
+// scan to the event log.
+func logPortEvents(events eventlog.Logger, prev, curr []scanner.ProcessInfo) {
+	for _, e := range scanner.Diff(prev, curr, time.Now()) {
+		if e.Type == scanner.PortOpened || e.Type == scanner.PortClosed {
+			events.Log(e)
+		}
+	}
+}
+
+func resolveHistoryPath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	return history.DefaultPath()
+}
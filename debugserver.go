@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"port-monitor/scanner"
+)
+
+const inspectorTimeout = 2 * time.Second
+
+type attachURLMsg struct {
+	text string
+	err  error
+}
+
+// debugServer names the debugger listening on c, if it is one.
+func debugServer(p scanner.ProcessInfo, c scanner.Connection) string {
+	if c.Status != "LISTEN" {
+		return ""
+	}
+	return scanner.DebugServerOn(p.Name, p.Command, c.Port)
+}
+
+// debugListener picks the debugger listener of p, preferring the selected
+// one.
+func debugListener(p scanner.ProcessInfo, selected *scanner.Connection) (string, *scanner.Connection) {
+	if selected != nil {
+		if kind := debugServer(p, *selected); kind != "" {
+			return kind, selected
+		}
+	}
+	for _, c := range sortedConnections(p.Connections) {
+		if kind := debugServer(p, c); kind != "" {
+			return kind, &c
+		}
+	}
+	return "", nil
+}
+
+// attachURLCmd works out how to connect a debugger client to the server
+// of kind on c. The node inspector is asked for its targets, whose
+// WebSocket URLs have a per-run ID.
+func attachURLCmd(p scanner.ProcessInfo, kind string, c scanner.Connection) tea.Cmd {
+	addr := hostPort(dialAddr(c.LocalAddr), c.Port)
+	title := fmt.Sprintf("# %s of %s (PID %d)", kind, p.Name, p.PID)
+	return func() tea.Msg {
+		lines := []string{title}
+		switch kind {
+		case scanner.NodeInspector:
+			targets, err := inspectorTargets(addr)
+			if err != nil {
+				return attachURLMsg{err: err}
+			}
+			for _, t := range targets {
+				lines = append(lines, t.WebSocketDebuggerURL)
+				if t.DevtoolsFrontendURL != "" {
+					lines = append(lines, t.DevtoolsFrontendURL)
+				}
+			}
+			lines = append(lines, "# or open chrome://inspect and add "+addr)
+		case scanner.Delve:
+			lines = append(lines, "dlv connect "+addr)
+		case scanner.Debugpy:
+			lines = append(lines,
+				"# VS Code launch configuration",
+				fmt.Sprintf(`{"type": "debugpy", "request": "attach", "connect": {"host": %q, "port": %d}}`, dialAddr(c.LocalAddr), c.Port))
+		case scanner.JDWP:
+			lines = append(lines, "jdb -attach "+addr)
+		}
+		return attachURLMsg{text: strings.Join(lines, "\n")}
+	}
+}
+
+type inspectorTarget struct {
+	DevtoolsFrontendURL  string `json:"devtoolsFrontendUrl"`
+	WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+}
+
+// inspectorTargets lists the debugging targets of a node inspector.
+func inspectorTargets(addr string) ([]inspectorTarget, error) {
+	client := http.Client{Timeout: inspectorTimeout}
+	resp, err := client.Get("http://" + addr + "/json/list")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the inspector: %w", err)
+	}
+	defer resp.Body.Close()
+	var targets []inspectorTarget
+	if err := json.NewDecoder(resp.Body).Decode(&targets); err != nil {
+		return nil, fmt.Errorf("failed to query the inspector: %w", err)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("the inspector on %s has no targets", addr)
+	}
+	return targets, nil
+}
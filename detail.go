@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"port-monitor/scanner"
+)
+
+// connPaneRows is how many connections the detail pane shows at once.
+const connPaneRows = 5
+
+// sortedConnections orders connections listeners first, then by local port.
+func sortedConnections(conns []scanner.Connection) []scanner.Connection {
+	out := append([]scanner.Connection(nil), conns...)
+	sort.SliceStable(out, func(i, j int) bool {
+		li, lj := out[i].Status == "LISTEN", out[j].Status == "LISTEN"
+		if li != lj {
+			return li
+		}
+		return out[i].Port < out[j].Port
+	})
+	return out
+}
+
+// renderConnections draws the connections as a small fixed-height table,
+// scrolled so the row at cursor is visible and marked. Remote peers are
+// shown by hostname when lookup knows one, and note adds to the state.
+func renderConnections(conns []scanner.Connection, cursor int, lookup func(ip string) string, note func(scanner.Connection) string) string {
+	if len(conns) == 0 {
+		return "Connections: none"
+	}
+
+	conns = sortedConnections(conns)
+	cursor = max(0, min(cursor, len(conns)-1))
+	offset := max(0, min(cursor-connPaneRows/2, len(conns)-connPaneRows))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Connections (%d):\n", len(conns))
+	fmt.Fprintf(&b, "  %-6s %-28s %-40s %s", "Proto", "Local", "Remote", "State")
+	for i := offset; i < min(offset+connPaneRows, len(conns)); i++ {
+		c := conns[i]
+		remote := "*"
+		if c.RemoteAddr != "" {
+			host := c.RemoteAddr
+			if name := lookup(host); name != "" {
+				host = name
+			}
+			remote = hostPort(host, c.RemotePort)
+			if e := ellipsis(); len(remote) > 40 {
+				remote = e + remote[len(remote)-40+len(e):]
+			}
+		}
+		marker := " "
+		if i == cursor {
+			marker = ">"
+		}
+		state := c.Status
+		if c.IsExposed() {
+			state += " (exposed)"
+		}
+		if n := note(c); n != "" {
+			state += " " + n
+		}
+		fmt.Fprintf(&b, "\n%s %-6s %-28s %-40s %s", marker, c.Protocol, hostPort(c.LocalAddr, c.Port), remote, state)
+	}
+	if len(conns) > connPaneRows {
+		fmt.Fprintf(&b, "\n  %d-%d of %d", offset+1, min(offset+connPaneRows, len(conns)), len(conns))
+	}
+	return b.String()
+}
+
+func hostPort(ip string, port uint32) string {
+	if ip == "" {
+		ip = "*"
+	}
+	return net.JoinHostPort(ip, strconv.FormatUint(uint64(port), 10))
+}
@@ -0,0 +1,148 @@
+package main
+
+import "port-monitor/scanner"
+
+// highlightCycles is how many refreshes a NEW/GONE badge stays visible.
+const highlightCycles = 3
+
+type portKey struct {
+	pid  int32
+	port uint32
+}
+
+type goneProcess struct {
+	info scanner.ProcessInfo
+	ttl  int
+}
+
+// scanDiff remembers what changed between consecutive scans so the table
+// can badge processes and listening ports that appeared or disappeared.
+type scanDiff struct {
+	seen        bool
+	newPids     map[int32]int
+	newPorts    map[portKey]int
+	closedPorts map[portKey]int
+	gone        map[int32]goneProcess
+}
+
+func newScanDiff() scanDiff {
+	return scanDiff{
+		newPids:     make(map[int32]int),
+		newPorts:    make(map[portKey]int),
+		closedPorts: make(map[portKey]int),
+		gone:        make(map[int32]goneProcess),
+	}
+}
+
+// apply compares curr against prev and refreshes the badges.
+func (d *scanDiff) apply(prev, curr []scanner.ProcessInfo) {
+	age(d.newPids)
+	age(d.newPorts)
+	age(d.closedPorts)
+	for pid, g := range d.gone {
+		if g.ttl--; g.ttl <= 0 {
+			delete(d.gone, pid)
+		} else {
+			d.gone[pid] = g
+		}
+	}
+
+	// Everything is "new" on the first scan, so don't badge anything
+	if !d.seen {
+		d.seen = true
+		return
+	}
+
+	prevProcs := make(map[int32]scanner.ProcessInfo, len(prev))
+	prevPorts := make(map[portKey]struct{})
+	for _, p := range prev {
+		prevProcs[p.PID] = p
+		for _, k := range listenKeys(p) {
+			prevPorts[k] = struct{}{}
+		}
+	}
+
+	currPids := make(map[int32]struct{}, len(curr))
+	currPorts := make(map[portKey]struct{})
+	for _, p := range curr {
+		currPids[p.PID] = struct{}{}
+		if _, ok := prevProcs[p.PID]; !ok {
+			d.newPids[p.PID] = highlightCycles
+		}
+		for _, k := range listenKeys(p) {
+			currPorts[k] = struct{}{}
+			if _, ok := prevPorts[k]; !ok {
+				d.newPorts[k] = highlightCycles
+			}
+		}
+		delete(d.gone, p.PID) // PID came back
+	}
+
+	for pid, p := range prevProcs {
+		if _, ok := currPids[pid]; !ok {
+			d.gone[pid] = goneProcess{info: p, ttl: highlightCycles}
+		}
+	}
+	for k := range prevPorts {
+		if _, ok := currPorts[k]; !ok {
+			if _, alive := currPids[k.pid]; alive {
+				d.closedPorts[k] = highlightCycles
+			}
+		}
+	}
+}
+
+func (d *scanDiff) isNew(pid int32) bool {
+	_, ok := d.newPids[pid]
+	return ok
+}
+
+func (d *scanDiff) isGone(pid int32) bool {
+	_, ok := d.gone[pid]
+	return ok
+}
+
+func (d *scanDiff) isNewPort(pid int32, port uint32) bool {
+	_, ok := d.newPorts[portKey{pid, port}]
+	return ok
+}
+
+// closedPortsOf returns listening ports the process recently stopped using.
+func (d *scanDiff) closedPortsOf(pid int32) []uint32 {
+	var ports []uint32
+	for k := range d.closedPorts {
+		if k.pid == pid {
+			ports = append(ports, k.port)
+		}
+	}
+	return ports
+}
+
+// ghosts returns recently exited processes so they can still be shown.
+func (d *scanDiff) ghosts() []scanner.ProcessInfo {
+	var procs []scanner.ProcessInfo
+	for _, g := range d.gone {
+		procs = append(procs, g.info)
+	}
+	return procs
+}
+
+func listenKeys(p scanner.ProcessInfo) []portKey {
+	var keys []portKey
+	for _, c := range p.Connections {
+		if c.Status == "LISTEN" {
+			keys = append(keys, portKey{p.PID, c.Port})
+		}
+	}
+	return keys
+}
+
+func age[K comparable](m map[K]int) {
+	for k, ttl := range m {
+		if ttl <= 1 {
+			delete(m, k)
+		} else {
+			m[k] = ttl - 1
+		}
+	}
+}
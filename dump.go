@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"port-monitor/scanner"
+)
+
+type quitResultMsg struct {
+	proc   scanner.ProcessInfo
+	target string // Where the stack dump was written
+	err    error
+}
+
+type coreDumpMsg struct {
+	file string
+	err  error
+}
+
+func quitProcessCmd(p scanner.ProcessInfo) tea.Cmd {
+	return func() tea.Msg {
+		target, err := scanner.QuitProcess(p)
+		return quitResultMsg{proc: p, target: target, err: err}
+	}
+}
+
+// quitNote tells where the stack dump of a SIGQUIT went.
+func quitNote(msg quitResultMsg) string {
+	sent := fmt.Sprintf("Sent SIGQUIT to %s (%d)", msg.proc.Name, msg.proc.PID)
+	switch {
+	case msg.target == "":
+		return sent + ", dump location unknown"
+	case msg.target == "/dev/null":
+		return sent + ", but its output is discarded (/dev/null)"
+	default:
+		return sent + ", dump written to " + msg.target
+	}
+}
+
+// coreDumpCmd suspends the TUI while gcore writes a core file of p to
+// the user cache dir.
+func coreDumpCmd(p scanner.ProcessInfo) (tea.Cmd, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find cache dir: %w", err)
+	}
+	cmd, file, err := scanner.CoreDumpCommand(p.PID, filepath.Join(dir, "port-monitor", "dumps"), p.Type == scanner.SystemProcess)
+	if err != nil {
+		return nil, err
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return coreDumpMsg{file: file, err: err}
+	}), nil
+}
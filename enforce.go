@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"port-monitor/audit"
+	"port-monitor/eventlog"
+	"port-monitor/rules"
+	"port-monitor/scanner"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type ruleActionMsg []string
+
+const (
+	ruleRetryMin = 30 * time.Second // Wait after a first failed kill
+	ruleRetryMax = time.Hour
+)
+
+// ruleEnforcer applies the configured rules to scans, remembering what it
+// did so a violation is acted on once rather than every scan: a notify
+// once per process, a kill once unless it fails, and failed kills retried
+// with a growing delay.
+type ruleEnforcer struct {
+	rules []rules.Rule
+
+	mu      sync.Mutex
+	actions map[ruleActionKey]*ruleAction
+}
+
+// ruleActionKey is a violation of a rule by a process on a host; the start
+// time tells a reused PID from the process acted on.
+type ruleActionKey struct {
+	host    string
+	rule    string
+	pid     int32
+	started int64
+}
+
+type ruleAction struct {
+	done     bool      // Notified, or killed
+	failures int       // Failed kills in a row
+	retry    time.Time // When to try killing again
+	seen     bool      // Still violating in the latest scan
+}
+
+func newRuleEnforcer(rs []rules.Rule) *ruleEnforcer {
+	return &ruleEnforcer{rules: rs, actions: make(map[ruleActionKey]*ruleAction)}
+}
+
+// enforce applies the rules to a scan of host, writing an audit entry for
+// every action and logging kills to events when set. It returns one human
+// readable line per action. On a local scan, the monitor and the shell and
+// terminal it runs in are never acted on, even with --allow-kill-self.
+func (e *ruleEnforcer) enforce(src processSource, host string, procs []scanner.ProcessInfo, auditPath string, events eventlog.Logger) []string {
+	var protected map[int32]bool
+	if _, local := src.(localSource); local {
+		protected = protectedPIDs(procs)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for k, a := range e.actions {
+		if k.host == host {
+			a.seen = false
+		}
+	}
+
+	var msgs []string
+	now := time.Now()
+	for _, v := range rules.Evaluate(e.rules, procs) {
+		if protected[v.Process.PID] {
+			continue
+		}
+		key := ruleActionKey{host: host, rule: fmt.Sprint(v.Rule), pid: v.Process.PID, started: v.Process.Started.UnixMilli()}
+		a, ok := e.actions[key]
+		if !ok {
+			a = &ruleAction{}
+			e.actions[key] = a
+		}
+		a.seen = true
+		if a.done || now.Before(a.retry) {
+			continue
+		}
+
+		entry := audit.Entry{
+			Time:   now,
+			Action: v.Action,
+			PID:    v.Process.PID,
+			Name:   v.Process.Name,
+			Port:   v.Rule.Port,
+			Reason: fmt.Sprintf("port %d is reserved for %v", v.Rule.Port, v.Rule.Allow),
+		}
+
+		var msg string
+		switch v.Action {
+		case rules.ActionKill:
+			if err := src.KillProcess(v.Process.PID); err != nil {
+				entry.Error = err.Error()
+				delay := min(ruleRetryMin<<a.failures, ruleRetryMax)
+				if delay < ruleRetryMax {
+					a.failures++ // Stop doubling before the shift overflows
+				}
+				a.retry = now.Add(delay)
+				msg = fmt.Sprintf("Rule: failed to kill %s (pid %d) on %d: %v, retrying in %s", v.Process.Name, v.Process.PID, v.Rule.Port, err, delay)
+			} else {
+				a.done = true
+				msg = fmt.Sprintf("Rule: killed %s (pid %d) on %d", v.Process.Name, v.Process.PID, v.Rule.Port)
+				if events != nil {
+					events.Log(scanner.Event{Type: scanner.ProcessKilled, Time: entry.Time, PID: v.Process.PID, Name: v.Process.Name, Port: v.Rule.Port})
+				}
+			}
+		default:
+			a.done = true
+			msg = fmt.Sprintf("Rule: %s (pid %d) is using reserved port %d", v.Process.Name, v.Process.PID, v.Rule.Port)
+		}
+
+		if err := audit.Append(auditPath, entry); err != nil {
+			msg += fmt.Sprintf(" (audit: %v)", err)
+		}
+		msgs = append(msgs, msg)
+	}
+
+	// Forget violations that ended, so the memory doesn't grow
+	for k, a := range e.actions {
+		if k.host == host && !a.seen {
+			delete(e.actions, k)
+		}
+	}
+	return msgs
+}
+
+func (e *ruleEnforcer) enforceCmd(src processSource, host string, procs []scanner.ProcessInfo, auditPath string, events eventlog.Logger) tea.Cmd {
+	return func() tea.Msg {
+		return ruleActionMsg(e.enforce(src, host, procs, auditPath, events))
+	}
+}
@@ -0,0 +1,52 @@
+package eventlog
+
+import (
+	"fmt"
+	"strings"
+
+	"port-monitor/scanner"
+)
+
+// Logger forwards events to the system log pipeline.
+type Logger interface {
+	Log(e scanner.Event) error
+	Close() error
+}
+
+// Open returns a logger for "syslog" or "journald".
+func Open(kind string) (Logger, error) {
+	switch kind {
+	case "syslog":
+		return openSyslog()
+	case "journald":
+		return openJournald()
+	}
+	return nil, fmt.Errorf("unknown event log %q (want syslog or journald)", kind)
+}
+
+// fields returns the structured fields of an event, with stable names
+// usable both as syslog key=value pairs and journald fields.
+func fields(e scanner.Event) [][2]string {
+	f := [][2]string{
+		{"event", string(e.Type)},
+		{"pid", fmt.Sprint(e.PID)},
+		{"name", e.Name},
+	}
+	if e.Port != 0 {
+		f = append(f, [2]string{"port", fmt.Sprint(e.Port)}, [2]string{"protocol", e.Protocol})
+	}
+	return f
+}
+
+// keyValues formats fields as logfmt, quoting values with spaces.
+func keyValues(e scanner.Event) string {
+	var parts []string
+	for _, kv := range fields(e) {
+		v := kv[1]
+		if strings.ContainsAny(v, " \"=") {
+			v = fmt.Sprintf("%q", v)
+		}
+		parts = append(parts, kv[0]+"="+v)
+	}
+	return strings.Join(parts, " ")
+}
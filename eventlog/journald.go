@@ -0,0 +1,63 @@
+package eventlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+
+	"port-monitor/scanner"
+)
+
+const journalSocket = "/run/systemd/journal/socket"
+
+// journaldLogger speaks journald's native protocol, so every field of an
+// event becomes a queryable journal field (journalctl PM_PORT=8080).
+type journaldLogger struct {
+	conn net.Conn
+}
+
+func openJournald() (Logger, error) {
+	conn, err := net.Dial("unixgram", journalSocket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to journald: %w", err)
+	}
+	return journaldLogger{conn: conn}, nil
+}
+
+func (l journaldLogger) Log(e scanner.Event) error {
+	priority := "6" // info
+	if e.Type == scanner.ProcessKilled {
+		priority = "5" // notice
+	}
+
+	var b bytes.Buffer
+	writeJournalField(&b, "MESSAGE", e.String())
+	writeJournalField(&b, "PRIORITY", priority)
+	writeJournalField(&b, "SYSLOG_IDENTIFIER", "port-monitor")
+	for _, kv := range fields(e) {
+		writeJournalField(&b, "PM_"+strings.ToUpper(kv[0]), kv[1])
+	}
+	_, err := l.conn.Write(b.Bytes())
+	return err
+}
+
+// writeJournalField appends one field in journald's native format. Values
+// with a newline, like a process name or command line could carry, use the
+// length-prefixed binary form so they can't inject fields of their own.
+func writeJournalField(b *bytes.Buffer, key, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(b, "%s=%s\n", key, value)
+		return
+	}
+	b.WriteString(key)
+	b.WriteByte('\n')
+	binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+func (l journaldLogger) Close() error {
+	return l.conn.Close()
+}
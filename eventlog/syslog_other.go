@@ -0,0 +1,9 @@
+//go:build windows || plan9
+
+package eventlog
+
+import "errors"
+
+func openSyslog() (Logger, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
@@ -0,0 +1,33 @@
+//go:build !windows && !plan9
+
+package eventlog
+
+import (
+	"log/syslog"
+
+	"port-monitor/scanner"
+)
+
+type syslogLogger struct {
+	w *syslog.Writer
+}
+
+func openSyslog() (Logger, error) {
+	w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, "port-monitor")
+	if err != nil {
+		return nil, err
+	}
+	return syslogLogger{w: w}, nil
+}
+
+func (l syslogLogger) Log(e scanner.Event) error {
+	msg := e.String() + " " + keyValues(e)
+	if e.Type == scanner.ProcessKilled {
+		return l.w.Notice(msg)
+	}
+	return l.w.Info(msg)
+}
+
+func (l syslogLogger) Close() error {
+	return l.w.Close()
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"port-monitor/scanner"
+)
+
+const (
+	// maxEvents is how many events the log keeps, enough for the whole
+	// session as scans in a row share an entry.
+	maxEvents = 1000
+
+	// The status bar rotates through the last tickerEvents events younger
+	// than tickerWindow, showing each for tickerPeriod.
+	tickerEvents = 3
+	tickerWindow = time.Minute
+	tickerPeriod = 3 * time.Second
+)
+
+// event is something worth telling the user about: a notification, a kill,
+// a new listener, a failed scan.
+type event struct {
+	at    time.Time
+	text  string
+	quiet bool // Only in the log, not in the ticker
+
+	// Scans in a row share an entry: how many and when the last one was
+	scans int
+	until time.Time
+}
+
+type tickerMsg struct{}
+
+// pushEvent queues an event, dropping the oldest when the queue is full,
+// and starts the ticker rotating if it isn't.
+func (m *model) pushEvent(text string) tea.Cmd {
+	m.appendEvent(event{at: time.Now(), text: text})
+	if m.ticking {
+		return nil
+	}
+	m.ticking = true
+	return tickerCmd()
+}
+
+// logEvent adds an event to the log only.
+func (m *model) logEvent(text string) {
+	m.appendEvent(event{at: time.Now(), text: text, quiet: true})
+}
+
+func (m *model) appendEvent(e event) {
+	m.events = append(m.events, e)
+	if len(m.events) > maxEvents {
+		m.events = m.events[len(m.events)-maxEvents:]
+	}
+	// Keep the view where it was when scrolled back
+	if m.logScroll > 0 {
+		m.logScroll = min(m.logScroll+1, len(m.events)-1)
+	}
+}
+
+// logScan logs a finished scan, adding it to the previous entry when that
+// was a scan too.
+func (m *model) logScan(procs []scanner.ProcessInfo, took time.Duration) {
+	listening := 0
+	for _, p := range procs {
+		if listensAnywhere(p) {
+			listening++
+		}
+	}
+	now := time.Now()
+	text := fmt.Sprintf("Scanned %d processes, %d listening, in %s", len(procs), listening, took.Round(time.Millisecond))
+	if n := len(m.events); n > 0 && m.events[n-1].scans > 0 {
+		e := &m.events[n-1]
+		e.text, e.scans, e.until = text, e.scans+1, now
+		return
+	}
+	m.appendEvent(event{at: now, text: text, quiet: true, scans: 1, until: now})
+}
+
+func tickerCmd() tea.Cmd {
+	return tea.Tick(tickerPeriod, func(time.Time) tea.Msg {
+		return tickerMsg{}
+	})
+}
+
+// recentEvents are the events the ticker rotates through, oldest first.
+func (m *model) recentEvents() []event {
+	var recent []event
+	for i := len(m.events) - 1; i >= 0 && len(recent) < tickerEvents; i-- {
+		if m.events[i].quiet {
+			continue
+		}
+		if time.Since(m.events[i].at) > tickerWindow {
+			break
+		}
+		recent = append([]event{m.events[i]}, recent...)
+	}
+	return recent
+}
+
+// ticker is the recent event the status bar shows now, or "" when there
+// is none.
+func (m *model) ticker() string {
+	recent := m.recentEvents()
+	if len(recent) == 0 {
+		return ""
+	}
+	i := int(time.Now().UnixNano()/int64(tickerPeriod)) % len(recent)
+	s := fmt.Sprintf("%s %s", recent[i].at.Format("15:04:05"), recent[i].text)
+	if len(recent) > 1 {
+		s = fmt.Sprintf("%s (%d/%d)", s, i+1, len(recent))
+	}
+	return s
+}
+
+// logRows is how many events the log screen fits.
+func (m *model) logRows() int {
+	return max(m.height-16, 3)
+}
+
+// logView is the event log screen, the newest events before the scroll
+// position.
+func (m *model) logView() string {
+	var b strings.Builder
+	if len(m.events) == 0 {
+		b.WriteString("Event log\n\nNothing happened yet.")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "Event log (%d since %s)\n", len(m.events), m.events[0].at.Format("15:04:05"))
+	end := len(m.events) - m.logScroll
+	for _, e := range m.events[max(end-m.logRows(), 0):end] {
+		fmt.Fprintf(&b, "\n%s %s", e.at.Format("15:04:05"), e.text)
+		if e.scans > 1 {
+			fmt.Fprintf(&b, " (%d scans until %s)", e.scans, e.until.Format("15:04:05"))
+		}
+	}
+	if m.logScroll > 0 {
+		fmt.Fprintf(&b, "\n%s %d newer", glyph("↓", "v"), m.logScroll)
+	}
+	return b.String()
+}
+
+// scrollLog handles a key on the event log screen.
+func (m *model) scrollLog(key string) {
+	height := m.logRows()
+	last := max(len(m.events)-height, 0)
+	switch key {
+	case "up", "k":
+		m.logScroll = min(m.logScroll+1, last)
+	case "down", "j":
+		m.logScroll = max(m.logScroll-1, 0)
+	case "pgup", "b":
+		m.logScroll = min(m.logScroll+height, last)
+	case "pgdown", "f", " ":
+		m.logScroll = max(m.logScroll-height, 0)
+	case "g", "home":
+		m.logScroll = last
+	case "G", "end":
+		m.logScroll = 0
+	}
+}
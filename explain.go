@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"port-monitor/firewall"
+	"port-monitor/scanner"
+)
+
+// explainCommands lists the standard tools that show and do what the TUI
+// does for a process and one of its listening ports (nil if none), on goos.
+func explainCommands(p scanner.ProcessInfo, listener *scanner.Connection, goos string) []string {
+	pid := fmt.Sprint(p.PID)
+	sudo := ""
+	if p.Type == scanner.SystemProcess && goos != "windows" {
+		sudo = "sudo "
+	}
+
+	var cmds []string
+	if goos == "windows" {
+		if listener != nil {
+			cmds = append(cmds, "# Who owns port "+fmt.Sprint(listener.Port),
+				fmt.Sprintf("netstat -ano | findstr :%d", listener.Port))
+		}
+		return append(cmds,
+			"# Process details",
+			fmt.Sprintf(`tasklist /V /FI "PID eq %s"`, pid),
+			"# Kill it",
+			"taskkill /F /PID "+pid,
+		)
+	}
+
+	if listener != nil {
+		port := fmt.Sprint(listener.Port)
+		proto := strings.TrimSuffix(listener.Protocol, "6")
+		cmds = append(cmds, "# Who owns port "+port)
+		if proto == "udp" {
+			cmds = append(cmds, sudo+"lsof -nP -iUDP:"+port)
+		} else {
+			cmds = append(cmds, sudo+"lsof -nP -iTCP:"+port+" -sTCP:LISTEN")
+		}
+		if goos == "linux" {
+			flag := "-tlnp"
+			if proto == "udp" {
+				flag = "-ulnp"
+			}
+			cmds = append(cmds,
+				fmt.Sprintf("%sss %s 'sport = :%s'", sudo, flag, port),
+				fmt.Sprintf("%sfuser -v %s/%s", sudo, port, proto),
+			)
+		}
+	}
+
+	cmds = append(cmds, "# Process details", "ps -o pid,ppid,user,%cpu,rss,command -p "+pid)
+	if goos == "linux" {
+		cmds = append(cmds, sudo+"ls -l /proc/"+pid+"/cwd", sudo+"ls -l /proc/"+pid+"/fd | grep socket")
+	} else {
+		cmds = append(cmds, sudo+"lsof -a -d cwd -p "+pid)
+	}
+
+	cmds = append(cmds, "# Kill it", sudo+"kill -9 "+pid)
+	if listener != nil && goos == "linux" {
+		cmds = append(cmds, fmt.Sprintf("%sfuser -k %d/%s", sudo, listener.Port, strings.TrimSuffix(listener.Protocol, "6")))
+	}
+	return cmds
+}
+
+// killEquivalent is the shell command for killing pids.
+func killEquivalent(pids []int32, goos string) []string {
+	ids := make([]string, len(pids))
+	for i, pid := range pids {
+		ids[i] = fmt.Sprint(pid)
+	}
+	if goos == "windows" {
+		return []string{"taskkill /F /PID " + strings.Join(ids, " /PID ")}
+	}
+	return []string{"kill -9 " + strings.Join(ids, " ")}
+}
+
+// dropEquivalent is the ss command destroying a single connection.
+func dropEquivalent(c scanner.Connection) []string {
+	return []string{fmt.Sprintf("sudo ss -K 'src %s sport = :%d dst %s dport = :%d'", c.LocalAddr, c.Port, c.RemoteAddr, c.RemotePort)}
+}
+
+// blockEquivalent lists the firewall commands of plan.
+func blockEquivalent(plan firewall.Plan) []string {
+	cmds := make([]string, len(plan.Commands))
+	for i, c := range plan.Commands {
+		cmds[i] = "sudo " + c.String()
+	}
+	return cmds
+}
+
+// onHost wraps commands so they run on a remote host over ssh.
+func onHost(host string, cmds []string) []string {
+	if host == "" {
+		return cmds
+	}
+	out := make([]string, len(cmds))
+	for i, c := range cmds {
+		if strings.HasPrefix(c, "#") {
+			out[i] = c
+			continue
+		}
+		out[i] = fmt.Sprintf("ssh %s %q", host, c)
+	}
+	return out
+}
+
+// copyToClipboard uses the platform clipboard tool, falling back to the
+// OSC 52 escape sequence that most terminals (also over ssh) understand.
+func copyToClipboard(text string) error {
+	for _, tool := range [][]string{
+		{"pbcopy"},
+		{"wl-copy"},
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+		{"clip.exe"},
+	} {
+		if _, err := exec.LookPath(tool[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(tool[0], tool[1:]...)
+		cmd.Stdin = bytes.NewBufferString(text)
+		if err := cmd.Run(); err == nil {
+			return nil
+		}
+	}
+
+	if _, err := fmt.Fprintf(os.Stdout, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text))); err != nil {
+		return errors.New("no clipboard available")
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"port-monitor/scanner"
+)
+
+type exportMsg struct {
+	file string
+	data string
+	err  error
+}
+
+// processExport is the JSON a process is exported as for bug reports.
+type processExport struct {
+	Exported time.Time           `json:"exported"`
+	Host     string              `json:"host,omitempty"`
+	Process  scanner.ProcessInfo `json:"process"`
+	Env      []string            `json:"env,omitempty"`
+	Limits   []scanner.Limit     `json:"limits,omitempty"`
+	Cgroup   *scanner.Cgroup     `json:"cgroup,omitempty"`
+	Parents  []exportedParent    `json:"parents"` // Parent first, up to the root
+}
+
+type exportedParent struct {
+	PID     int32  `json:"pid"`
+	Name    string `json:"name"`
+	User    string `json:"user"`
+	Command string `json:"command"`
+}
+
+// exportCmd writes p as pretty-printed JSON to the user cache dir.
+// Environment, limits and cgroup are only read for processes on this
+// machine; the environment comes redacted by DetailsOf, as the export ends
+// up in bug reports and the clipboard.
+func (m *model) exportCmd(p scanner.ProcessInfo) tea.Cmd {
+	e := processExport{Host: m.host, Process: p, Parents: []exportedParent{}}
+	byPID := make(map[int32]scanner.ProcessInfo, len(m.processes))
+	for _, q := range m.processes {
+		byPID[q.PID] = q
+	}
+	seen := map[int32]bool{p.PID: true}
+	for parent, ok := byPID[p.PPID]; ok && !seen[parent.PID]; parent, ok = byPID[parent.PPID] {
+		seen[parent.PID] = true
+		e.Parents = append(e.Parents, exportedParent{PID: parent.PID, Name: parent.Name, User: parent.User, Command: parent.Command})
+	}
+	local := m.host == "" && !m.replaying
+
+	return func() tea.Msg {
+		e.Exported = time.Now()
+		if local {
+			if d, err := scanner.DetailsOf(p.PID); err == nil {
+				e.Env, e.Limits = d.Env, d.Limits
+			}
+			if cg, err := scanner.CgroupOf(p.PID); err == nil {
+				e.Cgroup = &cg
+			}
+		}
+		data, err := json.MarshalIndent(e, "", "  ")
+		if err != nil {
+			return exportMsg{err: err}
+		}
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return exportMsg{err: fmt.Errorf("failed to find cache dir: %w", err)}
+		}
+		dir = filepath.Join(dir, "port-monitor", "exports")
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return exportMsg{err: err}
+		}
+		name := strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(p.Name)
+		file := filepath.Join(dir, fmt.Sprintf("%s-%d-%s.json", name, p.PID, e.Exported.Format("20060102-150405")))
+		if err := os.WriteFile(file, append(data, '\n'), 0o600); err != nil {
+			return exportMsg{err: err}
+		}
+		return exportMsg{file: file, data: string(data)}
+	}
+}
+
+// exportNote reports where an export went, copying it to the clipboard too.
+func exportNote(msg exportMsg) string {
+	if msg.err != nil {
+		return fmt.Sprintf("Error: export failed: %v", msg.err)
+	}
+	if err := copyToClipboard(msg.data); err != nil {
+		return "Exported to " + msg.file
+	}
+	return "Exported to " + msg.file + " and copied to clipboard"
+}
@@ -0,0 +1,334 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"port-monitor/scanner"
+)
+
+// Predicate reports whether a process matches a filter expression.
+type Predicate func(scanner.ProcessInfo) bool
+
+// Fields lists the names usable in expressions.
+var Fields = []string{
+	"pid", "ppid", "name", "user", "cmd", "cwd", "type", "runtime", "manager",
+	"state", "cpu", "mem", "port", "listen", "remote", "conns", "exposed", "kernel",
+}
+
+// field extracts the values of a field from a process. Numbers are
+// float64, everything else string.
+func field(name string, p scanner.ProcessInfo) ([]any, bool) {
+	switch name {
+	case "pid":
+		return []any{float64(p.PID)}, true
+	case "ppid":
+		return []any{float64(p.PPID)}, true
+	case "name":
+		return []any{p.Name}, true
+	case "user":
+		return []any{p.User}, true
+	case "cmd":
+		return []any{p.Command}, true
+	case "cwd":
+		return []any{p.Cwd}, true
+	case "type":
+		return []any{p.AppType}, true
+	case "runtime":
+		return []any{p.Runtime}, true
+	case "manager":
+		return []any{p.Manager}, true
+	case "state":
+		return []any{p.State}, true
+	case "cpu":
+		return []any{p.CPUPercent}, true
+	case "mem":
+		return []any{float64(p.MemoryUsage) / (1 << 20)}, true // MB
+	case "conns":
+		return []any{float64(len(p.Connections))}, true
+	case "port", "listen", "remote":
+		var vals []any
+		for _, c := range p.Connections {
+			switch {
+			case name == "port":
+				vals = append(vals, float64(c.Port))
+			case name == "listen" && c.Status == "LISTEN":
+				vals = append(vals, float64(c.Port))
+			case name == "remote" && c.RemotePort != 0:
+				vals = append(vals, float64(c.RemotePort))
+			}
+		}
+		return vals, true
+	case "exposed":
+		for _, c := range p.Connections {
+			if c.IsExposed() {
+				return []any{float64(1)}, true
+			}
+		}
+		return []any{float64(0)}, true
+	case "kernel":
+		if p.KernelThread {
+			return []any{float64(1)}, true
+		}
+		return []any{float64(0)}, true
+	}
+	return nil, false
+}
+
+// Parse compiles an expression such as
+// `port >= 3000 && user == "me" && cpu > 10`: comparisons joined with &&
+// and ||, negated with ! and grouped with parentheses. Fields with several
+// values (port, listen, remote) match when any value does. An empty
+// expression matches everything.
+func Parse(expr string) (Predicate, error) {
+	toks, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 0 {
+		return func(scanner.ProcessInfo) bool { return true }, nil
+	}
+	p := &parser{toks: toks}
+	pred, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("unexpected %q", p.toks[p.pos].text)
+	}
+	return pred, nil
+}
+
+type tokenKind int
+
+const (
+	tokIdent tokenKind = iota
+	tokNumber
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "<", ">", "!", "(", ")"}
+
+func lex(s string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(s); {
+		ch := s[i]
+		switch {
+		case ch == ' ' || ch == '\t':
+			i++
+		case ch == '"' || ch == '\'':
+			j := strings.IndexByte(s[i+1:], ch)
+			if j < 0 {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			toks = append(toks, token{tokString, s[i+1 : i+1+j]})
+			i += j + 2
+		case ch >= '0' && ch <= '9' || ch == '.':
+			j := i
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == '.') {
+				j++
+			}
+			toks = append(toks, token{tokNumber, s[i:j]})
+			i = j
+		case ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z':
+			j := i
+			for j < len(s) && (s[j] == '_' || s[j] >= 'a' && s[j] <= 'z' || s[j] >= 'A' && s[j] <= 'Z' || s[j] >= '0' && s[j] <= '9') {
+				j++
+			}
+			toks = append(toks, token{tokIdent, s[i:j]})
+			i = j
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(s[i:], op) {
+					toks = append(toks, token{tokOp, op})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected %q at %d", ch, i)
+			}
+		}
+	}
+	return toks, nil
+}
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek(op string) bool {
+	return p.pos < len(p.toks) && p.toks[p.pos].kind == tokOp && p.toks[p.pos].text == op
+}
+
+func (p *parser) or() (Predicate, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("||") {
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(proc scanner.ProcessInfo) bool { return l(proc) || right(proc) }
+	}
+	return left, nil
+}
+
+func (p *parser) and() (Predicate, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("&&") {
+		p.pos++
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(proc scanner.ProcessInfo) bool { return l(proc) && right(proc) }
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (Predicate, error) {
+	switch {
+	case p.peek("!"):
+		p.pos++
+		inner, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(proc scanner.ProcessInfo) bool { return !inner(proc) }, nil
+	case p.peek("("):
+		p.pos++
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peek(")") {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return inner, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (Predicate, error) {
+	if p.pos >= len(p.toks) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	tok := p.toks[p.pos]
+	if tok.kind != tokIdent {
+		return nil, fmt.Errorf("expected a field, got %q", tok.text)
+	}
+	name := strings.ToLower(tok.text)
+	if _, ok := field(name, scanner.ProcessInfo{}); !ok {
+		return nil, fmt.Errorf("unknown field %q, use one of %s", tok.text, strings.Join(Fields, ", "))
+	}
+	p.pos++
+
+	// A bare field is true when non-zero / non-empty, e.g. "exposed"
+	if p.pos >= len(p.toks) || p.toks[p.pos].kind != tokOp || !isComparison(p.toks[p.pos].text) {
+		return func(proc scanner.ProcessInfo) bool {
+			vals, _ := field(name, proc)
+			for _, v := range vals {
+				if v != float64(0) && v != "" {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+	op := p.toks[p.pos].text
+	p.pos++
+
+	if p.pos >= len(p.toks) || p.toks[p.pos].kind == tokOp {
+		return nil, fmt.Errorf("expected a value after %s", op)
+	}
+	val := p.toks[p.pos]
+	p.pos++
+
+	cmp, err := compare(op, val)
+	if err != nil {
+		return nil, err
+	}
+	return func(proc scanner.ProcessInfo) bool {
+		vals, _ := field(name, proc)
+		for _, v := range vals {
+			if cmp(v) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+func isComparison(op string) bool {
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=", "=~":
+		return true
+	}
+	return false
+}
+
+// compare builds a test of a field value against the literal val.
+// Strings compare case-insensitively; =~ is a regular expression match.
+func compare(op string, val token) (func(any) bool, error) {
+	if op == "=~" {
+		re, err := regexp.Compile("(?i)" + val.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %w", err)
+		}
+		return func(v any) bool { return re.MatchString(fmt.Sprint(v)) }, nil
+	}
+
+	num, numErr := strconv.ParseFloat(val.text, 64)
+	return func(v any) bool {
+		var c int
+		switch v := v.(type) {
+		case float64:
+			if numErr != nil {
+				return false
+			}
+			switch {
+			case v < num:
+				c = -1
+			case v > num:
+				c = 1
+			}
+		case string:
+			c = strings.Compare(strings.ToLower(v), strings.ToLower(val.text))
+		}
+		switch op {
+		case "==":
+			return c == 0
+		case "!=":
+			return c != 0
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		default:
+			return c >= 0
+		}
+	}, nil
+}
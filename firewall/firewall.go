@@ -0,0 +1,96 @@
+package firewall
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Name of the pf anchor / nftables table holding rules added by port-monitor.
+const (
+	pfAnchor = "com.apple/port-monitor"
+	nftTable = "port_monitor"
+)
+
+// Command is a single program invocation, optionally fed through stdin.
+type Command struct {
+	Args  []string
+	Stdin string
+}
+
+func (c Command) String() string {
+	s := strings.Join(c.Args, " ")
+	if c.Stdin != "" {
+		s += fmt.Sprintf(" <<< '%s'", strings.TrimSpace(c.Stdin))
+	}
+	return s
+}
+
+// Plan is the exact list of commands that blocks a port, so it can be
+// shown to the user before being applied.
+type Plan struct {
+	Port     uint32
+	Protocol string // tcp or udp
+	Commands []Command
+}
+
+// BlockPlan builds the commands blocking inbound traffic to port on this host:
+// pf on macOS, nftables (or iptables if nft is missing) on Linux, Windows
+// Defender Firewall (netsh) on Windows.
+func BlockPlan(port uint32, protocol string) (Plan, error) {
+	protocol = strings.TrimSuffix(protocol, "6")
+	if protocol != "tcp" && protocol != "udp" {
+		return Plan{}, fmt.Errorf("unsupported protocol %q", protocol)
+	}
+
+	plan := Plan{Port: port, Protocol: protocol}
+	switch runtime.GOOS {
+	case "darwin":
+		rules, _ := exec.Command("pfctl", "-a", pfAnchor, "-sr").Output()
+		rule := fmt.Sprintf("block drop in quick proto %s from any to any port %d\n", protocol, port)
+		plan.Commands = []Command{
+			{Args: []string{"pfctl", "-a", pfAnchor, "-f", "-"}, Stdin: string(rules) + rule},
+			{Args: []string{"pfctl", "-E"}},
+		}
+	case "linux":
+		if _, err := exec.LookPath("nft"); err == nil {
+			plan.Commands = []Command{
+				{Args: []string{"nft", "add", "table", "inet", nftTable}},
+				{Args: []string{"nft", "add", "chain", "inet", nftTable, "input", "{ type filter hook input priority 0 ; policy accept ; }"}},
+				{Args: []string{"nft", "add", "rule", "inet", nftTable, "input", protocol, "dport", fmt.Sprint(port), "drop"}},
+			}
+		} else {
+			plan.Commands = []Command{
+				{Args: []string{"iptables", "-I", "INPUT", "-p", protocol, "--dport", fmt.Sprint(port), "-j", "DROP"}},
+				{Args: []string{"ip6tables", "-I", "INPUT", "-p", protocol, "--dport", fmt.Sprint(port), "-j", "DROP"}},
+			}
+		}
+	case "windows":
+		plan.Commands = []Command{
+			{Args: []string{"netsh", "advfirewall", "firewall", "add", "rule",
+				fmt.Sprintf("name=port-monitor block %s/%d", protocol, port),
+				"dir=in", "action=block", "protocol=" + strings.ToUpper(protocol), fmt.Sprintf("localport=%d", port)}},
+		}
+	default:
+		return Plan{}, fmt.Errorf("firewall rules are not supported on %s", runtime.GOOS)
+	}
+	return plan, nil
+}
+
+// Apply runs the plan's commands in order, stopping at the first failure.
+func (p Plan) Apply() error {
+	for _, c := range p.Commands {
+		cmd := exec.Command(c.Args[0], c.Args[1:]...)
+		if c.Stdin != "" {
+			cmd.Stdin = strings.NewReader(c.Stdin)
+		}
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s: %w: %s", c.Args[0], err, strings.TrimSpace(stderr.String()))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"port-monitor/scanner"
+)
+
+// forwarder is a local TCP proxy from a port to a target address.
+type forwarder struct {
+	port   uint32
+	addr   string // Listen address
+	target string
+	l      net.Listener
+
+	active atomic.Int64
+	total  atomic.Int64
+	bytes  atomic.Int64 // Both directions
+
+	// Called with the counters changed, may be nil
+	onConn func(f *forwarder, from net.Addr, opened bool)
+}
+
+// startForwarder listens on port of host and proxies every connection to
+// target.
+func startForwarder(host string, port uint32, target string) (*forwarder, error) {
+	l, err := net.Listen("tcp", net.JoinHostPort(host, strconv.FormatUint(uint64(port), 10)))
+	if err != nil {
+		return nil, err
+	}
+	f := &forwarder{port: port, addr: l.Addr().String(), target: target, l: l}
+	go f.serve()
+	return f, nil
+}
+
+func (f *forwarder) serve() {
+	for {
+		conn, err := f.l.Accept()
+		if err != nil {
+			return
+		}
+		go f.proxy(conn)
+	}
+}
+
+func (f *forwarder) proxy(client net.Conn) {
+	defer client.Close()
+	upstream, err := net.Dial("tcp", f.target)
+	if err != nil {
+		return
+	}
+	defer upstream.Close()
+
+	f.active.Add(1)
+	f.total.Add(1)
+	if f.onConn != nil {
+		f.onConn(f, client.RemoteAddr(), true)
+	}
+	defer func() {
+		f.active.Add(-1)
+		if f.onConn != nil {
+			f.onConn(f, client.RemoteAddr(), false)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	pipe := func(dst, src net.Conn) {
+		defer wg.Done()
+		io.Copy(counter{dst, &f.bytes}, src)
+		// Half close, so the other side sees EOF but can still answer
+		if tc, ok := dst.(*net.TCPConn); ok {
+			tc.CloseWrite()
+		}
+	}
+	go pipe(upstream, client)
+	go pipe(client, upstream)
+	wg.Wait()
+}
+
+// counter adds the bytes written through it to n as they go, so the
+// counters move while a connection is open.
+type counter struct {
+	w io.Writer
+	n *atomic.Int64
+}
+
+func (c counter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+func (f *forwarder) Close() error {
+	return f.l.Close()
+}
+
+func (f *forwarder) String() string {
+	return fmt.Sprintf("%s -> %s, %d active, %d total, %s", f.addr, f.target, f.active.Load(), f.total.Load(), formatBytes(uint64(f.bytes.Load())))
+}
+
+// parseForward parses "8080:3000" or "8080:host:3000" into the listen port
+// and the target address; the target host defaults to localhost.
+func parseForward(spec string) (uint32, string, error) {
+	listen, target, ok := strings.Cut(spec, ":")
+	if !ok {
+		return 0, "", usageError("invalid forward %q, expected LISTEN:TARGET", spec)
+	}
+	port, err := strconv.ParseUint(listen, 10, 16)
+	if err != nil || port == 0 {
+		return 0, "", usageError("invalid listen port %q", listen)
+	}
+	if _, err := strconv.ParseUint(target, 10, 16); err == nil {
+		target = "127.0.0.1:" + target
+	} else if _, _, err := net.SplitHostPort(target); err != nil {
+		return 0, "", usageError("invalid forward target %q", target)
+	}
+	return uint32(port), target, nil
+}
+
+// runForward proxies a port from the command line until interrupted.
+func runForward(args []string) error {
+	fs := flag.NewFlagSet("forward", flag.ExitOnError)
+	bind := fs.String("bind", "127.0.0.1", "address to listen on, e.g. 0.0.0.0 to accept connections from other machines")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return usageError("usage: port-monitor forward LISTEN:[HOST:]PORT")
+	}
+	port, target, err := parseForward(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	f, err := startForwarder(*bind, port, target)
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", port, err)
+	}
+	defer f.Close()
+	f.onConn = func(f *forwarder, from net.Addr, opened bool) {
+		verb := "closed"
+		if opened {
+			verb = "opened"
+		}
+		log.Printf("%s %s (%s)", from, verb, f)
+	}
+
+	log.Printf("forwarding %s to %s, Ctrl+C to stop", f.addr, target)
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+	return nil
+}
+
+// toggleForward stops the proxy to c, or starts one on the next free port
+// above it, and describes what happened. The proxy listens on the address
+// c is bound to, so it's reachable from no further than c itself.
+func (m *model) toggleForward(c scanner.Connection) string {
+	target := hostPort(dialAddr(c.LocalAddr), c.Port)
+	for i, f := range m.forwards {
+		if f.target == target || f.port == c.Port {
+			f.Close()
+			m.forwards = append(m.forwards[:i], m.forwards[i+1:]...)
+			return fmt.Sprintf("Stopped forwarding %s to %s", f.addr, f.target)
+		}
+	}
+
+	port, err := nextFreePort(c.Port+1, min(c.Port+1000, 65535), m.processes, "", time.Time{})
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	host := c.LocalAddr
+	if net.ParseIP(host) == nil {
+		host = "127.0.0.1"
+	}
+	f, err := startForwarder(host, port, target)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	m.forwards = append(m.forwards, f)
+	return fmt.Sprintf("Forwarding %s to %s, press T on either port to stop", f.addr, target)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"port-monitor/portmap"
+	"port-monitor/scanner"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const gatewayTimeout = 15 * time.Second
+
+type gatewayMsg struct {
+	gw  portmap.Gateway
+	err error
+}
+
+// gatewayCmd asks the router for its port mappings.
+func gatewayCmd() tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), gatewayTimeout)
+		defer cancel()
+		gw, err := portmap.Query(ctx)
+		return gatewayMsg{gw: gw, err: err}
+	}
+}
+
+// localIPs returns the addresses of this machine's interfaces.
+func localIPs() map[string]bool {
+	ips := make(map[string]bool)
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, a := range addrs {
+			if ipnet, ok := a.(*net.IPNet); ok {
+				ips[ipnet.IP.String()] = true
+			}
+		}
+	}
+	return ips
+}
+
+// forwardsTo reports whether a router mapping reaches listener c. Loopback
+// listeners don't count, the router can't reach them.
+func forwardsTo(mp portmap.Mapping, c scanner.Connection) bool {
+	if c.Status != "LISTEN" || c.Port != mp.InternalPort || !strings.HasPrefix(c.Protocol, mp.Protocol) || !c.IsExposed() {
+		return false
+	}
+	switch c.LocalAddr {
+	case "", "0.0.0.0", "::", mp.InternalHost:
+		return true
+	}
+	return false
+}
+
+// mappedProcess finds the local process a router mapping forwards to.
+func mappedProcess(procs []scanner.ProcessInfo, mp portmap.Mapping) *scanner.ProcessInfo {
+	for i := range procs {
+		for _, c := range procs[i].Connections {
+			if forwardsTo(mp, c) {
+				return &procs[i]
+			}
+		}
+	}
+	return nil
+}
+
+// internetNote marks a listener the router forwards a public port to.
+func (m *model) internetNote(c scanner.Connection) string {
+	if m.gateway == nil || m.host != "" {
+		return ""
+	}
+	local := localIPs()
+	for _, mp := range m.gateway.Mappings {
+		if mp.Enabled && local[mp.InternalHost] && forwardsTo(mp, c) {
+			if m.gateway.ExternalIP == "" {
+				return fmt.Sprintf("internet: router port %d", mp.ExternalPort)
+			}
+			return "internet: " + hostPort(m.gateway.ExternalIP, mp.ExternalPort)
+		}
+	}
+	return ""
+}
+
+// gatewayReport lists the mappings of the router, saying for those that
+// point at this machine what listens behind them.
+func (m *model) gatewayReport() string {
+	gw := m.gateway
+	var b strings.Builder
+	fmt.Fprintf(&b, "Router %s (%s)", gw.Addr, gw.Protocol)
+	if gw.ExternalIP != "" {
+		fmt.Fprintf(&b, ", external IP %s", gw.ExternalIP)
+	}
+	b.WriteString("\n\n")
+	if !gw.Listed {
+		b.WriteString("NAT-PMP can't list port mappings, only UPnP can.")
+		return b.String()
+	}
+	if len(gw.Mappings) == 0 {
+		b.WriteString("No port mappings, nothing on the LAN is reachable from the internet through the router.")
+		return b.String()
+	}
+
+	local := localIPs()
+	var reachable []string
+	fmt.Fprintf(&b, "%d port mappings:\n", len(gw.Mappings))
+	for _, mp := range gw.Mappings {
+		line := fmt.Sprintf("%s %d %s %s", strings.ToUpper(mp.Protocol), mp.ExternalPort, glyph("→", "->"), hostPort(mp.InternalHost, mp.InternalPort))
+		if p := mappedProcess(m.processes, mp); !local[mp.InternalHost] {
+			line += "  other machine"
+		} else if p != nil {
+			line += fmt.Sprintf("  %s (PID %d)", p.Name, p.PID)
+			if mp.Enabled {
+				reachable = append(reachable, fmt.Sprintf("%d (%s)", mp.InternalPort, p.Name))
+			}
+		} else {
+			line += "  nothing listening here"
+		}
+		if !mp.Enabled {
+			line += "  disabled"
+		}
+		if mp.RemoteHost != "" {
+			line += "  only from " + mp.RemoteHost
+		}
+		if mp.Description != "" {
+			line += fmt.Sprintf("  %q", mp.Description)
+		}
+		b.WriteString(line + "\n")
+	}
+	if len(reachable) > 0 {
+		fmt.Fprintf(&b, "\nReachable from the internet: %s", strings.Join(reachable, ", "))
+	} else {
+		b.WriteString("\nNone of this machine's listeners is reachable from the internet.")
+	}
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"port-monitor/geoip"
+	"port-monitor/scanner"
+)
+
+// geoDBs are the MaxMind databases given with --geoip.
+type geoDBs []*geoip.DB
+
+// openGeoIP opens a comma separated list of .mmdb files, typically a
+// Country or City database plus an ASN one.
+func openGeoIP(paths string) (geoDBs, error) {
+	var dbs geoDBs
+	for _, path := range strings.Split(paths, ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		db, err := geoip.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		dbs = append(dbs, db)
+	}
+	return dbs, nil
+}
+
+// note describes where the peer of an established connection is, e.g.
+// "DE AS3320 Deutsche Telekom AG". Private and loopback peers have none.
+func (g geoDBs) note(c scanner.Connection) string {
+	if len(g) == 0 || c.Status != "ESTABLISHED" {
+		return ""
+	}
+	ip := net.ParseIP(c.RemoteAddr)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+		return ""
+	}
+	var merged geoip.Info
+	for _, db := range g {
+		info, err := db.Info(ip)
+		if err != nil {
+			continue
+		}
+		if merged.Country == "" {
+			merged.Country = info.Country
+		}
+		if merged.ASN == 0 {
+			merged.ASN, merged.Org = info.ASN, info.Org
+		}
+	}
+	var parts []string
+	if merged.Country != "" {
+		parts = append(parts, merged.Country)
+	}
+	if merged.ASN != 0 {
+		parts = append(parts, fmt.Sprintf("AS%d", merged.ASN))
+	}
+	if merged.Org != "" {
+		parts = append(parts, merged.Org)
+	}
+	return strings.Join(parts, " ")
+}
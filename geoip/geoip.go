@@ -0,0 +1,292 @@
+// Package geoip looks up IP addresses in MaxMind DB files (.mmdb), such as
+// the GeoLite2 Country, City and ASN databases.
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+)
+
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// DB is an open MaxMind database, read into memory.
+type DB struct {
+	Type       string // database_type, e.g. "GeoLite2-ASN"
+	data       []byte
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	dataStart  uint
+	ipv4Start  uint // Node of ::/96, where IPv4 lookups in an IPv6 tree start
+}
+
+// Open reads a database file.
+func Open(path string) (*DB, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	i := bytes.LastIndex(data, metadataMarker)
+	if i < 0 {
+		return nil, fmt.Errorf("%s is not a MaxMind database", path)
+	}
+	db := &DB{data: data}
+	d := decoder{buf: data[i+len(metadataMarker):]}
+	v, _, err := d.decode(0)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid metadata: %w", path, err)
+	}
+	meta, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: invalid metadata", path)
+	}
+	db.nodeCount = uint(toUint(meta["node_count"]))
+	db.recordSize = uint(toUint(meta["record_size"]))
+	db.ipVersion = uint(toUint(meta["ip_version"]))
+	db.Type, _ = meta["database_type"].(string)
+	switch db.recordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("%s: unsupported record size %d", path, db.recordSize)
+	}
+	treeSize := db.nodeCount * db.recordSize / 4
+	db.dataStart = treeSize + 16
+	if db.dataStart > uint(len(data)) {
+		return nil, fmt.Errorf("%s: truncated search tree", path)
+	}
+
+	if db.ipVersion == 6 {
+		node := uint(0)
+		for i := 0; i < 96 && node < db.nodeCount; i++ {
+			node = db.record(node, 0)
+		}
+		db.ipv4Start = node
+	}
+	return db, nil
+}
+
+// Lookup returns the record of ip, a tree of maps, slices, strings,
+// numbers and booleans, or nil when the database has none.
+func (db *DB) Lookup(ip net.IP) (any, error) {
+	node := uint(0)
+	bits := 128
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		bits = 32
+		if db.ipVersion == 6 {
+			node = db.ipv4Start
+		}
+	} else if db.ipVersion == 4 {
+		return nil, nil
+	}
+
+	for i := 0; i < bits && node < db.nodeCount; i++ {
+		bit := uint(ip[i/8]>>(7-i%8)) & 1
+		node = db.record(node, bit)
+	}
+	if node <= db.nodeCount {
+		return nil, nil
+	}
+	offset := node - db.nodeCount - 16
+	d := decoder{buf: db.data[db.dataStart:]}
+	v, _, err := d.decode(offset)
+	return v, err
+}
+
+// record reads the left (0) or right (1) record of a search tree node.
+func (db *DB) record(node, bit uint) uint {
+	size := db.recordSize * 2 / 8
+	b := db.data[node*size : (node+1)*size]
+	switch db.recordSize {
+	case 24:
+		b = b[bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		if bit == 0 {
+			return uint(b[3]&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(b[bit*4:]))
+	}
+}
+
+// decoder reads the data section format of the MaxMind DB spec.
+type decoder struct {
+	buf []byte
+}
+
+var errCorrupt = errors.New("corrupt data section")
+
+// maxDepth caps how many pointers and nested maps and arrays decode
+// follows, so a pointer back to an enclosing value can't recurse forever.
+const maxDepth = 512
+
+// decode reads the value at offset, returning it and the offset after it.
+func (d decoder) decode(offset uint) (any, uint, error) {
+	return d.decodeAt(offset, 0)
+}
+
+// decodeAt is decode for a value depth pointers and containers deep.
+func (d decoder) decodeAt(offset, depth uint) (any, uint, error) {
+	if depth > maxDepth {
+		return nil, 0, errors.New("data section nested too deeply")
+	}
+	if offset >= uint(len(d.buf)) {
+		return nil, 0, errCorrupt
+	}
+	ctrl := d.buf[offset]
+	offset++
+	typ := uint(ctrl >> 5)
+
+	if typ == 1 {
+		// Pointer: the value is elsewhere, decoding goes on after it
+		ptr, next, err := d.pointer(ctrl, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		v, _, err := d.decodeAt(ptr, depth+1)
+		return v, next, err
+	}
+	if typ == 0 {
+		if offset >= uint(len(d.buf)) {
+			return nil, 0, errCorrupt
+		}
+		typ = 7 + uint(d.buf[offset])
+		offset++
+	}
+
+	size := uint(ctrl & 0x1F)
+	if size >= 29 {
+		n := size - 28
+		if offset+n > uint(len(d.buf)) {
+			return nil, 0, errCorrupt
+		}
+		var extra uint
+		for _, b := range d.buf[offset : offset+n] {
+			extra = extra<<8 | uint(b)
+		}
+		offset += n
+		size = []uint{29, 285, 65821}[n-1] + extra
+	}
+
+	switch typ {
+	case 7: // map
+		m := make(map[string]any, size)
+		for range size {
+			k, next, err := d.decodeAt(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			v, next, err := d.decodeAt(next, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			key, _ := k.(string)
+			m[key] = v
+			offset = next
+		}
+		return m, offset, nil
+	case 11: // array
+		a := make([]any, 0, size)
+		for range size {
+			v, next, err := d.decodeAt(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			a = append(a, v)
+			offset = next
+		}
+		return a, offset, nil
+	case 14: // boolean, the size is the value
+		return size != 0, offset, nil
+	}
+
+	if offset+size > uint(len(d.buf)) {
+		return nil, 0, errCorrupt
+	}
+	b := d.buf[offset : offset+size]
+	offset += size
+	switch typ {
+	case 2: // UTF-8 string
+		return string(b), offset, nil
+	case 3: // double
+		if size != 8 {
+			return nil, 0, errCorrupt
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), offset, nil
+	case 15: // float
+		if size != 4 {
+			return nil, 0, errCorrupt
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), offset, nil
+	case 4: // bytes
+		return append([]byte(nil), b...), offset, nil
+	case 5, 6, 9, 10: // uint16, uint32, uint64, uint128 (truncated)
+		var n uint64
+		for _, c := range b {
+			n = n<<8 | uint64(c)
+		}
+		return n, offset, nil
+	case 8: // int32
+		var n uint32
+		for _, c := range b {
+			n = n<<8 | uint32(c)
+		}
+		return int64(int32(n)), offset, nil
+	}
+	return nil, 0, fmt.Errorf("unsupported data type %d", typ)
+}
+
+// pointer reads the target of a pointer whose control byte is ctrl.
+func (d decoder) pointer(ctrl byte, offset uint) (uint, uint, error) {
+	n := uint(ctrl>>3)&3 + 1
+	if offset+n > uint(len(d.buf)) {
+		return 0, 0, errCorrupt
+	}
+	var v uint
+	if n < 4 {
+		v = uint(ctrl & 7)
+	}
+	for _, b := range d.buf[offset : offset+n] {
+		v = v<<8 | uint(b)
+	}
+	v += []uint{0, 2048, 526336, 0}[n-1]
+	return v, offset + n, nil
+}
+
+func toUint(v any) uint64 {
+	n, _ := v.(uint64)
+	return n
+}
+
+// Info is what a database knows about an address.
+type Info struct {
+	Country string // ISO code, e.g. "DE"
+	ASN     uint64
+	Org     string // Organization owning the ASN
+}
+
+// Info looks ip up and picks the fields of the Country, City and ASN
+// databases.
+func (db *DB) Info(ip net.IP) (Info, error) {
+	v, err := db.Lookup(ip)
+	if err != nil {
+		return Info{}, err
+	}
+	rec, _ := v.(map[string]any)
+	var info Info
+	for _, key := range []string{"country", "registered_country"} {
+		if c, ok := rec[key].(map[string]any); ok && info.Country == "" {
+			info.Country, _ = c["iso_code"].(string)
+		}
+	}
+	info.ASN = toUint(rec["autonomous_system_number"])
+	info.Org, _ = rec["autonomous_system_organization"].(string)
+	return info, nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"cmp"
+	"hash/fnv"
+	"slices"
+
+	"port-monitor/scanner"
+)
+
+// procGroup is a process together with same-name descendants (workers),
+// e.g. an nginx master and its worker processes.
+type procGroup struct {
+	head    scanner.ProcessInfo   // Aggregated row shown when collapsed
+	members []scanner.ProcessInfo // Group leader first, then its workers
+}
+
+// groupWorkers collapses processes whose parent chain leads to a process
+// with the same name. The head row sums CPU and memory and carries the
+// union of the members' connections. Order of procs is preserved.
+func groupWorkers(procs []scanner.ProcessInfo) []procGroup {
+	byPID := make(map[int32]scanner.ProcessInfo, len(procs))
+	for _, p := range procs {
+		byPID[p.PID] = p
+	}
+
+	var order []int32
+	members := make(map[int32][]scanner.ProcessInfo)
+	for _, p := range procs {
+		root := p
+		for range len(procs) { // Bounded in case of PID reuse loops
+			parent, ok := byPID[root.PPID]
+			if !ok || parent.Name != p.Name || parent.PID == root.PID {
+				break
+			}
+			root = parent
+		}
+		if _, ok := members[root.PID]; !ok {
+			order = append(order, root.PID)
+		}
+		members[root.PID] = append(members[root.PID], p)
+	}
+
+	groups := make([]procGroup, 0, len(order))
+	for _, pid := range order {
+		ms := members[pid]
+		// Leader first
+		for i, p := range ms {
+			if p.PID == pid {
+				ms[0], ms[i] = ms[i], ms[0]
+				break
+			}
+		}
+		groups = append(groups, procGroup{head: aggregate(ms), members: ms})
+	}
+	return groups
+}
+
+// groupUsers rolls processes up by the account owning them. The head row
+// is named after the user and sums its processes like a worker group; its
+// PID is userGroupPID, not any member's. Order of first appearance is
+// preserved.
+func groupUsers(procs []scanner.ProcessInfo) []procGroup {
+	var order []string
+	members := make(map[string][]scanner.ProcessInfo)
+	for _, p := range procs {
+		if _, ok := members[p.User]; !ok {
+			order = append(order, p.User)
+		}
+		members[p.User] = append(members[p.User], p)
+	}
+
+	groups := make([]procGroup, 0, len(order))
+	for _, user := range order {
+		ms := members[user]
+		slices.SortFunc(ms, func(a, b scanner.ProcessInfo) int { return cmp.Compare(a.PID, b.PID) })
+		head := aggregate(ms)
+		head.PID = userGroupPID(user)
+		head.PPID = 0
+		head.Name = user
+		if user == "" {
+			head.Name = "(unknown)"
+		}
+		groups = append(groups, procGroup{head: head, members: ms})
+	}
+	return groups
+}
+
+// userGroupPID is the PID of the row standing for user's processes. It's
+// negative, so it never names a real process, and stable across scans, so
+// the row keeps its expansion and the cursor.
+func userGroupPID(user string) int32 {
+	h := fnv.New32a()
+	h.Write([]byte(user))
+	return -int32(h.Sum32()>>1) - 1
+}
+
+// listenCount is the number of distinct ports p listens on.
+func listenCount(p scanner.ProcessInfo) int {
+	seen := make(map[uint32]bool)
+	for _, c := range p.Connections {
+		if c.Status == "LISTEN" {
+			seen[c.Port] = true
+		}
+	}
+	return len(seen)
+}
+
+func aggregate(ms []scanner.ProcessInfo) scanner.ProcessInfo {
+	head := ms[0]
+	if len(ms) == 1 {
+		return head
+	}
+
+	// Workers share their parent's sockets, so a socket is kept once; sockets
+	// on other addresses or to other peers are distinct even on one port
+	seen := make(map[scanner.Connection]struct{})
+	head.Connections = nil
+	head.CPUPercent = 0
+	head.MemoryUsage = 0
+	head.MemoryPercent = 0
+	head.SwapUsage = 0
+	head.DiskRead = 0
+	head.DiskWrite = 0
+	for _, p := range ms {
+		head.CPUPercent += p.CPUPercent
+		head.MemoryUsage += p.MemoryUsage
+		head.MemoryPercent += p.MemoryPercent
+		head.SwapUsage += p.SwapUsage
+		head.DiskRead += p.DiskRead
+		head.DiskWrite += p.DiskWrite
+		for _, c := range p.Connections {
+			if _, ok := seen[c]; ok {
+				continue
+			}
+			seen[c] = struct{}{}
+			head.Connections = append(head.Connections, c)
+		}
+	}
+	return head
+}
@@ -0,0 +1,142 @@
+package health
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	timeout = 2 * time.Second
+	slow    = time.Second // Slower answers count as degraded
+)
+
+// client doesn't follow redirects, a 3xx answer is healthy by itself, and
+// accepts the self-signed certificates of local dev servers.
+var client = &http.Client{
+	Timeout: timeout,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+	Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	},
+}
+
+type Status int
+
+const (
+	Unknown Status = iota
+	Up
+	Degraded
+	Down
+)
+
+func (s Status) String() string {
+	switch s {
+	case Up:
+		return "up"
+	case Degraded:
+		return "degraded"
+	case Down:
+		return "down"
+	}
+	return "unknown"
+}
+
+// Check probes a local port on every scan, with an HTTP GET when Path is
+// set and a plain TCP connect otherwise.
+type Check struct {
+	Port  uint32 `json:"port"`
+	Path  string `json:"path,omitempty"`  // e.g. "/healthz"
+	Host  string `json:"host,omitempty"`  // Defaults to 127.0.0.1
+	HTTPS bool   `json:"https,omitempty"` // Certificates are not verified
+}
+
+func (c Check) addr() string {
+	host := c.Host
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, strconv.FormatUint(uint64(c.Port), 10))
+}
+
+type Result struct {
+	Check   Check
+	Status  Status
+	Latency time.Duration
+	Detail  string // HTTP status or error
+}
+
+// Run performs a single check. Refused or timed out connections are Down,
+// HTTP errors (4xx/5xx) and answers slower than a second are Degraded.
+func Run(c Check) Result {
+	r := Result{Check: c}
+	start := time.Now()
+
+	if c.Path == "" {
+		latency, err := ConnectTime(c.addr())
+		r.Latency = latency
+		if err != nil {
+			r.Status, r.Detail = Down, err.Error()
+			return r
+		}
+		r.Status, r.Detail = Up, "connected"
+	} else {
+		scheme := "http"
+		if c.HTTPS {
+			scheme = "https"
+		}
+		resp, err := client.Get(fmt.Sprintf("%s://%s%s", scheme, c.addr(), c.Path))
+		r.Latency = time.Since(start)
+		if err != nil {
+			r.Status, r.Detail = Down, err.Error()
+			return r
+		}
+		resp.Body.Close()
+		r.Detail = resp.Status
+		r.Status = Up
+		if resp.StatusCode >= 400 {
+			r.Status = Degraded
+		}
+	}
+
+	if r.Status == Up && r.Latency > slow {
+		r.Status = Degraded
+		r.Detail += ", slow"
+	}
+	return r
+}
+
+// ConnectTime measures how long a TCP handshake with addr takes. The
+// kernel completes handshakes on behalf of the server until its accept
+// queue is full, so a timeout means the server stopped accepting.
+func ConnectTime(addr string) (time.Duration, error) {
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	latency := time.Since(start)
+	if err != nil {
+		return latency, err
+	}
+	conn.Close()
+	return latency, nil
+}
+
+// RunAll runs the checks concurrently, returning results in check order.
+func RunAll(checks []Check) []Result {
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = Run(c)
+		}()
+	}
+	wg.Wait()
+	return results
+}
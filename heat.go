@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+
+	"port-monitor/config"
+)
+
+// defaultHeat turns CPU yellow at half a core and red at a full one, and
+// memory at 500 MB and 2 GB.
+var defaultHeat = config.Heat{CPU: [2]float64{50, 100}, Mem: [2]float64{500, 2000}}
+
+// heatStops are the RGB colors at zero, the first and the second threshold.
+var heatStops = [3][3]float64{{95, 215, 95}, {255, 215, 0}, {255, 95, 95}}
+
+// heatOf fills in the thresholds cfg leaves unset or gets backwards.
+func heatOf(cfg config.Heat) config.Heat {
+	h := cfg
+	if h.CPU[0] <= 0 || h.CPU[1] <= h.CPU[0] {
+		h.CPU = defaultHeat.CPU
+	}
+	if h.Mem[0] <= 0 || h.Mem[1] <= h.Mem[0] {
+		h.Mem = defaultHeat.Mem
+	}
+	return h
+}
+
+// heatColor places v on the gradient from green at zero over yellow at
+// t[0] to red at t[1] and above.
+func heatColor(v float64, t [2]float64) lipgloss.Color {
+	from, to, f := heatStops[0], heatStops[1], v/t[0]
+	if v >= t[0] {
+		from, to, f = heatStops[1], heatStops[2], min((v-t[0])/(t[1]-t[0]), 1)
+	}
+	var rgb [3]int
+	for i := range rgb {
+		rgb[i] = int(from[i] + (to[i]-from[i])*f)
+	}
+	return lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2]))
+}
+
+// heatSpan is where a colored cell is on a table line, and how to read
+// its value back.
+type heatSpan struct {
+	from, to int
+	value    func(cell string) (float64, bool)
+	t        [2]float64
+}
+
+// heatCells colors the CPU% and Mem cells of the rendered table by load.
+// The table counts escape codes in its cells as text, so the rendered
+// lines are colored instead, the values being read back from the cells.
+// Idle cells stay plain and the cursor row keeps its highlight.
+func (m model) heatCells(view string) string {
+	if noColor || m.heat.Off {
+		return view
+	}
+	var spans []heatSpan
+	visible := m.visibleColumns()
+	x := 0
+	for i, c := range m.table.Columns() {
+		if c.Width <= 0 {
+			continue
+		}
+		if i >= 4 {
+			from, to := x+cellPadding/2, x+cellPadding/2+c.Width
+			switch visible[i-4].id {
+			case "cpu":
+				spans = append(spans, heatSpan{from, to, m.cpuHeat, m.heat.CPU})
+			case "mem":
+				spans = append(spans, heatSpan{from, to, memHeat, m.heat.Mem})
+			}
+		}
+		x += c.Width + cellPadding
+	}
+	if len(spans) == 0 {
+		return view
+	}
+	slices.SortFunc(spans, func(a, b heatSpan) int { return a.from - b.from })
+	var cuts []int
+	for _, s := range spans {
+		cuts = append(cuts, s.from, s.to)
+	}
+
+	lines := strings.Split(view, "\n")
+	for i := 2; i < len(lines); i++ { // Below the header and its border
+		if strings.Contains(lines[i], "\x1b") {
+			continue // The cursor row
+		}
+		parts := cutAt(lines[i], cuts)
+		for j, s := range spans {
+			cell := parts[2*j+1]
+			if v, ok := s.value(cell); ok && v > 0 {
+				parts[2*j+1] = lipgloss.NewStyle().Foreground(heatColor(v, s.t)).Render(cell)
+			}
+		}
+		lines[i] = strings.Join(parts, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// cpuHeat reads a CPU% cell back in percent of one core.
+func (m model) cpuHeat(cell string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(cell), "%"), 64)
+	return v * float64(m.cpuCores()), err == nil
+}
+
+// memHeat reads a Mem cell, as written by formatBytes, back in MB.
+func memHeat(cell string) (float64, bool) {
+	fields := strings.Fields(cell)
+	if len(fields) != 2 {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(fields[0], 64)
+	exp := strings.IndexByte("BKMGTPE", fields[1][0])
+	if err != nil || exp < 0 {
+		return 0, false
+	}
+	for range exp {
+		v *= 1024
+	}
+	return v / (1024 * 1024), true
+}
+
+// cutAt splits a line without escape codes at the given increasing
+// screen columns.
+func cutAt(line string, cols []int) []string {
+	parts := make([]string, 0, len(cols)+1)
+	start, w := 0, 0
+	for i, r := range line {
+		for len(cols) > 0 && w >= cols[0] {
+			parts = append(parts, line[start:i])
+			start, cols = i, cols[1:]
+		}
+		w += runewidth.RuneWidth(r)
+	}
+	for range cols {
+		parts = append(parts, line[start:])
+		start = len(line)
+	}
+	return append(parts, line[start:])
+}
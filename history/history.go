@@ -0,0 +1,120 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"port-monitor/scanner"
+)
+
+// Snapshot is a single recorded scan.
+type Snapshot struct {
+	Time      time.Time             `json:"time"`
+	Processes []scanner.ProcessInfo `json:"processes"`
+}
+
+// DefaultPath returns the history file location inside the user's cache dir.
+func DefaultPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find cache dir: %w", err)
+	}
+	return filepath.Join(dir, "port-monitor", "history.jsonl"), nil
+}
+
+// Recorder appends snapshots to a JSON lines file.
+// Only processes holding connections are kept, and a snapshot is skipped
+// when its ports look exactly like the previous one, so the file stays small.
+type Recorder struct {
+	f    *os.File
+	enc  *json.Encoder
+	last string
+}
+
+func NewRecorder(path string) (*Recorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create history dir: %w", err)
+	}
+	// Snapshots hold full command lines, which can carry secrets
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	// OpenFile keeps the mode of an existing file
+	if err := f.Chmod(0o600); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to restrict history file: %w", err)
+	}
+	return &Recorder{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (r *Recorder) Record(t time.Time, procs []scanner.ProcessInfo) error {
+	var kept []scanner.ProcessInfo
+	for _, p := range procs {
+		if len(p.Connections) > 0 {
+			kept = append(kept, p)
+		}
+	}
+
+	sig := signature(kept)
+	if sig == r.last {
+		return nil
+	}
+	r.last = sig
+
+	return r.enc.Encode(Snapshot{Time: t, Processes: kept})
+}
+
+func (r *Recorder) Close() error {
+	return r.f.Close()
+}
+
+// signature identifies a snapshot by its processes and their ports,
+// ignoring fields like CPU that change on every scan.
+func signature(procs []scanner.ProcessInfo) string {
+	var parts []string
+	for _, p := range procs {
+		var conns []string
+		for _, c := range p.Connections {
+			conns = append(conns, fmt.Sprintf("%d/%s", c.Port, c.Status))
+		}
+		sort.Strings(conns)
+		parts = append(parts, fmt.Sprintf("%d:%s:%s", p.PID, p.Name, strings.Join(conns, ",")))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ";")
+}
+
+// Load reads every snapshot from a history file, oldest first.
+func Load(path string) ([]Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	var snaps []Snapshot
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for sc.Scan() {
+		var s Snapshot
+		if err := json.Unmarshal(sc.Bytes(), &s); err != nil {
+			continue // Skip partially written lines
+		}
+		snaps = append(snaps, s)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	sort.SliceStable(snaps, func(i, j int) bool {
+		return snaps[i].Time.Before(snaps[j].Time)
+	})
+	return snaps, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"port-monitor/remote"
+	"port-monitor/scanner"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// monitoredHost is one machine on the multi-host dashboard.
+type monitoredHost struct {
+	name   string
+	source processSource
+	procs  []scanner.ProcessInfo
+	err    error
+}
+
+type hostScanMsg struct {
+	host  int
+	procs []scanner.ProcessInfo
+	warn  scanner.Warnings
+	took  time.Duration
+	err   error
+}
+
+// hostList collects repeated or comma separated --host flags.
+type hostList []string
+
+func (h *hostList) String() string { return strings.Join(*h, ",") }
+
+func (h *hostList) Set(v string) error {
+	for _, host := range strings.Split(v, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			*h = append(*h, host)
+		}
+	}
+	return nil
+}
+
+// newMonitoredHost maps a --host value to a source; "local" is this machine.
+func newMonitoredHost(name string) monitoredHost {
+	if name == "local" || name == "localhost" {
+		return monitoredHost{name: "local", source: newLocalSource(scanner.Options{})}
+	}
+	return monitoredHost{name: name, source: remote.SSH{Host: name}}
+}
+
+// scanCmd scans the active source, or every host on the dashboard.
+func (m model) scanCmd() tea.Cmd {
+	if len(m.hosts) < 2 {
+		return scanProcessesCmd(m.source)
+	}
+
+	cmds := []tea.Cmd{func() tea.Msg { return scanStartMsg{} }}
+	for i, h := range m.hosts {
+		src := h.source
+		cmds = append(cmds, func() tea.Msg {
+			start := time.Now()
+			procs, warn, err := scanSource(src)
+			return hostScanMsg{host: i, procs: procs, warn: warn, took: time.Since(start), err: err}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// switchHost makes another dashboard host the one shown in the table.
+func (m *model) switchHost(i int) {
+	m.activeHost = i
+	h := m.hosts[i]
+	m.source = h.source
+	m.host = h.name
+	if h.name == "local" {
+		m.host = ""
+	}
+	m.processes = h.procs
+	m.diff = newScanDiff()
+	m.connRate = newConnRate()
+	m.listening = newListenTracker()
+	m.selectedPids = make(map[int32]struct{})
+	m.updateTable()
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"port-monitor/scanner"
+)
+
+// runKill kills whatever listens on a port, for scripts freeing a port
+// before starting a server.
+func runKill(args []string) error {
+	fs := flag.NewFlagSet("kill", flag.ExitOnError)
+	port := fs.Uint("port", 0, "kill the processes listening on this port")
+	fs.Parse(args)
+	if *port == 0 || *port > 65535 {
+		return usageError("usage: port-monitor kill --port PORT")
+	}
+
+	procs, err := scanner.Scan(context.Background(), scanner.Options{ListenOnly: true, Ports: []uint32{uint32(*port)}})
+	if err != nil {
+		return err
+	}
+
+	var killed, denied, failed int
+	var lastErr error
+	for _, p := range procs {
+		if !listensOn(p, uint32(*port)) {
+			continue
+		}
+		err := scanner.KillProcess(p.PID)
+		switch {
+		case err == nil:
+			killed++
+			fmt.Printf("killed %s (%d)\n", p.Name, p.PID)
+		case errors.Is(err, os.ErrPermission):
+			denied++
+			lastErr = err
+		default:
+			failed++
+			lastErr = err
+		}
+	}
+
+	switch {
+	case killed+denied+failed == 0:
+		return withCode(exitNoMatch, fmt.Errorf("nothing listens on port %d", *port))
+	case killed > 0 && lastErr != nil:
+		return withCode(exitPartialKill, fmt.Errorf("killed %d of %d processes on port %d: %w", killed, killed+denied+failed, *port, lastErr))
+	case failed == 0 && denied > 0:
+		return withCode(exitPermission, fmt.Errorf("permission denied killing %d process(es) on port %d", denied, *port))
+	}
+	return lastErr
+}
+
+func listensOn(p scanner.ProcessInfo, port uint32) bool {
+	for _, c := range p.Connections {
+		if c.Status == "LISTEN" && c.Port == port {
+			return true
+		}
+	}
+	return false
+}
+
+// killOrder deduplicates pids and orders them children first: a process
+// whose parent is also on the list is killed before it, so a supervisor
+// isn't left respawning workers the plan is about to kill anyway.
+func killOrder(pids []int32, procs []scanner.ProcessInfo) []int32 {
+	byPID := make(map[int32]scanner.ProcessInfo, len(procs))
+	for _, p := range procs {
+		byPID[p.PID] = p
+	}
+	var order []int32
+	victim := make(map[int32]bool)
+	for _, pid := range pids {
+		if !victim[pid] {
+			victim[pid] = true
+			order = append(order, pid)
+		}
+	}
+
+	// depth counts the ancestors of pid that are victims too
+	depth := make(map[int32]int, len(order))
+	for _, pid := range order {
+		p := byPID[pid]
+		for range len(procs) { // Bounded in case of PID reuse loops
+			parent, ok := byPID[p.PPID]
+			if !ok || parent.PID == p.PID {
+				break
+			}
+			if victim[parent.PID] {
+				depth[pid]++
+			}
+			p = parent
+		}
+	}
+	slices.SortStableFunc(order, func(a, b int32) int {
+		if depth[a] != depth[b] {
+			return depth[b] - depth[a]
+		}
+		return int(a - b)
+	})
+	return order
+}
+
+// killPlan describes a kill for the confirmation prompt: the processes in
+// the order they die and the ports several of them share.
+func killPlan(pids []int32, procs []scanner.ProcessInfo) string {
+	byPID := make(map[int32]scanner.ProcessInfo, len(procs))
+	for _, p := range procs {
+		byPID[p.PID] = p
+	}
+	names := make([]string, len(pids))
+	holders := make(map[uint32]int)
+	for i, pid := range pids {
+		p, ok := byPID[pid]
+		if !ok {
+			names[i] = fmt.Sprintf("PID %d", pid)
+			continue
+		}
+		names[i] = fmt.Sprintf("%s (%d)", p.Name, pid)
+		seen := make(map[uint32]bool)
+		for _, c := range p.Connections {
+			if c.Status == "LISTEN" && !seen[c.Port] {
+				seen[c.Port] = true
+				holders[c.Port]++
+			}
+		}
+	}
+	plan := strings.Join(names, " "+glyph("→", "->")+" ")
+	var shared []uint32
+	for port, n := range holders {
+		if n > 1 {
+			shared = append(shared, port)
+		}
+	}
+	if len(shared) > 0 {
+		slices.Sort(shared)
+		ports := make([]string, len(shared))
+		for i, port := range shared {
+			ports[i] = fmt.Sprint(port)
+		}
+		plan += "; sharing port " + strings.Join(ports, ", ")
+	}
+	return plan
+}
+
+// killVerifyTimeout is how long killed processes get to exit before they
+// are reported as still running.
+const killVerifyTimeout = 2 * time.Second
+
+var errStillRunning = errors.New("still running")
+
+// awaitExit polls until the processes pids exited or timeout passed, and
+// returns those still running.
+func awaitExit(pids []int32, timeout time.Duration) []int32 {
+	pids = slices.Clone(pids)
+	deadline := time.Now().Add(timeout)
+	for {
+		pids = slices.DeleteFunc(pids, func(pid int32) bool { return !scanner.Alive(pid) })
+		if len(pids) == 0 || time.Now().After(deadline) {
+			return pids
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// killOutcome is what killing one process did: nil when it died.
+type killOutcome struct {
+	pid int32
+	err error
+}
+
+// killReport lists the outcome of a batch kill per PID.
+func killReport(outcomes []killOutcome, procs []scanner.ProcessInfo) string {
+	names := make(map[int32]string, len(procs))
+	for _, p := range procs {
+		names[p.PID] = p.Name
+	}
+	var b strings.Builder
+	var killed, denied, gone, running, failed int
+	for _, o := range outcomes {
+		var result string
+		switch {
+		case o.err == nil:
+			result = "killed"
+			killed++
+		case errors.Is(o.err, os.ErrPermission):
+			result = "permission denied"
+			denied++
+		case scanner.IsGone(o.err):
+			result = "already gone"
+			gone++
+		case errors.Is(o.err, errStillRunning):
+			result = "still running"
+			running++
+		default:
+			result = "failed: " + o.err.Error()
+			failed++
+		}
+		name := names[o.pid]
+		if name == "" {
+			name = "?"
+		}
+		fmt.Fprintf(&b, "  %-8d %-20s %s\n", o.pid, name, result)
+	}
+	summary := fmt.Sprintf("Killed %d of %d processes", killed, len(outcomes))
+	for _, n := range []struct {
+		count int
+		what  string
+	}{{denied, "denied"}, {gone, "already gone"}, {running, "still running"}, {failed, "failed"}} {
+		if n.count > 0 {
+			summary += fmt.Sprintf(", %d %s", n.count, n.what)
+		}
+	}
+	if denied > 0 {
+		summary += "\nDenied processes are retried with administrator rights where possible."
+	}
+	return "Kill results\n\n" + summary + "\n\n" + b.String()
+}
+
+// elevatedReport is the line a batch kill report gets once the retry with
+// administrator rights is done.
+func elevatedReport(msg elevatedKillMsg) string {
+	if msg.err != nil {
+		return fmt.Sprintf("Administrator retry of %d process(es) failed: %v", len(msg.pids), msg.err)
+	}
+	return fmt.Sprintf("Administrator retry killed %d process(es).", len(msg.pids))
+}
+
+const protectedNote = "Not killing port-monitor or the shell it runs in (--allow-kill-self overrides)."
+
+// protectedPIDs are the monitor itself and the processes it runs under:
+// the shell that started it, the terminal or multiplexer, and so on up.
+// Killing one would end the session the monitor runs in. Remote hosts
+// have none.
+func (m *model) protectedPIDs() map[int32]bool {
+	if m.allowKillSelf || m.host != "" {
+		return nil
+	}
+	return protectedPIDs(m.processes)
+}
+
+// protectedPIDs finds the monitor's protected processes in a local scan.
+func protectedPIDs(procs []scanner.ProcessInfo) map[int32]bool {
+	byPID := make(map[int32]scanner.ProcessInfo, len(procs))
+	for _, p := range procs {
+		byPID[p.PID] = p
+	}
+	protected := map[int32]bool{int32(os.Getpid()): true}
+	pid := int32(os.Getppid())
+	for range len(procs) + 1 { // Bounded in case of PID reuse loops
+		if pid <= 1 || protected[pid] {
+			break
+		}
+		protected[pid] = true
+		p, ok := byPID[pid]
+		if !ok {
+			break
+		}
+		pid = p.PPID
+	}
+	return protected
+}
+
+// unprotected drops the protectedPIDs from pids.
+func (m *model) unprotected(pids []int32) []int32 {
+	protected := m.protectedPIDs()
+	return slices.DeleteFunc(slices.Clone(pids), func(pid int32) bool { return protected[pid] })
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"port-monitor/history"
+	"port-monitor/scanner"
+)
+
+type listenStart struct {
+	at     time.Time
+	before bool // Already listening when tracking began, so at is a lower bound
+}
+
+// listenTracker remembers when each listening port was first seen, to
+// find long-forgotten servers.
+type listenTracker struct {
+	started bool
+	since   map[portKey]listenStart
+}
+
+func newListenTracker() listenTracker {
+	return listenTracker{since: make(map[portKey]listenStart)}
+}
+
+// seed restores start times from recorded history: a port listening in the
+// latest snapshot has been up since the oldest snapshot of its unbroken run.
+func (t *listenTracker) seed(snaps []history.Snapshot) {
+	if len(snaps) == 0 {
+		return
+	}
+	first := snaps[0].Time
+	for _, s := range snaps {
+		present := make(map[portKey]struct{})
+		for _, p := range s.Processes {
+			for _, k := range listenKeys(p) {
+				present[k] = struct{}{}
+				if _, ok := t.since[k]; !ok {
+					t.since[k] = listenStart{at: s.Time, before: s.Time.Equal(first)}
+				}
+			}
+		}
+		for k := range t.since {
+			if _, ok := present[k]; !ok {
+				delete(t.since, k)
+			}
+		}
+	}
+}
+
+// observe records ports that started listening and forgets closed ones.
+func (t *listenTracker) observe(procs []scanner.ProcessInfo, now time.Time) {
+	present := make(map[portKey]struct{})
+	for _, p := range procs {
+		for _, k := range listenKeys(p) {
+			present[k] = struct{}{}
+			if _, ok := t.since[k]; !ok {
+				t.since[k] = listenStart{at: now, before: !t.started}
+			}
+		}
+	}
+	for k := range t.since {
+		if _, ok := present[k]; !ok {
+			delete(t.since, k)
+		}
+	}
+	t.started = true
+}
+
+// note describes how long a listener has been up, e.g. "for 3h 12m", with
+// a "+" when it was already listening before tracking began.
+func (t *listenTracker) note(pid int32, c scanner.Connection, now time.Time) string {
+	if c.Status != "LISTEN" {
+		return ""
+	}
+	s, ok := t.since[portKey{pid, c.Port}]
+	if !ok {
+		return ""
+	}
+	d := formatDuration(now.Sub(s.at))
+	if s.before {
+		d += "+"
+	}
+	return "for " + d
+}
+
+// formatDuration renders d coarsely: "2d 4h", "3h 12m", "45m", "30s".
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd %dh", int(d.Hours())/24, int(d.Hours())%24)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%ds", int(d.Seconds()))
+}
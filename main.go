@@ -1,12 +1,28 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"log"
 	"os"
+	"runtime"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"port-monitor/api"
+	"port-monitor/audit"
+	"port-monitor/config"
+	"port-monitor/eventlog"
+	"port-monitor/filter"
+	"port-monitor/firewall"
+	"port-monitor/health"
+	"port-monitor/history"
+	"port-monitor/portmap"
+	"port-monitor/remote"
 	"port-monitor/scanner"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -41,16 +57,29 @@ var (
 			Background(lipgloss.Color("57")).
 			Bold(true).
 			BorderForeground(lipgloss.Color("62"))
+
+	modalStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("196")).
+			Padding(1, 2)
 )
 
 type notificationTimeoutMsg struct{}
 
 type tickMsg time.Time
 
-type scanMsg []scanner.ProcessInfo
+type scanMsg struct {
+	procs []scanner.ProcessInfo
+	warn  scanner.Warnings
+	took  time.Duration
+}
 
 type scanStartMsg struct{}
 
+// changeMsg reports that the watcher saw a listener open/close or a
+// socket owner exit.
+type changeMsg struct{}
+
 type errMsg error
 
 const (
@@ -59,19 +88,51 @@ const (
 	SortPorts
 	SortCPU
 	SortMem
+	SortIO
+	SortConnRate
+	SortUser
+	sortModes // Number of sort modes
 )
 
+type destroyResultMsg struct {
+	conn scanner.Connection
+	err  error
+}
+
+type blockResultMsg struct {
+	plan firewall.Plan
+	err  error
+}
+
 type killResultMsg struct {
-	count int
-	err   error
+	count     int
+	killed    []int32
+	denied    []int32 // Not ours to kill; retried with OS authentication
+	survivors []int32 // Signalled but still running after killVerifyTimeout
+	err       error
+	outcomes  []killOutcome // Per PID, in kill order
+}
+
+type elevatedKillMsg struct {
+	pids []int32
+	err  error
 }
 
 type model struct {
+	source processSource
+	host   string // Remote host being monitored, empty for this machine
+
+	// Multi-host dashboard, switched with H. Empty when monitoring one host.
+	hosts        []monitoredHost
+	activeHost   int
 	table        table.Model
 	processes    []scanner.ProcessInfo
 	selectedPids map[int32]struct{}
-	activeTab    int // 0: User, 1: System
-	err          error
+	activeTab    int          // 0: User, 1: System
+	tabs         [2]*tabState // Filters, sort and cursor per tab, saved on leaving it
+	err          error        // Last scan failure, nil once a scan succeeds again
+	failures     int          // Scans failed in a row, retried less and less often
+	warning      string       // What the last scan couldn't read, shown as a banner
 	width        int
 	height       int
 	loading      bool
@@ -82,19 +143,196 @@ type model struct {
 	sortBy      int
 	sortDesc    bool
 
+	// System tab noise filters
+	hideKernelThreads bool
+	hideRootDaemons   bool
+
+	// Show only processes reachable from the network
+	filterExposed bool
+	filterStalled bool // Only stopped and zombie processes
+
+	// Show listeners as addr:port instead of bare port numbers
+	showBindAddr bool
+
+	// CPU as a share of all cores instead of one (%)
+	cpuAllCores bool
+
+	// Thresholds coloring CPU% and Mem cells by load
+	heat config.Heat
+
+	// Ids of the columns shown after Ports, in order, and the column
+	// chooser (O) saving them to the config file
+	columns         []string
+	choosingColumns bool
+	columnCursor    int
+	configPath      string
+
+	// Show the command one argument per line instead of wrapped
+	cmdPerArg bool
+
+	// One row per listening port instead of per process
+	perPort bool
+
+	// Worker grouping, or rolling processes up by user
+	groupWorkers bool
+	groupUsers   bool
+	expanded     map[int32]bool    // Group leader PID -> showing workers
+	userGroups   map[int32][]int32 // User row PID -> PIDs of the user's processes
+
+	// Detail pane connection cursor
+	connCursor    int
+	connCursorPID int32
+
+	// Reverse DNS of remote peers, nil when disabled
+	dns *dnsCache
+
+	// Packages owning the executables, resolved in the background
+	packages *packageCache
+
+	// pprof endpoints of Go servers, probed in the background
+	pprof *pprofCache
+
+	// SHA-256 and code signature of executables, computed in the background
+	signatures *signatureCache
+
+	// Cgroup limits of the selected process (Linux)
+	cgroups *cgroupCache
+
+	// WSL listener forwarding, nil when not running under WSL
+	wsl *wslView
+
+	// mDNS services advertised on local ports (--mdns)
+	mdns *mdnsView
+
+	// Containers behind the ports of podman/Lima/Colima/Docker Desktop VMs
+	vmPorts *vmPortsView
+
+	// MaxMind databases annotating remote peers (--geoip)
+	geo geoDBs
+
+	// Router port mappings, fetched with U
+	gateway *portmap.Gateway
+
+	// Rows of the table before formatting, in display order
+	rowItems []rowItem
+
 	// Search
 	textInput textinput.Model
 	searching bool
 
+	// Expression filter (--filter, edited with |)
+	exprInput   textinput.Model
+	editingExpr bool
+	expr        filter.Predicate
+
+	// Command prompt (:kill 8080, :goto 5432, :filter node)
+	promptInput textinput.Model
+	prompting   bool
+
+	// Command palette (ctrl+p)
+	paletteInput  textinput.Model
+	paletting     bool
+	paletteCursor int
+
 	// Kill & Interactions
 	confirming   bool
 	pendingPids  []int32
+	survivors    []int32              // Killed but still running, offered an administrator kill
+	pendingConn  *scanner.Connection  // Set when confirming a connection drop instead of a kill
+	pendingBlock *firewall.Plan       // Set when confirming a firewall rule instead of a kill
+	pendingUnit  *serviceAction       // Set when confirming a service restart/stop instead of a kill
+	pendingQuit  *scanner.ProcessInfo // Set when confirming a SIGQUIT stack dump instead of a kill
+	pendingDebug *debugAttach         // Set when confirming a debugger attach instead of a kill
 	notification string
+	events       []event // Recent notifications and changes, oldest first
+	ticking      bool    // Whether the status bar ticker is rotating
+
+	// Explain modal with equivalent shell commands
+	explaining  bool
+	explainText string
+	lastAction  []string // Shell equivalent of the last kill, drop or block
+
+	// Response modal of a test request
+	responding   bool
+	responseText string
+
+	// Modal with every port of a process, for when the cell is truncated
+	viewingPorts bool
+	portsText    string
+
+	// Let the monitor kill itself and the shell it runs in
+	allowKillSelf bool
+
+	// Security view of suspicious listeners (!)
+	viewingSecurity bool
+	heuristicsOff   map[string]bool
+
+	// Event log screen (M)
+	viewingLog bool
+	logScroll  int // Events scrolled back from the newest
+
+	// History
+	historyPath string
+	recorder    *history.Recorder
+	replaying   bool
+	snapshots   []history.Snapshot
+	snapIdx     int
+
+	// Changes since the previous scan
+	diff scanDiff
+
+	// Connections accepted per second by each process
+	connRate connRate
+
+	// When each listening port was first seen
+	listening listenTracker
+
+	// Ports held by placeholder listeners
+	reserved reservations
+
+	// Local TCP proxies started with T
+	forwards []*forwarder
+
+	// Ports with a connection count sparkline
+	watches []portWatch
+
+	// Killed processes watched for respawning
+	respawns []respawnWatch
+
+	// Health checks from the config file and their latest results
+	healthChecks  []health.Check
+	health        map[uint32]health.Result
+	healthRunning bool
+
+	// Debugger commands per runtime from the config file
+	attach map[string]string
+
+	// Auto-kill rules from the config file, nil when there are none
+	rules     *ruleEnforcer
+	auditPath string
+
+	// System log for port and kill events, nil when disabled
+	eventLog eventlog.Logger
+
+	// Scan interval, lengthened in adaptive mode when scans are slow
+	adaptive       bool
+	interval       time.Duration
+	intervalReason string
+
+	// Event-driven rescans on Linux, nil when polling only
+	watcher       *scanner.Watcher
+	rescanPending bool
+
+	// eBPF tracer adding connections that closed between scans, nil when off
+	tracer *scanner.Tracer
 }
 
 func newSpinnerModel() spinner.Model {
 	s := spinner.New()
 	s.Spinner = spinner.MiniDot
+	if asciiOnly {
+		s.Spinner = spinner.Line
+	}
 	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("62"))
 	return s
 }
@@ -105,20 +343,26 @@ func initialModel() model {
 		{Title: "PID", Width: 8},
 		{Title: "Name", Width: 20},
 		{Title: "Ports", Width: 15},
-		{Title: "CPU%", Width: 6},
-		{Title: "Mem", Width: 10},
-		{Title: "Type", Width: 8},
+	}
+	for _, id := range defaultColumns {
+		c, _ := columnDef(id)
+		columns = append(columns, table.Column{Title: c.title, Width: c.width})
 	}
 
+	// g and G open pprof, so only Home and End jump to the first and last row
+	keys := table.DefaultKeyMap()
+	keys.GotoTop.SetKeys("home")
+	keys.GotoBottom.SetKeys("end")
 	t := table.New(
 		table.WithColumns(columns),
 		table.WithFocused(true),
 		table.WithHeight(10), // Will be updated on resize
+		table.WithKeyMap(keys),
 	)
 
 	s := table.DefaultStyles()
 	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
+		BorderStyle(baseStyle.GetBorderStyle()).
 		BorderForeground(lipgloss.Color("240")).
 		BorderBottom(true).
 		Bold(true)
@@ -133,9 +377,28 @@ func initialModel() model {
 	ti.CharLimit = 156
 	ti.Width = 20
 
+	ei := textinput.New()
+	ei.Placeholder = `port >= 3000 && cpu > 10`
+	ei.CharLimit = 512
+	ei.Width = 40
+
+	pi := textinput.New()
+	pi.Prompt = ":"
+	pi.Placeholder = "kill 8080"
+	pi.CharLimit = 256
+	pi.Width = 40
+
+	ci := textinput.New()
+	ci.Prompt = "> "
+	ci.Placeholder = "Type to search actions"
+	ci.CharLimit = 64
+	ci.Width = 40
+
 	return model{
+		source:       newLocalSource(scanner.Options{}),
 		table:        t,
 		selectedPids: make(map[int32]struct{}),
+		expanded:     make(map[int32]bool),
 		activeTab:    0,
 		loading:      true,
 		spinner:      newSpinnerModel(),
@@ -143,39 +406,80 @@ func initialModel() model {
 		sortBy:       SortPorts, // Default sort by Ports
 		sortDesc:     true,
 		textInput:    ti,
+		exprInput:    ei,
+		promptInput:  pi,
+		paletteInput: ci,
 		searching:    false,
 		confirming:   false,
+		diff:         newScanDiff(),
+		connRate:     newConnRate(),
+		listening:    newListenTracker(),
+		packages:     newPackageCache(),
+		cgroups:      newCgroupCache(),
+		signatures:   newSignatureCache(),
+		reserved:     make(reservations),
+		pprof:        newPprofCache(),
+		interval:     baseInterval,
+		columns:      slices.Clone(defaultColumns),
+		heat:         defaultHeat,
 	}
 }
 
 func (m model) Init() tea.Cmd {
+	if m.replaying {
+		return textinput.Blink
+	}
 	return tea.Batch(
-		scanProcessesCmd(),
-		tickCmd(),
+		m.scanCmd(),
+		tickCmd(m.interval),
+		watchCmd(m.watcher),
 		textinput.Blink,
 	)
 }
 
-func scanProcessesCmd() tea.Cmd {
+// watchCmd waits for the next change seen by the watcher.
+func watchCmd(w *scanner.Watcher) tea.Cmd {
+	if w == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		<-w.Changes()
+		return changeMsg{}
+	}
+}
+
+func scanProcessesCmd(src processSource) tea.Cmd {
 	return tea.Batch(
 		func() tea.Msg { return scanStartMsg{} },
 		func() tea.Msg {
-			procs, err := scanner.ScanProcesses()
+			start := time.Now()
+			procs, warn, err := scanSource(src)
 			if err != nil {
 				return errMsg(err)
 			}
-			return scanMsg(procs)
+			return scanMsg{procs: procs, warn: warn, took: time.Since(start)}
 		},
 	)
 }
 
-func tickCmd() tea.Cmd {
-	return tea.Tick(time.Second*3, func(t time.Time) tea.Msg {
+func tickCmd(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(t time.Time) tea.Msg {
 		return tickMsg(t)
 	})
 }
 
+// Update handles msg and queues the notification it raised, if any.
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	before := m.notification
+	next, cmd := m.update(msg)
+	m = next.(model)
+	if m.notification != "" && m.notification != before {
+		cmd = tea.Batch(cmd, m.pushEvent(m.notification))
+	}
+	return m, cmd
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var spinnerCmd tea.Cmd
 	if m.loading {
@@ -198,16 +502,207 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		if m.editingExpr {
+			switch msg.String() {
+			case "enter":
+				pred, err := filter.Parse(m.exprInput.Value())
+				if err != nil {
+					m.notification = fmt.Sprintf("Filter: %v", err)
+					return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+				}
+				m.expr = pred
+				if strings.TrimSpace(m.exprInput.Value()) == "" {
+					m.expr = nil
+				}
+				fallthrough
+			case "esc":
+				m.editingExpr = false
+				m.exprInput.Blur()
+				m.table.Focus()
+				m.updateTable()
+				return m, spinnerCmd
+			default:
+				m.exprInput, cmd = m.exprInput.Update(msg)
+				return m, tea.Batch(cmd, spinnerCmd)
+			}
+		}
+
+		if m.prompting {
+			switch msg.String() {
+			case "enter", "esc":
+				m.prompting = false
+				m.promptInput.Blur()
+				m.table.Focus()
+				line := m.promptInput.Value()
+				m.promptInput.Reset()
+				if msg.String() == "esc" {
+					return m, spinnerCmd
+				}
+				return m, tea.Batch(m.runPrompt(line), spinnerCmd)
+			default:
+				m.promptInput, cmd = m.promptInput.Update(msg)
+				return m, tea.Batch(cmd, spinnerCmd)
+			}
+		}
+
+		if m.choosingColumns {
+			switch msg.String() {
+			case "O", "esc", "q", "enter":
+				m.choosingColumns = false
+			default:
+				if m.chooseColumn(msg.String()) {
+					if err := m.saveColumns(); err != nil {
+						return m, tea.Batch(m.notify(fmt.Sprintf("Error: %v", err)), spinnerCmd)
+					}
+				}
+			}
+			return m, spinnerCmd
+		}
+
+		if m.paletting {
+			switch msg.String() {
+			case "up", "ctrl+k":
+				m.paletteCursor = max(m.paletteCursor-1, 0)
+				return m, spinnerCmd
+			case "down", "ctrl+j":
+				m.paletteCursor = min(m.paletteCursor+1, max(len(paletteMatches(m.paletteInput.Value()))-1, 0))
+				return m, spinnerCmd
+			case "enter", "esc", "ctrl+p":
+				matches := paletteMatches(m.paletteInput.Value())
+				m.paletting = false
+				m.paletteInput.Blur()
+				m.paletteInput.Reset()
+				m.table.Focus()
+				if msg.String() != "enter" || m.paletteCursor >= len(matches) {
+					return m, spinnerCmd
+				}
+				return m.update(keyMsg(matches[m.paletteCursor].key))
+			default:
+				m.paletteInput, cmd = m.paletteInput.Update(msg)
+				m.paletteCursor = 0
+				return m, tea.Batch(cmd, spinnerCmd)
+			}
+		}
+
+		if m.explaining {
+			switch msg.String() {
+			case "c":
+				m.explaining = false
+				if err := copyToClipboard(m.explainText); err != nil {
+					m.notification = fmt.Sprintf("Error: %v", err)
+				} else {
+					m.notification = "Commands copied to clipboard."
+				}
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			case "E", "esc", "q", "enter":
+				m.explaining = false
+			}
+			return m, spinnerCmd
+		}
+
+		if m.responding {
+			switch msg.String() {
+			case "i", "I", "U", "esc", "q", "enter":
+				m.responding = false
+			}
+			return m, spinnerCmd
+		}
+
+		if m.viewingPorts {
+			switch msg.String() {
+			case "v", "esc", "q", "enter":
+				m.viewingPorts = false
+			}
+			return m, spinnerCmd
+		}
+
+		if m.viewingSecurity {
+			switch key := msg.String(); key {
+			case "!", "esc", "q", "enter":
+				m.viewingSecurity = false
+			default:
+				if i, err := strconv.Atoi(key); err == nil && i >= 1 && i <= len(heuristics) {
+					id := heuristics[i-1].id
+					m.heuristicsOff[id] = !m.heuristicsOff[id]
+				}
+			}
+			return m, spinnerCmd
+		}
+
+		if m.viewingLog {
+			switch key := msg.String(); key {
+			case "M", "esc", "q", "enter":
+				m.viewingLog = false
+			default:
+				m.scrollLog(key)
+			}
+			return m, spinnerCmd
+		}
+
 		if m.confirming {
 			switch strings.ToLower(msg.String()) {
 			case "y":
-				cmd = m.killPending()
 				m.confirming = false
+				if pids := m.survivors; pids != nil {
+					m.survivors = nil
+					cmd, err := scanner.ElevatedKillCommand(pids)
+					if err != nil {
+						m.notification = fmt.Sprintf("Error: can't escalate: %v", err)
+						return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+					}
+					return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+						return elevatedKillMsg{pids: pids, err: err}
+					})
+				}
+				if p := m.pendingQuit; p != nil {
+					m.pendingQuit = nil
+					return m, tea.Batch(quitProcessCmd(*p), spinnerCmd)
+				}
+				if a := m.pendingUnit; a != nil {
+					m.pendingUnit = nil
+					return m, tea.Batch(a.run(), spinnerCmd)
+				}
+				if d := m.pendingDebug; d != nil {
+					m.pendingDebug = nil
+					return m, attachCmd(d.command)
+				}
+				if m.pendingBlock != nil {
+					cmd = blockPortCmd(*m.pendingBlock)
+					m.notification = fmt.Sprintf("Blocking port %d...", m.pendingBlock.Port)
+					m.pendingBlock = nil
+					return m, tea.Batch(cmd, waitNotificationCmd(), spinnerCmd)
+				}
+				if m.pendingConn != nil {
+					cmd = destroyConnectionCmd(*m.pendingConn)
+					m.pendingConn = nil
+					m.notification = "Dropping connection..."
+					return m, tea.Batch(cmd, waitNotificationCmd(), spinnerCmd)
+				}
+				cmd = m.killPending()
 				m.notification = fmt.Sprintf("Killing %d process(s)...", len(m.pendingPids))
 				return m, tea.Batch(cmd, waitNotificationCmd(), spinnerCmd)
+			case "w":
+				d := m.pendingDebug
+				if d == nil {
+					return m, spinnerCmd
+				}
+				m.confirming = false
+				m.pendingDebug = nil
+				if err := attachInWindow(d.command); err != nil {
+					m.notification = fmt.Sprintf("Error: %v", err)
+				} else {
+					m.notification = "Opened " + d.command
+				}
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
 			case "n", "esc":
 				m.confirming = false
 				m.pendingPids = nil
+				m.survivors = nil
+				m.pendingConn = nil
+				m.pendingBlock = nil
+				m.pendingUnit = nil
+				m.pendingQuit = nil
+				m.pendingDebug = nil
 				m.notification = "Cancelled."
 				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
 			default:
@@ -217,123 +712,793 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		switch msg.String() {
 		case "q", "ctrl+c":
+			m.reserved.releaseAll()
+			for _, f := range m.forwards {
+				f.Close()
+			}
 			return m, tea.Quit
 		case "tab":
-			m.activeTab = (m.activeTab + 1) % 2
-			m.updateTable()
+			m.switchTab((m.activeTab + 1) % 2)
+		case "H":
+			if len(m.hosts) > 1 {
+				m.switchHost((m.activeHost + 1) % len(m.hosts))
+			}
 		case " ":
-			m.toggleSelection()
-			m.updateTable() // Refresh checks
-			return m, spinnerCmd // Prevent jumping (bubbles/table maps space to PageDown)
+			cmd := m.toggleSelection()
+			m.updateTable()                      // Refresh checks
+			return m, tea.Batch(cmd, spinnerCmd) // Prevent jumping (bubbles/table maps space to PageDown)
 		case "k":
-			m.startKillProcess()
+			if m.replaying {
+				m.notification = "Kill is disabled while replaying history."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			if cmd := m.startKillProcess(); cmd != nil {
+				return m, tea.Batch(cmd, spinnerCmd)
+			}
 		case "f":
 			m.filterPorts = !m.filterPorts
 			m.updateTable()
 		case "s":
-			m.sortBy = (m.sortBy + 1) % 5
+			m.sortBy = (m.sortBy + 1) % sortModes
 			m.updateTable()
+			m.layoutColumns()
 		case "o":
 			m.sortDesc = !m.sortDesc
 			m.updateTable()
+			m.layoutColumns()
+		case "t":
+			m.hideKernelThreads = !m.hideKernelThreads
+			m.updateTable()
+		case "r":
+			m.hideRootDaemons = !m.hideRootDaemons
+			m.updateTable()
+		case "e":
+			m.filterExposed = !m.filterExposed
+			m.updateTable()
+		case "z":
+			m.filterStalled = !m.filterStalled
+			m.updateTable()
+		case "a":
+			m.showBindAddr = !m.showBindAddr
+			m.layoutColumns()
+			m.updateTable()
+		case "m":
+			cols := slices.DeleteFunc(slices.Clone(m.columns), func(id string) bool { return id == "mem%" || id == "swap" })
+			if len(cols) == len(m.columns) {
+				at := slices.Index(cols, "mem") + 1
+				if at == 0 {
+					at = len(cols)
+				}
+				cols = slices.Insert(cols, at, "mem%", "swap")
+			}
+			m.setColumns(cols)
+		case "%":
+			if m.host != "" {
+				m.notification = "The core count is only known for this machine."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			m.cpuAllCores = !m.cpuAllCores
+			m.updateTable()
+			m.layoutColumns()
+		case "c":
+			m.cmdPerArg = !m.cmdPerArg
+			m.resizeTable()
+		case "l":
+			m.perPort = !m.perPort
+			m.layoutColumns()
+			m.updateTable()
+		case "w":
+			// Cycle: off, workers, users
+			m.groupWorkers, m.groupUsers = !m.groupWorkers && !m.groupUsers, m.groupWorkers
+			m.updateTable()
+		case "J":
+			m.moveConnCursor(1)
+			return m, spinnerCmd
+		case "K":
+			m.moveConnCursor(-1)
+			return m, spinnerCmd
+		case "F":
+			if m.replaying || m.host != "" {
+				m.notification = "Firewall rules only work for live local scans."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			c := m.selectedListener()
+			if c == nil {
+				m.notification = "No listening port selected."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			plan, err := firewall.BlockPlan(c.Port, c.Protocol)
+			if err != nil {
+				m.notification = fmt.Sprintf("Error: %v", err)
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			m.pendingBlock = &plan
+			m.confirming = true
+			return m, spinnerCmd
+		case "R", "S":
+			if m.replaying || m.host != "" {
+				m.notification = "Service control only works for live local scans."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			p := m.selectedProcess()
+			if p == nil {
+				m.notification = "No process selected."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			action := "restart"
+			if msg.String() == "S" {
+				action = "stop"
+			}
+			a, err := newServiceAction(*p, action)
+			if err != nil {
+				m.notification = fmt.Sprintf("Error: %v", err)
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			m.pendingUnit = a
+			m.confirming = true
+			return m, spinnerCmd
+		case "Q", "C":
+			if m.replaying || m.host != "" {
+				m.notification = "Diagnostic dumps only work for live local scans."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			p := m.selectedProcess()
+			if p == nil {
+				m.notification = "No process selected."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			if msg.String() == "Q" {
+				proc := *p
+				m.pendingQuit = &proc
+				m.confirming = true
+				return m, spinnerCmd
+			}
+			dump, err := coreDumpCmd(*p)
+			if err != nil {
+				m.notification = fmt.Sprintf("Error: %v", err)
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			return m, dump
+		case "g", "G":
+			p := m.selectedProcess()
+			var index string
+			if p != nil && !m.replaying && m.host == "" {
+				index = m.pprof.endpoint(*p)
+			}
+			if index == "" {
+				m.notification = "Selected process serves no pprof endpoint."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			if msg.String() == "G" {
+				m.notification = fmt.Sprintf("Recording %ds CPU profile of %s...", pprofProfileSeconds, p.Name)
+				return m, tea.Batch(downloadProfileCmd(index, p.Name), waitNotificationCmd(), spinnerCmd)
+			}
+			note, err := openPprofUI(index)
+			if err != nil {
+				m.notification = fmt.Sprintf("Error: %v", err)
+			} else {
+				m.notification = note
+			}
+			return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+		case "B":
+			if m.replaying || m.host != "" {
+				m.notification = "Attaching a debugger only works for live local scans."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			p := m.selectedProcess()
+			if p == nil {
+				m.notification = "No process selected."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			command, err := attachCommand(*p, m.attach)
+			if err != nil {
+				m.notification = fmt.Sprintf("Error: %v", err)
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			m.pendingDebug = &debugAttach{name: p.Name, pid: p.PID, command: command}
+			m.confirming = true
+			return m, spinnerCmd
+		case "p", "P":
+			if m.replaying || m.host != "" {
+				m.notification = "Packet capture only works for live local scans."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			c := m.selectedListener()
+			if c == nil {
+				m.notification = "No listening port selected."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			capture, err := captureCmd(c.Port, msg.String() == "P")
+			if err != nil {
+				m.notification = fmt.Sprintf("Error: %v", err)
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			return m, capture
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			if cmd := m.sortByColumn(int(msg.String()[0] - '0')); cmd != nil {
+				return m, tea.Batch(cmd, spinnerCmd)
+			}
+		case "O":
+			m.choosingColumns = true
+			m.columnCursor = 0
+		case "X":
+			if p := m.selectedProcess(); p != nil {
+				m.notification = fmt.Sprintf("Exporting %s (%d)...", p.Name, p.PID)
+				return m, tea.Batch(m.exportCmd(*p), waitNotificationCmd(), spinnerCmd)
+			}
+		case "E":
+			m.explainText = m.explanation()
+			if m.explainText == "" {
+				m.notification = "Nothing to explain, select a process first."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			m.explaining = true
+			return m, spinnerCmd
+		case "v":
+			p := m.selectedProcess()
+			if p == nil {
+				return m, spinnerCmd
+			}
+			m.portsText = m.portsModal(*p)
+			m.viewingPorts = true
+			return m, spinnerCmd
+		case "L":
+			c := m.selectedListener()
+			if c == nil || !strings.HasPrefix(c.Protocol, "tcp") || m.replaying || m.host != "" {
+				m.notification = "No local TCP listener selected."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			m.notification = fmt.Sprintf("Connecting to port %d...", c.Port)
+			return m, tea.Batch(measureLatencyCmd(*c), spinnerCmd)
+		case "V":
+			c := m.selectedListener()
+			if c == nil || m.replaying || m.host != "" {
+				m.notification = "No local listening port selected."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			m.notification = m.reserved.toggle(c.Port)
+			return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+		case "T":
+			c := m.selectedListener()
+			if c == nil || !strings.HasPrefix(c.Protocol, "tcp") || m.replaying || m.host != "" {
+				m.notification = "No local TCP listener selected."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			m.notification = m.toggleForward(*c)
+			return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+		case "i", "I":
+			c := m.selectedListener()
+			if c == nil || !strings.HasPrefix(c.Protocol, "tcp") || m.replaying || m.host != "" {
+				m.notification = "No local TCP listener selected."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			m.notification = fmt.Sprintf("Sending test request to port %d...", c.Port)
+			return m, tea.Batch(testRequestCmd(*c, msg.String() == "I"), spinnerCmd)
+		case "N":
+			var from uint32
+			if c := m.selectedListener(); c != nil {
+				from = c.Port
+			}
+			if m.host != "" {
+				m.notification = "Finding a free port only works for this machine."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			return m, tea.Batch(nextFreeCmd(from, m.processes, m.historyPath), spinnerCmd)
+		case "!":
+			m.viewingSecurity = true
+			return m, spinnerCmd
+		case "M":
+			m.viewingLog = true
+			m.logScroll = 0
+			return m, spinnerCmd
+		case "A":
+			p := m.selectedProcess()
+			if p == nil {
+				return m, spinnerCmd
+			}
+			kind, c := debugListener(*p, m.selectedListener())
+			if c == nil {
+				m.notification = fmt.Sprintf("%s has no node inspector, delve, debugpy or JDWP listener.", p.Name)
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			if m.host != "" || m.replaying {
+				m.notification = "Attach URLs only work for this machine."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			return m, tea.Batch(attachURLCmd(*p, kind, *c), spinnerCmd)
+		case "U":
+			if m.host != "" || m.replaying {
+				m.notification = "Router port mappings only work for this machine."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			m.notification = "Asking the router for its port mappings..."
+			return m, tea.Batch(gatewayCmd(), spinnerCmd)
+		case "W":
+			c := m.selectedListener()
+			if c == nil {
+				m.notification = "No listening port selected."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			if m.toggleWatch(c.Port) {
+				m.notification = fmt.Sprintf("Watching port %d", c.Port)
+			} else {
+				m.notification = fmt.Sprintf("Stopped watching port %d", c.Port)
+			}
+			m.resizeTable()
+			return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+		case "n":
+			if m.dns == nil {
+				m.dns = newDNSCache()
+				return m, tea.Batch(m.dns.resolveCmd(m.processes), spinnerCmd)
+			}
+			m.dns = nil
+			return m, spinnerCmd
+		case "x":
+			if m.replaying || m.host != "" {
+				m.notification = "Dropping connections only works for live local scans."
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			if c := m.selectedConnection(); c != nil {
+				m.pendingConn = c
+				m.confirming = true
+			}
+			return m, spinnerCmd
+		case "enter":
+			if m.groupWorkers || m.groupUsers {
+				m.toggleExpanded()
+				m.updateTable()
+			}
+		case "ctrl+p":
+			m.paletting = true
+			m.paletteCursor = 0
+			m.paletteInput.Focus()
+			m.table.Blur()
+			return m, tea.Batch(textinput.Blink, spinnerCmd)
+		case ":":
+			m.prompting = true
+			m.promptInput.Focus()
+			m.table.Blur()
+			return m, tea.Batch(textinput.Blink, spinnerCmd)
+		case "|":
+			m.editingExpr = true
+			m.exprInput.Focus()
+			m.table.Blur()
+			return m, tea.Batch(textinput.Blink, spinnerCmd)
 		case "/":
 			m.searching = true
 			m.textInput.Focus()
 			m.table.Blur()
 			return m, tea.Batch(textinput.Blink, spinnerCmd)
+		case "[":
+			m.seekSnapshot(m.snapIdx - 1)
+		case "]":
+			m.seekSnapshot(m.snapIdx + 1)
+		case "{":
+			m.seekSnapshot(0)
+		case "}":
+			m.seekSnapshot(len(m.snapshots) - 1)
 		}
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		m.table.SetHeight(m.height - 15) // Reserve extra space for header/footer/tabs
-
-		// Reserve margin for borders (2 for outer border, plus extra safety)
-		tableWidth := m.width - 4
-		m.table.SetWidth(tableWidth)
-
-		// Calculate column widths
-		// Fixed: X(2), PID(8), CPU(6), Mem(10), Type(8) -> Total 34
-		fixedWidths := 34
-		avail := tableWidth - fixedWidths
-		if avail < 0 {
-			avail = 0
-		}
-
-		// Distribute remainder: Name ~40%, Ports ~60%
-		nameW := int(float64(avail) * 0.4)
-		// Ensure name matches minimum usability if possible, but prioritized fitting
-		if nameW < 10 && avail >= 10 {
-			nameW = 10
-		}
-
-		portsW := avail - nameW
-
-		columns := []table.Column{
-			{Title: "X", Width: 2},
-			{Title: "PID", Width: 8},
-			{Title: "Name", Width: nameW},
-			{Title: "Ports", Width: portsW},
-			{Title: "CPU%", Width: 6},
-			{Title: "Mem", Width: 10},
-			{Title: "Type", Width: 8},
-		}
-		m.table.SetColumns(columns)
+		m.resizeTable()
+		m.layoutColumns()
+		m.updateTable() // Ports are truncated to the column width
 	case scanStartMsg:
 		m.loading = true
 		m.spinner = newSpinnerModel()
 		return m, m.spinner.Tick
 	case scanMsg:
-		m.processes = msg
+		var eventCmd tea.Cmd
+		if m.tracer != nil {
+			msg.procs = m.tracer.Attribute(msg.procs)
+			if err := m.tracer.Err(); err != nil {
+				m.tracer = nil
+				eventCmd = m.pushEvent(fmt.Sprintf("Error: eBPF tracing stopped: %v", err))
+			}
+		}
+		if m.eventLog != nil && m.diff.seen {
+			logPortEvents(m.eventLog, m.processes, msg.procs)
+		}
+		if m.diff.seen && !m.replaying {
+			for _, e := range scanner.Diff(m.processes, msg.procs, time.Now()) {
+				switch e.Type {
+				case scanner.PortOpened:
+					eventCmd = tea.Batch(eventCmd, m.pushEvent(e.String()))
+				case scanner.PortClosed:
+					m.logEvent(e.String())
+				}
+			}
+		}
+		if !m.replaying {
+			m.logScan(msg.procs, msg.took)
+		}
+		if m.err != nil {
+			eventCmd = tea.Batch(eventCmd, m.pushEvent(fmt.Sprintf("Scanning again after %d failure(s).", m.failures)))
+			m.err, m.failures = nil, 0
+		}
+		if w := msg.warn.String(); w != m.warning {
+			m.warning = w
+			m.resizeTable()
+			if w != "" {
+				eventCmd = tea.Batch(eventCmd, m.pushEvent("Scan incomplete: "+w))
+			}
+		}
+		m.diff.apply(m.processes, msg.procs)
+		m.connRate.observe(msg.procs, time.Now())
+		if !m.replaying {
+			m.listening.observe(msg.procs, time.Now())
+		}
+		sampleWatches(m.watches, msg.procs)
+		m.pruneSelection(msg.procs)
+		m.processes = msg.procs
 		m.loading = false
+		if m.adaptive {
+			m.interval, m.intervalReason = nextInterval(msg.took)
+		}
+		if m.recorder != nil {
+			if err := m.recorder.Record(time.Now(), msg.procs); err != nil {
+				m.notification = fmt.Sprintf("History: %v", err)
+			}
+		}
 		m.updateTable()
+
+		var ruleCmd tea.Cmd
+		if m.rules != nil {
+			ruleCmd = m.rules.enforceCmd(m.source, m.host, msg.procs, m.auditPath, m.eventLog)
+		}
+		if m.dns != nil {
+			ruleCmd = tea.Batch(ruleCmd, m.dns.resolveCmd(msg.procs))
+		}
+		if !m.replaying {
+			ruleCmd = tea.Batch(ruleCmd, m.packages.resolveCmd(msg.procs))
+		}
+		if !m.replaying && m.host == "" {
+			ruleCmd = tea.Batch(ruleCmd, m.pprof.probeCmd(msg.procs))
+			ruleCmd = tea.Batch(ruleCmd, m.signatures.resolveCmd(msg.procs))
+			ruleCmd = tea.Batch(ruleCmd, latencyCmd(m.watches))
+			if len(m.healthChecks) > 0 && !m.healthRunning {
+				m.healthRunning = true
+				ruleCmd = tea.Batch(ruleCmd, healthCmd(m.healthChecks))
+			}
+		}
+		if m.wsl != nil && !m.replaying {
+			ruleCmd = tea.Batch(ruleCmd, m.wsl.refreshCmd())
+		}
+		if m.vmPorts != nil && !m.replaying {
+			ruleCmd = tea.Batch(ruleCmd, m.vmPorts.refreshCmd(msg.procs))
+		}
+		if m.mdns != nil && !m.replaying && m.host == "" {
+			ruleCmd = tea.Batch(ruleCmd, m.mdns.refreshCmd())
+		}
+		if m.watcher != nil {
+			var owners []int32
+			for _, p := range msg.procs {
+				if len(p.Connections) > 0 {
+					owners = append(owners, p.PID)
+				}
+			}
+			m.watcher.Watch(owners)
+			if m.rescanPending {
+				m.rescanPending = false
+				ruleCmd = tea.Batch(ruleCmd, m.scanCmd())
+			}
+		}
+
+		if taken := m.reserved.retry(); len(taken) > 0 {
+			m.notification = fmt.Sprintf("Now holding freed port(s) %v", taken)
+			ruleCmd = tea.Batch(ruleCmd, waitNotificationCmd())
+		}
+
+		var respawned []string
+		m.respawns, respawned = checkRespawns(m.respawns, msg.procs, time.Now())
+		if len(respawned) > 0 {
+			m.notification = strings.Join(respawned, "; ")
+			return m, tea.Batch(ruleCmd, eventCmd, waitNotificationCmd(), spinnerCmd)
+		}
+		return m, tea.Batch(ruleCmd, eventCmd, spinnerCmd)
+	case ruleActionMsg:
+		if len(msg) == 0 {
+			return m, spinnerCmd
+		}
+		m.notification = strings.Join(msg, "; ")
+		return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
 	case tickMsg:
-		return m, tea.Batch(scanProcessesCmd(), tickCmd(), spinnerCmd)
+		return m, tea.Batch(m.scanCmd(), tickCmd(retryInterval(m.interval, m.failures)), spinnerCmd)
+	case changeMsg:
+		// A scan already running may have missed the change; run another after it
+		if m.loading {
+			m.rescanPending = true
+			return m, tea.Batch(watchCmd(m.watcher), spinnerCmd)
+		}
+		return m, tea.Batch(m.scanCmd(), watchCmd(m.watcher), spinnerCmd)
 	case killResultMsg:
+		// Batch kills get a modal with the outcome of every PID
+		if len(msg.outcomes) > 1 {
+			m.responseText = killReport(msg.outcomes, m.processes)
+			m.responding = true
+		}
+		// Root-owned processes: ask the OS to authenticate and retry elevated
+		if len(msg.denied) > 0 {
+			if _, local := m.source.(localSource); local {
+				// Survivors are retried along with them
+				pids := append(msg.denied, msg.survivors...)
+				if cmd, err := scanner.ElevatedKillCommand(pids); err == nil {
+					m.recordKills(msg.killed)
+					return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+						return elevatedKillMsg{pids: pids, err: err}
+					})
+				}
+			}
+			if msg.err == nil {
+				msg.err = fmt.Errorf("permission denied killing %d process(s)", len(msg.denied))
+			}
+		}
 		if msg.err != nil {
 			m.notification = fmt.Sprintf("Error: %v", msg.err)
+		} else if msg.count == 0 && len(msg.denied) == 0 {
+			m.notification = "Already gone: nothing left to kill."
 		} else {
 			m.notification = fmt.Sprintf("Successfully killed %d process(s)", msg.count)
 			// Clear selection if successful
 			m.selectedPids = make(map[int32]struct{})
 		}
-		return m, tea.Batch(scanProcessesCmd(), waitNotificationCmd(), spinnerCmd)
+		m.recordKills(msg.killed)
+		if len(msg.survivors) > 0 && msg.err == nil {
+			m.survivors = msg.survivors
+			m.confirming = true
+			return m, tea.Batch(m.scanCmd(), spinnerCmd)
+		}
+		return m, tea.Batch(m.scanCmd(), waitNotificationCmd(), spinnerCmd)
+	case serviceDoneMsg:
+		if msg.err != nil {
+			m.notification = fmt.Sprintf("Error: %s %s failed: %v", msg.action, msg.unit, msg.err)
+		} else {
+			m.notification = fmt.Sprintf("Service %s: %s done", msg.unit, msg.action)
+			m.lastAction = []string{msg.String()}
+		}
+		return m, tea.Batch(m.scanCmd(), waitNotificationCmd(), spinnerCmd)
+	case quitResultMsg:
+		if msg.err != nil {
+			m.notification = fmt.Sprintf("Error: SIGQUIT failed: %v", msg.err)
+		} else {
+			m.notification = quitNote(msg)
+		}
+		return m, tea.Batch(m.scanCmd(), waitNotificationCmd(), spinnerCmd)
+	case coreDumpMsg:
+		if msg.err != nil {
+			m.notification = fmt.Sprintf("Error: core dump failed: %v", msg.err)
+		} else {
+			m.notification = "Core dump saved to " + msg.file
+		}
+		return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+	case profileSavedMsg:
+		if msg.err != nil {
+			m.notification = fmt.Sprintf("Error: profile failed: %v", msg.err)
+		} else {
+			m.notification = "Profile saved to " + msg.file
+		}
+		return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+	case attachDoneMsg:
+		if msg.err != nil {
+			m.notification = fmt.Sprintf("Error: debugger failed: %v", msg.err)
+			return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+		}
+		return m, spinnerCmd
+	case captureDoneMsg:
+		switch {
+		case msg.err != nil:
+			m.notification = fmt.Sprintf("Error: capture failed: %v", msg.err)
+		case msg.file != "":
+			m.notification = "Capture saved to " + msg.file
+		default:
+			return m, spinnerCmd
+		}
+		return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+	case elevatedKillMsg:
+		if m.responding {
+			m.responseText += "\n" + elevatedReport(msg)
+		}
+		if msg.err != nil {
+			m.notification = fmt.Sprintf("Error: authenticated kill failed: %v", msg.err)
+		} else {
+			m.notification = fmt.Sprintf("Killed %d process(s) as administrator", len(msg.pids))
+			m.selectedPids = make(map[int32]struct{})
+			m.recordKills(msg.pids)
+		}
+		return m, tea.Batch(m.scanCmd(), waitNotificationCmd(), spinnerCmd)
+	case blockResultMsg:
+		if msg.err != nil {
+			m.notification = fmt.Sprintf("Error: %v", msg.err)
+		} else {
+			m.notification = fmt.Sprintf("Blocked inbound %s port %d", msg.plan.Protocol, msg.plan.Port)
+			m.lastAction = blockEquivalent(msg.plan)
+		}
+		return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+	case exportMsg:
+		m.notification = exportNote(msg)
+		return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+	case attachURLMsg:
+		if msg.err != nil {
+			m.notification = fmt.Sprintf("Error: %v", msg.err)
+			return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+		}
+		m.explainText = msg.text
+		m.explaining = true
+		return m, spinnerCmd
+	case gatewayMsg:
+		m.notification = ""
+		if msg.err != nil {
+			m.responseText = fmt.Sprintf("Router port mappings\n\nError: %v", msg.err)
+		} else {
+			m.gateway = &msg.gw
+			m.responseText = m.gatewayReport()
+		}
+		m.responding = true
+		return m, spinnerCmd
+	case testResponseMsg:
+		m.notification = ""
+		m.responseText = msg.title + "\n\n" + msg.text
+		if msg.err != nil {
+			m.responseText = fmt.Sprintf("%s\n\nError: %v", msg.title, msg.err)
+		}
+		m.responding = true
+		return m, spinnerCmd
+	case nextFreeMsg:
+		if msg.err != nil {
+			m.notification = fmt.Sprintf("Error: %v", msg.err)
+		} else {
+			m.notification = fmt.Sprintf("Next free port: %d", msg.port)
+		}
+		return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+	case latencyMsg:
+		applyLatency(m.watches, msg)
+		return m, spinnerCmd
+	case latencyResultMsg:
+		m.notification = fmt.Sprintf("Port %d (%s): %s", msg.port, msg.addr, latencyNote(msg.latency, msg.err))
+		if msg.err != nil && !errors.Is(msg.err, os.ErrDeadlineExceeded) {
+			m.notification += ": " + msg.err.Error()
+		}
+		return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+	case healthMsg:
+		m.healthRunning = false
+		m.health = make(map[uint32]health.Result, len(msg))
+		for _, r := range msg {
+			m.health[r.Check.Port] = r
+		}
+		m.updateTable()
+		return m, spinnerCmd
+	case dnsResolvedMsg, wslOwnersMsg, mdnsBrowsedMsg, vmPortsMsg, packagesResolvedMsg, signaturesResolvedMsg, pprofProbedMsg:
+		return m, spinnerCmd
+	case destroyResultMsg:
+		if msg.err != nil {
+			m.notification = fmt.Sprintf("Error: %v", msg.err)
+		} else {
+			m.notification = fmt.Sprintf("Dropped connection %s -> %s", hostPort(msg.conn.LocalAddr, msg.conn.Port), hostPort(msg.conn.RemoteAddr, msg.conn.RemotePort))
+			m.lastAction = dropEquivalent(msg.conn)
+		}
+		return m, tea.Batch(m.scanCmd(), waitNotificationCmd(), spinnerCmd)
 	case notificationTimeoutMsg:
 		m.notification = ""
 		return m, spinnerCmd
+	case tickerMsg:
+		// Keep rotating while there are recent events to show
+		if len(m.recentEvents()) == 0 {
+			m.ticking = false
+			return m, spinnerCmd
+		}
+		return m, tea.Batch(tickerCmd(), spinnerCmd)
+	case hostScanMsg:
+		m.hosts[msg.host].err = msg.err
+		if msg.err != nil {
+			if msg.host == m.activeHost {
+				m.loading = false
+				m.notification = fmt.Sprintf("%s: %v", m.hosts[msg.host].name, msg.err)
+				return m, tea.Batch(waitNotificationCmd(), spinnerCmd)
+			}
+			return m, spinnerCmd
+		}
+		m.hosts[msg.host].procs = msg.procs
+		if msg.host == m.activeHost {
+			return m.update(scanMsg{procs: msg.procs, warn: msg.warn, took: msg.took})
+		}
+		if m.rules != nil {
+			h := m.hosts[msg.host]
+			name := h.name
+			if name == "local" {
+				name = "" // As m.host names it when active
+			}
+			return m, tea.Batch(m.rules.enforceCmd(h.source, name, msg.procs, m.auditPath, m.eventLog), spinnerCmd)
+		}
+		return m, spinnerCmd
 	case errMsg:
+		// Keep the last good scan on screen and retry, backing off
 		m.err = msg
+		m.failures++
+		m.loading = false
+		if m.failures == 1 {
+			return m, tea.Batch(m.pushEvent(fmt.Sprintf("Scan failed: %v", msg)), spinnerCmd)
+		}
+		m.logEvent(fmt.Sprintf("Scan failed again (%d in a row): %v", m.failures, msg))
+		return m, spinnerCmd
 	}
 
 	m.table, cmd = m.table.Update(msg)
+	m.ensureRows()
 	return m, tea.Batch(cmd, spinnerCmd)
 }
 
+// seekSnapshot shows the recorded snapshot at idx (clamped) in replay mode.
+func (m *model) seekSnapshot(idx int) {
+	if !m.replaying || len(m.snapshots) == 0 {
+		return
+	}
+	idx = max(0, min(idx, len(m.snapshots)-1))
+	m.snapIdx = idx
+	m.diff.apply(m.processes, m.snapshots[idx].Processes)
+	m.connRate.observe(m.snapshots[idx].Processes, m.snapshots[idx].Time)
+	m.processes = m.snapshots[idx].Processes
+	m.updateTable()
+}
+
 func waitNotificationCmd() tea.Cmd {
 	return tea.Tick(time.Second*3, func(t time.Time) tea.Msg {
 		return notificationTimeoutMsg{}
 	})
 }
 
-func (m *model) toggleSelection() {
-	row := m.table.SelectedRow()
-	if row == nil {
-		return
+func (m *model) toggleSelection() tea.Cmd {
+	pid, ok := m.cursorPID()
+	if !ok {
+		return nil
+	}
+	if members, ok := m.userGroups[pid]; ok {
+		return m.toggleGroupSelection(members)
 	}
-	var pid int32
-	fmt.Sscanf(row[1], "%d", &pid)
 
 	if _, ok := m.selectedPids[pid]; ok {
 		delete(m.selectedPids, pid)
+	} else if m.protectedPIDs()[pid] {
+		return m.notify(protectedNote)
 	} else {
 		m.selectedPids[pid] = struct{}{}
 	}
+	return nil
+}
+
+// toggleGroupSelection selects every process of a user row, or clears them
+// when all are selected already. The monitor and its shell are left out.
+func (m *model) toggleGroupSelection(members []int32) tea.Cmd {
+	members = m.unprotected(members)
+	if len(members) == 0 {
+		return m.notify(protectedNote)
+	}
+	all := m.allSelected(members)
+	for _, pid := range members {
+		if all {
+			delete(m.selectedPids, pid)
+		} else {
+			m.selectedPids[pid] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// allSelected reports whether every one of pids is selected.
+func (m *model) allSelected(pids []int32) bool {
+	for _, pid := range pids {
+		if _, ok := m.selectedPids[pid]; !ok {
+			return false
+		}
+	}
+	return true
 }
 
 func (m cmdMsg) String() string { return "cmd" }
 
 type cmdMsg struct{} // dummy
 
-func (m *model) startKillProcess() {
+func (m *model) startKillProcess() tea.Cmd {
 	// Determine victims
 	var victims []int32
 
@@ -342,12 +1507,13 @@ func (m *model) startKillProcess() {
 			victims = append(victims, pid)
 		}
 	} else {
-		// Use current cursor
-		row := m.table.SelectedRow()
-		if row != nil {
-			var pid int32
-			fmt.Sscanf(row[1], "%d", &pid)
-			victims = append(victims, pid)
+		// Use current cursor; a user row stands for all of the user's processes
+		if pid, ok := m.cursorPID(); ok {
+			if members, ok := m.userGroups[pid]; ok {
+				victims = append(victims, members...)
+			} else {
+				victims = append(victims, pid)
+			}
 		}
 	}
 
@@ -361,28 +1527,198 @@ func (m *model) startKillProcess() {
 		// Let's rely on user pressing 'k' again or just changing Update to call a helper that returns (model, cmd).
 		// Better: just set the specific state and return cmd in Update.
 		// Refactoring: logic logic moved to Update or helper that helps Update.
-		return
+		return nil
 	}
 
-	m.pendingPids = victims
+	victims = m.unprotected(victims)
+	if len(victims) == 0 {
+		return m.notify(protectedNote)
+	}
+	m.pendingPids = killOrder(victims, m.processes)
 	m.confirming = true
+	return nil
 }
 
 func (m *model) killPending() tea.Cmd {
 	pids := m.pendingPids
+	src := m.source
+	_, local := src.(localSource)
 	return func() tea.Msg {
 		count := 0
+		var killed, denied, survivors []int32
 		var lastErr error
+		var outcomes []killOutcome
 		for _, pid := range pids {
-			err := scanner.KillProcess(pid)
-			if err != nil {
+			err := src.KillProcess(pid)
+			outcomes = append(outcomes, killOutcome{pid: pid, err: err})
+			if err != nil && errors.Is(err, os.ErrPermission) {
+				denied = append(denied, pid)
+			} else if err != nil && !scanner.IsGone(err) {
 				lastErr = err
-			} else {
+			} else if err == nil {
 				count++
+				killed = append(killed, pid)
+			}
+		}
+		// The signal being delivered doesn't mean the process died
+		if local {
+			survivors = awaitExit(killed, killVerifyTimeout)
+			for i, o := range outcomes {
+				if slices.Contains(survivors, o.pid) {
+					outcomes[i].err = errStillRunning
+				}
+			}
+			killed = slices.DeleteFunc(killed, func(pid int32) bool { return slices.Contains(survivors, pid) })
+			count = len(killed)
+		}
+		return killResultMsg{count: count, killed: killed, denied: denied, survivors: survivors, err: lastErr, outcomes: outcomes}
+	}
+}
+
+// connNote annotates a connection of p in the detail pane.
+func (m *model) connNote(p scanner.ProcessInfo) func(scanner.Connection) string {
+	now := time.Now()
+	pid := p.PID
+	return func(c scanner.Connection) string {
+		var notes []string
+		if n := forwardNote(p, c); n != "" {
+			notes = append(notes, n)
+		}
+		if n := m.vmPorts.note(p, c); n != "" {
+			notes = append(notes, n)
+		}
+		if kind := debugServer(p, c); kind != "" {
+			notes = append(notes, "debugger: "+kind+" ([A] attach URL)")
+		}
+		if !m.replaying {
+			if n := m.listening.note(pid, c, now); n != "" {
+				notes = append(notes, n)
+			}
+		}
+		if n := m.wsl.note(c); n != "" {
+			notes = append(notes, n)
+		}
+		if m.host == "" {
+			if n := m.mdns.note(c); n != "" {
+				notes = append(notes, n)
+			}
+		}
+		if n := m.internetNote(c); n != "" {
+			notes = append(notes, n)
+		}
+		if n := m.geo.note(c); n != "" {
+			notes = append(notes, n)
+		}
+		if r, ok := m.health[c.Port]; ok && c.Status == "LISTEN" {
+			notes = append(notes, healthNote(r))
+		}
+		return strings.Join(notes, " ")
+	}
+}
+
+// explanation is the text of the explain modal: the shell equivalent of
+// the last action and of what can be done with the selected row.
+func (m *model) explanation() string {
+	var lines []string
+	if len(m.lastAction) > 0 {
+		lines = append(lines, "# Last action")
+		lines = append(lines, m.lastAction...)
+	}
+	if p := m.selectedProcess(); p != nil {
+		goos := runtime.GOOS
+		if m.host != "" {
+			goos = "linux" // Remote hosts are always Linux
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, fmt.Sprintf("# Selected: %s (%d)", p.Name, p.PID))
+		lines = append(lines, onHost(m.host, explainCommands(*p, m.selectedListener(), goos))...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// recordKills logs killed processes and watches them for respawning.
+func (m *model) recordKills(pids []int32) {
+	if len(pids) > 0 {
+		goos := runtime.GOOS
+		if m.host != "" {
+			goos = "linux"
+		}
+		m.lastAction = onHost(m.host, killEquivalent(pids, goos))
+	}
+	for _, pid := range pids {
+		for _, p := range m.processes {
+			if p.PID != pid {
+				continue
+			}
+			if m.eventLog != nil {
+				m.eventLog.Log(scanner.Event{Type: scanner.ProcessKilled, Time: time.Now(), PID: p.PID, Name: p.Name})
+			}
+			if w, ok := newRespawnWatch(p, time.Now()); ok {
+				m.respawns = append(m.respawns, w)
+			}
+		}
+	}
+}
+
+func destroyConnectionCmd(c scanner.Connection) tea.Cmd {
+	return func() tea.Msg {
+		return destroyResultMsg{conn: c, err: scanner.DestroyConnection(c)}
+	}
+}
+
+// stalled reports whether p is stopped or a zombie, and so can't serve the
+// ports it holds.
+func stalled(p scanner.ProcessInfo) bool {
+	return p.State == "stopped" || p.State == "zombie"
+}
+
+func hasExposedListener(p scanner.ProcessInfo) bool {
+	for _, c := range p.Connections {
+		if c.IsExposed() {
+			return true
+		}
+	}
+	return false
+}
+
+// countExposed counts listening sockets reachable from the network.
+func countExposed(procs []scanner.ProcessInfo) int {
+	n := 0
+	for _, p := range procs {
+		for _, c := range p.Connections {
+			if c.IsExposed() {
+				n++
 			}
 		}
-		return killResultMsg{count: count, err: lastErr}
 	}
+	return n
+}
+
+func blockPortCmd(plan firewall.Plan) tea.Cmd {
+	return func() tea.Msg {
+		return blockResultMsg{plan: plan, err: plan.Apply()}
+	}
+}
+
+// countPartial counts processes with details hidden by missing permissions.
+func countPartial(procs []scanner.ProcessInfo) int {
+	n := 0
+	for _, p := range procs {
+		if len(p.Denied) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// privilegeHint says how to get the missing details.
+func privilegeHint() string {
+	if runtime.GOOS == "windows" {
+		return "run as administrator"
+	}
+	return "needs sudo"
 }
 
 func formatBytes(b uint64) string {
@@ -399,12 +1735,11 @@ func formatBytes(b uint64) string {
 }
 
 func (m *model) updateTable() {
-	var rows []table.Row
 	search := strings.ToLower(m.textInput.Value())
 
 	// Filter and Sort
 	var filtered []scanner.ProcessInfo
-	for _, p := range m.processes {
+	for _, p := range append(m.diff.ghosts(), m.processes...) {
 		// Tab Filter
 		if (m.activeTab == 0 && p.Type != scanner.UserProcess) ||
 			(m.activeTab == 1 && p.Type != scanner.SystemProcess) {
@@ -414,6 +1749,28 @@ func (m *model) updateTable() {
 		if m.filterPorts && len(p.Connections) == 0 {
 			continue
 		}
+		// Exposed Filter
+		if m.filterExposed && !hasExposedListener(p) {
+			continue
+		}
+		// Stopped/zombie Filter
+		if m.filterStalled && !stalled(p) {
+			continue
+		}
+		// System tab noise
+		if m.activeTab == 1 {
+			if m.hideKernelThreads && p.KernelThread {
+				continue
+			}
+			if m.hideRootDaemons && scanner.IsSystemAccount(p.User) {
+				continue
+			}
+		}
+
+		// Expression Filter
+		if m.expr != nil && !m.expr(p) {
+			continue
+		}
 
 		// Search Filter
 		if search != "" {
@@ -437,6 +1794,30 @@ func (m *model) updateTable() {
 		filtered = append(filtered, p)
 	}
 
+	// Collapse workers into their group leader, or processes into a row
+	// per user
+	var groups map[int32]procGroup
+	m.userGroups = nil
+	if m.groupWorkers || m.groupUsers {
+		grouped := groupWorkers(filtered)
+		if m.groupUsers {
+			grouped = groupUsers(filtered)
+		}
+		groups = make(map[int32]procGroup)
+		m.userGroups = make(map[int32][]int32)
+		var heads []scanner.ProcessInfo
+		for _, g := range grouped {
+			groups[g.head.PID] = g
+			heads = append(heads, g.head)
+			if m.groupUsers {
+				for _, p := range g.members {
+					m.userGroups[g.head.PID] = append(m.userGroups[g.head.PID], p.PID)
+				}
+			}
+		}
+		filtered = heads
+	}
+
 	// Sort
 	sort.Slice(filtered, func(i, j int) bool {
 		var less bool
@@ -456,6 +1837,16 @@ func (m *model) updateTable() {
 			less = filtered[i].CPUPercent < filtered[j].CPUPercent
 		case SortMem:
 			less = filtered[i].MemoryUsage < filtered[j].MemoryUsage
+		case SortIO:
+			less = filtered[i].DiskRead+filtered[i].DiskWrite < filtered[j].DiskRead+filtered[j].DiskWrite
+		case SortConnRate:
+			less = m.connRate.of(filtered[i].PID) < m.connRate.of(filtered[j].PID)
+		case SortUser:
+			if filtered[i].User == filtered[j].User {
+				less = filtered[i].PID < filtered[j].PID
+			} else {
+				less = filtered[i].User < filtered[j].User
+			}
 		default:
 			less = filtered[i].PID < filtered[j].PID
 		}
@@ -466,74 +1857,389 @@ func (m *model) updateTable() {
 		return less
 	})
 
+	items := make([]rowItem, 0, len(filtered))
 	for _, p := range filtered {
-		check := " "
-		if _, ok := m.selectedPids[p.PID]; ok {
-			check = "x"
+		g, grouped := groups[p.PID]
+		if !grouped || (len(g.members) == 1 && !m.groupUsers) {
+			items = append(items, rowItem{p: p, label: p.Name})
+			continue
 		}
 
-		// Format Ports
-		// Prioritize LISTEN ports
-		var listenPorts []string
-		var otherPorts []string
-		for _, c := range p.Connections {
-			if c.Status == "LISTEN" {
-				listenPorts = append(listenPorts, fmt.Sprintf("%d(L)", c.Port))
-			} else {
-				otherPorts = append(otherPorts, fmt.Sprintf("%d(E)", c.Port))
+		summary := fmt.Sprintf("%s (%d)", p.Name, len(g.members))
+		if m.groupUsers {
+			summary = fmt.Sprintf("%s (%d procs, %d listening)", p.Name, len(g.members), listenCount(p))
+		}
+		if !m.expanded[p.PID] {
+			items = append(items, rowItem{p: p, label: glyph("▸", ">") + " " + summary})
+			continue
+		}
+		items = append(items, rowItem{p: p, label: glyph("▾", "v") + " " + summary})
+		members := g.members[1:]
+		if m.groupUsers {
+			// The head row stands for the user, not its first process
+			members = g.members
+		}
+		for _, w := range members {
+			items = append(items, rowItem{p: w, label: "  " + glyph("└", "`-") + " " + w.Name})
+		}
+	}
+	if m.perPort {
+		items = perPortItems(items, m.sortBy == SortPorts, m.sortDesc)
+	}
+	oldItems := m.rowItems
+	m.rowItems = items
+
+	// Rows are formatted lazily, see materializeRows
+	rows := make([]table.Row, len(items))
+	for i, it := range items {
+		rows[i] = placeholderRow(it.p.PID)
+	}
+
+	// Keep the cursor on the same process (and port) rather than the same
+	// index. If it's gone, move to the row that was next to it.
+	old := m.table.Rows()
+	currIdx := m.table.Cursor()
+	cursor := min(currIdx, len(rows)-1)
+	if i := anchorIndex(oldItems, items, currIdx); i >= 0 {
+		cursor = i
+	}
+	m.materializeRows(rows, cursor)
+
+	// Most refreshes change nothing visible; skip the re-render then
+	if rowsEqual(old, rows) && cursor == currIdx {
+		return
+	}
+	m.table.SetRows(rows)
+	m.table.SetCursor(cursor)
+}
+
+// pruneSelection drops selected PIDs whose processes are gone, so they
+// aren't killed later should the PID be reused.
+func (m *model) pruneSelection(procs []scanner.ProcessInfo) {
+	if len(m.selectedPids) == 0 {
+		return
+	}
+	alive := make(map[int32]bool, len(procs))
+	for _, p := range procs {
+		alive[p.PID] = true
+	}
+	for pid := range m.selectedPids {
+		if !alive[pid] {
+			delete(m.selectedPids, pid)
+		}
+	}
+}
+
+func rowsEqual(a, b []table.Row) bool {
+	return slices.EqualFunc(a, b, slices.Equal)
+}
+
+// selectedListener returns the port of the selected row in the per-port
+// layout, else the listening socket under the detail pane cursor, falling
+// back to the process's first listener.
+func (m *model) selectedListener() *scanner.Connection {
+	if it := m.selectedItem(); it != nil && it.port != nil {
+		c := *it.port
+		return &c
+	}
+	if c := m.selectedConnection(); c != nil && c.IsListener() {
+		return c
+	}
+	p := m.selectedProcess()
+	if p == nil {
+		return nil
+	}
+	for _, c := range sortedConnections(p.Connections) {
+		if c.Status == "LISTEN" {
+			return &c
+		}
+	}
+	return nil
+}
+
+// resizeTable fits the table between the header, the watch panel and the
+// detail pane.
+func (m *model) resizeTable() {
+	warningRows := 0
+	if m.warning != "" {
+		warningRows = 1
+	}
+	m.table.SetHeight(m.height - 15 - commandRows(m.cmdPerArg) - connPaneRows - 2 - len(m.watches) - warningRows) // Reserve extra space for header/footer/tabs/connections
+}
+
+// layoutColumns sizes the table columns to the window width.
+// minFlexWidth is the room Name and Ports need to stay useful.
+const minFlexWidth = 40
+
+// cellPadding is the horizontal padding of a table cell.
+const cellPadding = 2
+
+// hideOrder lists the columns dropped first when the terminal is too narrow.
+var hideOrder = []string{"Age", "Threads", "Conns", "Type", "Manager", "State", "Swap", "Mem%", "Conn/s", "IO", "Mem", "CPU%"}
+
+func (m *model) layoutColumns() {
+	// Reserve margin for borders (2 for outer border, plus extra safety)
+	tableWidth := m.width - 4
+	m.table.SetWidth(tableWidth)
+
+	portsTitle := "Ports"
+	if m.perPort {
+		portsTitle = "Port"
+	}
+	if m.showBindAddr {
+		portsTitle = "Bind Address"
+	}
+
+	columns := []table.Column{
+		{Title: "X", Width: 2},
+		{Title: "PID", Width: 8},
+		{Title: "Name"},
+		{Title: portsTitle},
+	}
+	for _, c := range m.visibleColumns() {
+		columns = append(columns, table.Column{Title: c.title, Width: c.width})
+	}
+
+	// The per-port layout is dense: port, name, CPU and memory
+	if m.perPort {
+		for i := range columns {
+			switch columns[i].Title {
+			case "IO", "Conn/s", "Type", "State", "Manager", "Age", "Threads", "Conns":
+				columns[i].Width = 0
 			}
 		}
+	}
 
-		// Combine, listen first
-		allPorts := append(listenPorts, otherPorts...)
-		portsStr := strings.Join(allPorts, ", ")
+	// Every shown cell is padded by a space on either side
+	fixedWidths := 2 * cellPadding // Name and Ports
+	for _, c := range columns {
+		if c.Width > 0 {
+			fixedWidths += c.Width + cellPadding
+		}
+	}
 
-		// We need to know the current ports column width to truncate correctly.
-		// It's in m.table.Columns()[3].Width
-		cols := m.table.Columns()
-		portsWidth := 15 // default
-		if len(cols) > 3 {
-			portsWidth = cols[3].Width
+	// On narrow terminals drop low-priority columns rather than squeezing
+	// Name and Ports. The table skips zero-width columns, so rows keep
+	// their shape.
+	for _, title := range hideOrder {
+		if tableWidth-fixedWidths >= minFlexWidth {
+			break
 		}
+		for i := range columns {
+			if columns[i].Title == title && columns[i].Width > 0 {
+				fixedWidths -= columns[i].Width + cellPadding
+				columns[i].Width = 0
+			}
+		}
+	}
+
+	avail := tableWidth - fixedWidths
+	if avail < 0 {
+		avail = 0
+	}
 
-		if len(portsStr) > portsWidth {
-			portsStr = portsStr[:portsWidth-3] + "..."
+	// Distribute remainder: Name ~40%, Ports ~60%
+	nameW := int(float64(avail) * 0.4)
+	// Ensure name matches minimum usability if possible, but prioritized fitting
+	if nameW < 10 && avail >= 10 {
+		nameW = 10
+	}
+	columns[2].Width = nameW
+	columns[3].Width = avail - nameW
+
+	// A share of all cores is marked with their count, taking room from
+	// Ports to fit along with the sort arrow
+	if n := m.cpuCores(); n > 1 {
+		for i := range columns {
+			if columns[i].Title == "CPU%" && columns[i].Width > 0 {
+				columns[i].Title = fmt.Sprintf("CPU%%/%d", n)
+				if grow := len(columns[i].Title) + 1 - columns[i].Width; grow > 0 {
+					columns[i].Width += grow
+					columns[3].Width = max(columns[3].Width-grow, 0)
+				}
+			}
 		}
+	}
+	if i := m.sortColumn(); i > 0 {
+		arrow := glyph("▲", "^")
+		if m.sortDesc {
+			arrow = glyph("▼", "v")
+		}
+		columns[i].Title += arrow
+	}
+	m.table.SetColumns(columns)
+}
 
-		rows = append(rows, table.Row{
-			check,
-			fmt.Sprintf("%d", p.PID),
-			p.Name,
-			portsStr,
-			fmt.Sprintf("%.1f%%", p.CPUPercent),
-			formatBytes(p.MemoryUsage),
-			p.AppType,
-		})
+// cursorPID returns the PID of the row under the table cursor. User rows
+// have a negative PID that isn't a process, see userGroupPID.
+func (m *model) cursorPID() (int32, bool) {
+	if it := m.selectedItem(); it != nil {
+		return it.p.PID, true
+	}
+	row := m.table.SelectedRow()
+	if row == nil {
+		return 0, false
 	}
+	var pid int32
+	fmt.Sscanf(row[1], "%d", &pid)
+	return pid, true
+}
 
-	// Preserve selection index if possible
-	currIdx := m.table.Cursor()
-	m.table.SetRows(rows)
-	if currIdx >= len(rows) {
-		m.table.SetCursor(len(rows) - 1)
+// selectedProcess returns the process under the table cursor, if any.
+func (m *model) selectedProcess() *scanner.ProcessInfo {
+	pid, ok := m.cursorPID()
+	if !ok {
+		return nil
 	}
+
+	for i := range m.processes {
+		if m.processes[i].PID == pid {
+			return &m.processes[i]
+		}
+	}
+	return nil
 }
 
-func (m model) View() string {
-	if m.err != nil {
-		return fmt.Sprintf("Error: %v", m.err)
+// moveConnCursor moves the detail pane's connection cursor by delta rows.
+// The cursor resets whenever the table cursor moves to another process.
+func (m *model) moveConnCursor(delta int) {
+	p := m.selectedProcess()
+	if p == nil {
+		return
+	}
+	if p.PID != m.connCursorPID {
+		m.connCursorPID = p.PID
+		m.connCursor = 0
+	}
+	m.connCursor = max(0, min(m.connCursor+delta, len(p.Connections)-1))
+}
+
+// selectedConnection returns the connection under the detail pane cursor.
+func (m *model) selectedConnection() *scanner.Connection {
+	p := m.selectedProcess()
+	if p == nil || len(p.Connections) == 0 {
+		return nil
+	}
+	idx := 0
+	if p.PID == m.connCursorPID {
+		idx = m.connCursor
+	}
+	conns := sortedConnections(p.Connections)
+	c := conns[min(idx, len(conns)-1)]
+	return &c
+}
+
+// toggleExpanded opens or closes the worker group under the cursor.
+func (m *model) toggleExpanded() {
+	pid, ok := m.cursorPID()
+	if !ok {
+		return
+	}
+	if m.expanded[pid] {
+		delete(m.expanded, pid)
+	} else {
+		m.expanded[pid] = true
+	}
+}
+
+// processRow formats a single table row; label is the text for the Name column.
+func (m *model) processRow(p scanner.ProcessInfo, label string) table.Row {
+	check := " "
+	if _, ok := m.selectedPids[p.PID]; ok {
+		check = "x"
+	} else if members, ok := m.userGroups[p.PID]; ok && m.allSelected(members) {
+		check = "x"
+	}
+
+	portsStr := strings.Join(m.portEntries(p), ", ")
+
+	// We need to know the current ports column width to truncate correctly.
+	// It's in m.table.Columns()[3].Width
+	cols := m.table.Columns()
+	portsWidth := 15 // default
+	if len(cols) > 3 {
+		portsWidth = cols[3].Width
+	}
+
+	if runes := []rune(portsStr); len(runes) > portsWidth {
+		if portsWidth > 3 {
+			portsStr = string(runes[:portsWidth-3]) + "..."
+		} else {
+			portsStr = string(runes[:max(portsWidth, 0)])
+		}
+	}
+
+	name := label
+	if m.diff.isGone(p.PID) {
+		name = "GONE " + name
+	} else if m.diff.isNew(p.PID) {
+		name = "NEW " + name
+	}
+	if len(p.Denied) > 0 {
+		name += " [partial]"
 	}
 
+	row := table.Row{
+		check,
+		pidCell(p.PID),
+		name,
+		portsStr,
+	}
+	for _, c := range m.visibleColumns() {
+		row = append(row, c.cell(m, p))
+	}
+	return row
+}
+
+// kindLabel is the Type column: the runtime or app type, or what the
+// process forwards for.
+func kindLabel(p scanner.ProcessInfo) string {
+	kind := p.AppType
+	if p.Runtime != "" {
+		kind = p.Runtime
+	}
+	if k := forwarderKind(p); k != "" {
+		kind = k
+	}
+	return kind
+}
+
+// managerLabel shows who launched a process, e.g. "foreman:web.1".
+func managerLabel(p scanner.ProcessInfo) string {
+	if p.Service == "" {
+		return p.Manager
+	}
+	return p.Manager + ":" + p.Service
+}
+
+func (m model) View() string {
 	var userTab, sysTab string
 	if m.activeTab == 0 {
-		userTab = activeTabStyle.Render("User Processes")
+		userTab = activeTab("User Processes")
 		sysTab = tabStyle.Render("System Processes")
 	} else {
 		userTab = tabStyle.Render("User Processes")
-		sysTab = activeTabStyle.Render("System Processes")
+		sysTab = activeTab("System Processes")
 	}
 
 	header := lipgloss.JoinHorizontal(lipgloss.Top, userTab, sysTab)
+	if len(m.hosts) > 1 {
+		tabs := []string{header, tabStyle.Render("Hosts:")}
+		for i, h := range m.hosts {
+			name := h.name
+			if h.err != nil {
+				name += " !"
+			}
+			if i == m.activeHost {
+				tabs = append(tabs, activeTab(name))
+			} else {
+				tabs = append(tabs, tabStyle.Render(name))
+			}
+		}
+		header = lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
+	} else if m.host != "" {
+		header = lipgloss.JoinHorizontal(lipgloss.Top, header, tabStyle.Render("Host: "+m.host))
+	}
 
 	// Status Line
 	sortStr := "PID"
@@ -546,6 +2252,12 @@ func (m model) View() string {
 		sortStr = "CPU"
 	case SortMem:
 		sortStr = "Mem"
+	case SortIO:
+		sortStr = "IO"
+	case SortConnRate:
+		sortStr = "Conn/s"
+	case SortUser:
+		sortStr = "User"
 	}
 	orderStr := "ASC"
 	if m.sortDesc {
@@ -555,9 +2267,51 @@ func (m model) View() string {
 	if m.filterPorts {
 		filterStr = "Ports Only"
 	}
+	if m.filterExposed {
+		filterStr = "Exposed Only"
+	}
+	if m.filterStalled {
+		filterStr += ", Stopped/Zombie"
+	}
 
 	status := fmt.Sprintf("Sort: %s (%s) | Filter: %s", sortStr, orderStr, filterStr)
+	if n := countExposed(m.processes); n > 0 {
+		status = fmt.Sprintf("%s | Exposed: %d", status, n)
+	}
+	if n := countPartial(m.processes); n > 0 {
+		status = fmt.Sprintf("%s | Partial: %d (%s)", status, n, privilegeHint())
+	}
+	if m.activeTab == 1 && (m.hideKernelThreads || m.hideRootDaemons) {
+		var hidden []string
+		if m.hideKernelThreads {
+			hidden = append(hidden, "kernel threads")
+		}
+		if m.hideRootDaemons {
+			hidden = append(hidden, "root")
+		}
+		status = fmt.Sprintf("%s | Hidden: %s", status, strings.Join(hidden, ", "))
+	}
+	if m.adaptive {
+		status = fmt.Sprintf("%s | Refresh: %s (%s)", status, m.interval, m.intervalReason)
+	}
+	if m.wsl != nil && m.host == "" {
+		status = fmt.Sprintf("%s | %s", status, m.wsl.status())
+	}
+	if len(m.reserved) > 0 {
+		status = fmt.Sprintf("%s | %s", status, m.reserved.status())
+	}
+	for _, f := range m.forwards {
+		status = fmt.Sprintf("%s | Fwd %s", status, f)
+	}
+	if m.replaying && len(m.snapshots) > 0 {
+		snap := m.snapshots[m.snapIdx]
+		status = fmt.Sprintf("Replay %d/%d @ %s | %s", m.snapIdx+1, len(m.snapshots), snap.Time.Format("2006-01-02 15:04:05"), status)
+	}
 	status = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(status)
+	if m.err != nil {
+		failed := fmt.Sprintf("Scan failed: %v (%d in a row, retrying every %s)", m.err, m.failures, retryInterval(m.interval, m.failures))
+		status = lipgloss.JoinHorizontal(lipgloss.Left, status, " | ", lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(failed))
+	}
 
 	// Search Bar
 	search := ""
@@ -566,6 +2320,21 @@ func (m model) View() string {
 	} else if m.textInput.Value() != "" {
 		search = fmt.Sprintf("Filter: %s (press / to edit)", m.textInput.Value())
 	}
+	if h := renderHealth(m.health); h != "" {
+		status = lipgloss.JoinHorizontal(lipgloss.Left, status, " | ", h)
+	}
+	if m.editingExpr {
+		search = fmt.Sprintf("Expr: %s", m.exprInput.View())
+	} else if m.expr != nil {
+		expr := fmt.Sprintf("Expr: %s (press | to edit)", m.exprInput.Value())
+		if search != "" {
+			search += " | "
+		}
+		search += expr
+	}
+	if m.prompting {
+		search = m.promptInput.View()
+	}
 	if search != "" {
 		status = lipgloss.JoinHorizontal(lipgloss.Left, status, " | ", lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Render(search))
 	}
@@ -573,55 +2342,157 @@ func (m model) View() string {
 	// Notification / Confirmation
 	if m.confirming {
 		prompt := fmt.Sprintf("Are you sure you want to kill %d process(s)? (y/n)", len(m.pendingPids))
+		if len(m.pendingPids) > 1 {
+			prompt = fmt.Sprintf("Kill %d processes in this order: %s? (y/n)", len(m.pendingPids), killPlan(m.pendingPids, m.processes))
+		}
+		if pids := m.survivors; pids != nil {
+			prompt = fmt.Sprintf("%s still running %s escalate to an administrator kill? (y/n)", killPlan(pids, m.processes), glyph("—", "-"))
+		}
+		if b := m.pendingBlock; b != nil {
+			prompt = fmt.Sprintf("Add firewall rule blocking %s port %d? (y/n)", b.Protocol, b.Port)
+		}
+		if p := m.pendingQuit; p != nil {
+			prompt = fmt.Sprintf("Send SIGQUIT to %s (%d)? Go programs exit after dumping their stacks, Java keeps running. (y/n)", p.Name, p.PID)
+		}
+		if d := m.pendingDebug; d != nil {
+			prompt = fmt.Sprintf("Attach to %s (%d)? Runs: %s ([y] here, [w] new window, [n] cancel)", d.name, d.pid, d.command)
+		}
+		if a := m.pendingUnit; a != nil {
+			prompt = fmt.Sprintf("%s %s? Runs: %s (y/n)", strings.ToUpper(a.action[:1])+a.action[1:], a.unit, a)
+		}
+		if c := m.pendingConn; c != nil {
+			prompt = fmt.Sprintf("Drop connection %s -> %s? (y/n)", hostPort(c.LocalAddr, c.Port), hostPort(c.RemoteAddr, c.RemotePort))
+		}
 		status = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true).Render(prompt)
 	} else if m.notification != "" {
 		status = lipgloss.NewStyle().Foreground(lipgloss.Color("208")).Render(m.notification)
+	} else if t := m.ticker(); t != "" {
+		status = lipgloss.JoinHorizontal(lipgloss.Left, status, " | ", lipgloss.NewStyle().Foreground(lipgloss.Color("172")).Render(t))
 	} else if m.loading {
-		loading := lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Render(fmt.Sprintf("%s Loading processes…", m.spinner.View()))
+		loading := lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Render(fmt.Sprintf("%s Loading processes%s", m.spinner.View(), ellipsis()))
 		status = lipgloss.JoinHorizontal(lipgloss.Left, loading, "  ", status)
 	}
 
-	body := baseStyle.Render(m.table.View())
+	body := baseStyle.Render(m.tableView())
+	if m.confirming && m.pendingBlock != nil {
+		var cmds []string
+		for _, c := range m.pendingBlock.Commands {
+			cmds = append(cmds, "  "+c.String())
+		}
+		body = modalStyle.Render(fmt.Sprintf(
+			"Block inbound %s port %d at the host firewall?\n\nThe following commands will be run (needs sudo):\n\n%s\n\n[y] Apply  [n] Cancel",
+			m.pendingBlock.Protocol, m.pendingBlock.Port, strings.Join(cmds, "\n"),
+		))
+	}
 
 	// Footer Details
 	var footer string
-	selRow := m.table.SelectedRow()
-	if selRow != nil {
-		var pid int32
-		fmt.Sscanf(selRow[1], "%d", &pid)
+	if p := m.selectedProcess(); p != nil {
+		cursor := 0
+		if m.connCursorPID == p.PID {
+			cursor = m.connCursor
+		}
 
-		var p *scanner.ProcessInfo
-		for i := range m.processes {
-			if m.processes[i].PID == pid {
-				p = &m.processes[i]
-				break
-			}
+		cwd := p.Cwd
+		if p.GitRepo != "" {
+			cwd += fmt.Sprintf("  (git: %s on %s)", p.GitRepo, p.GitBranch)
+		}
+		if label := managerLabel(*p); label != "" {
+			cwd += "  (managed by " + label + ")"
+		}
+		if label := unitLabel(*p); label != "" {
+			cwd += "  (" + label + ", [R] restart [S] stop)"
+		}
+		if len(p.Denied) > 0 {
+			cwd += fmt.Sprintf("  (partial: %s unreadable, %s)", strings.Join(p.Denied, ", "), privilegeHint())
 		}
 
-		if p != nil {
-			var listenPorts []string
-			var otherPorts []string
-			for _, c := range p.Connections {
-				if c.Status == "LISTEN" {
-					listenPorts = append(listenPorts, fmt.Sprintf("%d(L)", c.Port))
-				} else {
-					otherPorts = append(otherPorts, fmt.Sprintf("%d(E)", c.Port))
-				}
+		mem := fmt.Sprintf("%s (%.1f%%)", formatBytes(p.MemoryUsage), p.MemoryPercent)
+		if p.SwapUsage > 0 {
+			mem += ", Swap " + formatBytes(p.SwapUsage)
+		}
+		mem += fmt.Sprintf(", Disk R %s W %s", formatBytes(p.DiskRead), formatBytes(p.DiskWrite))
+		if line := m.cgroupLine(*p); line != "" {
+			mem += " | " + line
+		}
+
+		exe := p.Exe
+		if pkg := m.packages.owner(p.Exe); pkg != "" {
+			exe += "  (" + pkg + ")"
+		}
+		if sig, ok := m.signatures.of(p.Exe); ok && m.host == "" && !m.replaying {
+			exe += "  sha256 " + sig.SHA256
+			switch sig.Signer {
+			case "":
+			case "unsigned":
+				exe += "  (unsigned)"
+			case "ad-hoc":
+				exe += "  (ad-hoc signed)"
+			default:
+				exe += "  signed by " + sig.Signer
 			}
-			allPorts := append(listenPorts, otherPorts...)
+		}
+		if url := m.pprof.endpoint(*p); url != "" {
+			exe += "  pprof: " + url + " ([g] UI, [G] CPU profile)"
+		}
 
-			footer = fmt.Sprintf(
-				"Path: %s\nCommand: %s\nFull Ports: %s\nResources: CPU %.1f%%, Mem %s",
-				p.Cwd,
-				p.Command,
-				strings.Join(allPorts, ", "),
-				p.CPUPercent,
-				formatBytes(p.MemoryUsage),
-			)
+		command := renderCommand(p.Command, m.width-4, m.cmdPerArg)
+		if tunnels := tunnelsOf(*p); len(tunnels) > 0 {
+			command += "\nTunnels: " + tunnelSummary(tunnels)
 		}
+
+		footer = fmt.Sprintf(
+			"Path: %s\nExecutable: %s\n%s\nResources: CPU %s, Mem %s\n%s",
+			cwd,
+			exe,
+			command,
+			m.cpuLabel(*p),
+			mem,
+			renderConnections(p.Connections, cursor, m.dns.name, m.connNote(*p)),
+		)
 	}
 
-	help := "\n[Tab] View  [Space] Select  [k] Kill  [f] Filter Ports  [s] Sort Col  [o] Sort Order  [/] Search  [q] Quit"
+	help := "\n[Tab] View  [Home/End] Top/Bottom  [Space] Select  [k] Kill  [f] Filter Ports  [s/1-9] Sort Col  [o] Sort Order  [e] Exposed  [z] Stopped/Zombie  [a] Bind Addr  [v] All Ports  [m] Mem%/Swap  [%] CPU of All Cores  [O] Columns  [c] Cmd Layout  [l] Row per Port  [w] Group  [J/K] Conn  [x] Drop Conn  [F] Firewall  [n] DNS  [W] Watch  [L] Latency  [N] Next Free  [U] Router Mappings  [V] Hold Port  [T] Forward  [i/I] Test HTTP/TCP  [p/P] Capture  [B] Debug  [A] Attach URL  [g/G] pprof UI/CPU Profile  [Q/C] Stack/Core Dump  [R/S] Restart/Stop Service  [E] Explain  [X] Export JSON  [!] Suspicious  [M] Log  [/] Search  [|] Expr Filter  [:] Command  [Ctrl+P] Palette  [q] Quit"
+	if m.activeTab == 1 {
+		help = "\n[Tab] View  [Home/End] Top/Bottom  [Space] Select  [k] Kill  [f] Filter Ports  [t] Kernel Threads  [r] Root  [s/1-9] Sort Col  [o] Sort Order  [e] Exposed  [z] Stopped/Zombie  [a] Bind Addr  [v] All Ports  [m] Mem%/Swap  [%] CPU of All Cores  [O] Columns  [c] Cmd Layout  [l] Row per Port  [w] Group  [J/K] Conn  [x] Drop Conn  [F] Firewall  [n] DNS  [W] Watch  [L] Latency  [N] Next Free  [U] Router Mappings  [V] Hold Port  [T] Forward  [i/I] Test HTTP/TCP  [p/P] Capture  [B] Debug  [A] Attach URL  [g/G] pprof UI/CPU Profile  [Q/C] Stack/Core Dump  [R/S] Restart/Stop Service  [E] Explain  [X] Export JSON  [!] Suspicious  [M] Log  [/] Search  [|] Expr Filter  [:] Command  [Ctrl+P] Palette  [q] Quit"
+	}
+	if len(m.hosts) > 1 {
+		help = strings.Replace(help, "[Tab] View", "[Tab] View  [H] Host", 1)
+	}
+	if m.replaying {
+		help = "\n[Tab] View  [[/]] Prev/Next Snapshot  [{/}] First/Last  [f] Filter Ports  [s/1-9] Sort Col  [o] Sort Order  [M] Log  [/] Search  [|] Expr Filter  [:] Command  [Ctrl+P] Palette  [q] Quit"
+	}
+
+	if m.explaining {
+		body = modalStyle.Render(m.explainText + "\n\n[c] Copy to clipboard  [Esc] Close")
+	}
+	if m.responding {
+		body = modalStyle.Render(clipText(m.responseText, max(m.width-8, 20), max(m.height-12, 5)) + "\n\n[Esc] Close")
+	}
+	if m.paletting {
+		body = modalStyle.Render(m.paletteView() + "\n\n[Up/Down] Choose  [Enter] Run  [Esc] Close")
+	}
+	if m.viewingSecurity {
+		body = modalStyle.Render(clipText(m.securityReport(), max(m.width-8, 20), max(m.height-12, 5)) + fmt.Sprintf("\n\n[1-%d] Toggle  [Esc] Close", len(heuristics)))
+	}
+	if m.choosingColumns {
+		body = modalStyle.Render(m.columnsView() + "\n\n[Up/Down] Choose  [Space] Show/Hide  [J/K] Move  [r] Reset  [Esc] Close")
+	}
+	if m.viewingLog {
+		body = modalStyle.Render(clipText(m.logView(), max(m.width-8, 20), max(m.height-12, 5)) + "\n\n[Up/Down/PgUp/PgDn] Scroll  [g/G] Oldest/Newest  [Esc] Close")
+	}
+	if m.viewingPorts {
+		body = modalStyle.Render(clipText(m.portsText, max(m.width-8, 20), max(m.height-12, 5)) + "\n\n[Esc] Close")
+	}
+
+	if len(m.watches) > 0 {
+		status = lipgloss.JoinVertical(lipgloss.Left, status, lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Render(renderWatches(m.watches)))
+	}
+	// Incomplete scans get a banner, so missing ports aren't taken for none
+	if m.warning != "" {
+		banner := ellipsize(glyph("⚠ ", "! ")+"Scan incomplete: "+m.warning, max(m.width-2, 20))
+		status = lipgloss.JoinVertical(lipgloss.Left, status, lipgloss.NewStyle().Foreground(lipgloss.Color("226")).Bold(true).Render(banner))
+	}
 
 	return lipgloss.JoinVertical(lipgloss.Left,
 		header,
@@ -632,10 +2503,274 @@ func (m model) View() string {
 	)
 }
 
+func loadConfig(path string) (config.Config, error) {
+	if path == "" {
+		var err error
+		if path, err = config.DefaultPath(); err != nil {
+			return config.Config{}, err
+		}
+	}
+	return config.Load(path)
+}
+
 func main() {
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
+	if len(os.Args) > 1 {
+		var run func([]string) error
+		switch os.Args[1] {
+		case "daemon":
+			run = runDaemon
+		case "agent":
+			run = runAgent
+		case "serve":
+			run = runServe
+		case "web":
+			run = runWeb
+		case "summary":
+			run = runSummary
+		case "next-free":
+			run = runNextFree
+		case "hold":
+			run = runHold
+		case "forward":
+			run = runForward
+		case "wait":
+			run = runWait
+		case "kill":
+			run = runKill
+		}
+		if run != nil {
+			args, jsonErrors := stripFlag(os.Args[2:], "json-errors")
+			if err := run(args); err != nil {
+				exitWithError(err, jsonErrors)
+			}
+			return
+		}
+	}
+
+	record := flag.Bool("record", false, "record scan history while running")
+	replay := flag.Bool("replay", false, "step through recorded scan history instead of scanning")
+	historyPath := flag.String("history", "", "history file (default: user cache dir)")
+	configPath := flag.String("config", "", "config file (default: user config dir)")
+	adaptive := flag.Bool("adaptive", false, "scan less often when scans are slow or system load is high")
+	nice := flag.Int("nice", 0, "set the monitor's own niceness to N (1-19 lowers its priority)")
+	resolve := flag.Bool("resolve", false, "resolve remote peers to hostnames")
+	filterExpr := flag.String("filter", "", `only show processes matching an expression, e.g. 'port >= 3000 && cpu > 10'`)
+	var hosts hostList
+	flag.Var(&hosts, "host", "monitor remote Linux hosts over ssh (user@server); repeat or comma separate for several, \"local\" is this machine")
+	logEvents := flag.String("log-events", "", "send port open/close and kill events to syslog or journald")
+	agentSocket := flag.String("agent", "", "scan through a privileged agent listening on this unix socket")
+	ebpf := flag.Bool("ebpf", false, "on Linux, trace short-lived TCP connections with eBPF (needs root and bpftrace)")
+	listenOnly := flag.Bool("listen-only", false, "fast scan of listening processes only, without path, command, CPU and memory")
+	watch := flag.Bool("watch", true, "on Linux, rescan as soon as a listener opens or closes instead of waiting for the next poll")
+	debugLog := flag.Bool("debug", false, "write a trace of every UI message to debug.log in the user cache dir")
+	geoDB := flag.String("geoip", "", "annotate remote peers with country and ASN from MaxMind .mmdb files (comma separated, e.g. GeoLite2-Country.mmdb,GeoLite2-ASN.mmdb)")
+	mdnsBrowse := flag.Bool("mdns", false, "label listening ports with the mDNS/Bonjour services this machine advertises on them (sends multicast queries)")
+	wslWindows := flag.Bool("wsl-windows", false, "on WSL, also list Windows processes listening on the same ports (uses interop)")
+	ascii := flag.Bool("ascii", !unicodeTerminal(), "draw with ASCII only, for terminals that show Unicode as garbage (default on for non UTF-8 locales)")
+	allowKillSelf := flag.Bool("allow-kill-self", false, "allow selecting and killing the monitor itself and the shell and terminal it runs in")
+	plain := flag.Bool("plain", false, "print listening ports and their changes as plain lines instead of the TUI, for screen readers and dumb terminals")
+	flag.Parse()
+
+	if *ascii {
+		setASCII()
+	}
+
+	if *nice != 0 {
+		if err := renice(*nice); err != nil {
+			fmt.Println("Error: failed to renice:", err)
+			os.Exit(1)
+		}
+	}
+
+	m := initialModel()
+	m.adaptive = *adaptive
+	m.configPath = *configPath
+	m.allowKillSelf = *allowKillSelf
+	if *listenOnly {
+		m.source = newLocalSource(scanner.Options{ListenOnly: true})
+	}
+	if *logEvents != "" {
+		l, err := eventlog.Open(*logEvents)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		defer l.Close()
+		m.eventLog = l
+	}
+	if len(hosts) > 1 {
+		for _, h := range hosts {
+			m.hosts = append(m.hosts, newMonitoredHost(h))
+		}
+		m.switchHost(0)
+	} else if len(hosts) == 1 && hosts[0] != "local" {
+		m.host = hosts[0]
+		m.source = remote.SSH{Host: hosts[0]}
+	} else if *agentSocket != "" {
+		m.source = api.NewUnixClient(*agentSocket)
+	}
+	if *resolve {
+		m.dns = newDNSCache()
+	}
+	if *filterExpr != "" {
+		pred, err := filter.Parse(*filterExpr)
+		if err != nil {
+			fmt.Println("Error: invalid --filter:", err)
+			os.Exit(1)
+		}
+		m.expr = pred
+		m.exprInput.SetValue(*filterExpr)
+	}
+	if m.host == "" && len(m.hosts) == 0 {
+		m.wsl = newWSLView(*wslWindows)
+		m.vmPorts = newVMPortsView()
+	}
+	if *mdnsBrowse {
+		m.mdns = newMDNSView()
+	}
+	if *geoDB != "" {
+		dbs, err := openGeoIP(*geoDB)
+		if err != nil {
+			fmt.Println("Error: invalid --geoip:", err)
+			os.Exit(1)
+		}
+		m.geo = dbs
+	}
+	m.intervalReason = "normal"
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	if len(cfg.Rules) > 0 {
+		m.auditPath, err = audit.DefaultPath()
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		m.rules = newRuleEnforcer(cfg.Rules)
+	}
+	for _, port := range cfg.Watch {
+		m.toggleWatch(port)
+	}
+	m.attach = cfg.Attach
+	m.heuristicsOff = make(map[string]bool)
+	for id, on := range cfg.Heuristics {
+		m.heuristicsOff[id] = !on
+	}
+	m.healthChecks = cfg.Health
+	if cols := knownColumns(cfg.Columns); len(cols) > 0 {
+		m.setColumns(cols)
+	}
+	m.heat = heatOf(cfg.Heat)
+	if err := scanner.SetRuntimeRules(cfg.Runtimes); err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	// Also used to find recently used ports when looking for a free one
+	if path, err := resolveHistoryPath(*historyPath); err == nil {
+		m.historyPath = path
+	}
+
+	if *record || *replay {
+		path, err := resolveHistoryPath(*historyPath)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+
+		if *replay {
+			snaps, err := history.Load(path)
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			if len(snaps) == 0 {
+				fmt.Println("No history recorded in", path)
+				os.Exit(1)
+			}
+			m.replaying = true
+			m.loading = false
+			m.snapshots = snaps
+			m.seekSnapshot(len(snaps) - 1)
+		} else {
+			// Listening durations carry over from earlier recorded sessions
+			if snaps, err := history.Load(path); err == nil {
+				m.listening.seed(snaps)
+			}
+			rec, err := history.NewRecorder(path)
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			defer rec.Close()
+			m.recorder = rec
+		}
+	}
+
+	if *ebpf {
+		if m.replaying || m.host != "" || len(m.hosts) > 0 {
+			fmt.Println("Error: --ebpf only works on the local machine")
+			os.Exit(1)
+		}
+		t, err := scanner.NewTracer()
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		defer t.Close()
+		m.tracer = t
+	}
+
+	if *plain {
+		if m.replaying || len(m.hosts) > 0 {
+			fmt.Println("Error: --plain works with a single live source, not --replay or several hosts")
+			os.Exit(1)
+		}
+		if err := runPlain(m.source, m.expr, baseInterval); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Remote hosts can only be polled
+	if *watch && !m.replaying && m.host == "" && len(m.hosts) == 0 {
+		if w, err := scanner.NewWatcher(); err == nil {
+			defer w.Close()
+			m.watcher = w
+		}
+	}
+
+	guard := crashGuard{model: m, debug: *debugLog}
+	guard.crashPath, err = logPath("crash.log")
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	if *debugLog {
+		path, err := logPath("debug.log")
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		f, err := tea.LogToFile(path, "")
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		log.SetFlags(log.LstdFlags | log.Lmicroseconds)
+	}
+
+	p := tea.NewProgram(guard, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Println("Error running program:", err)
+		if errors.Is(err, tea.ErrProgramPanic) {
+			fmt.Println("The crash was logged to", guard.crashPath)
+		}
 		os.Exit(1)
 	}
 }
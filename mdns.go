@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"port-monitor/mdns"
+	"port-monitor/scanner"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	mdnsInterval = time.Minute             // Between browses
+	mdnsWait     = 1500 * time.Millisecond // For answers, per query round
+)
+
+type mdnsBrowsedMsg struct{}
+
+// mdnsView labels listeners with the mDNS/Bonjour services this machine
+// advertises on them, e.g. _airplay._tcp on 7000.
+type mdnsView struct {
+	mu       sync.Mutex
+	services map[uint32][]mdns.Service // Local services by port
+	last     time.Time
+	running  bool
+}
+
+func newMDNSView() *mdnsView {
+	return &mdnsView{}
+}
+
+// note names the services advertised on a listener.
+func (v *mdnsView) note(c scanner.Connection) string {
+	if v == nil || c.Status != "LISTEN" {
+		return ""
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	var names []string
+	for _, s := range v.services[c.Port] {
+		if strings.HasSuffix(s.Type, "._tcp") == strings.HasPrefix(c.Protocol, "tcp") {
+			names = append(names, fmt.Sprintf("%s (%s)", s.Name, s.Type))
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return "mdns: " + strings.Join(names, ", ")
+}
+
+// refreshCmd browses again once mdnsInterval has passed since the last
+// time.
+func (v *mdnsView) refreshCmd() tea.Cmd {
+	if v == nil {
+		return nil
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.running || time.Since(v.last) < mdnsInterval {
+		return nil
+	}
+	v.running = true
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*mdnsWait)
+		defer cancel()
+		services, err := mdns.Browse(ctx, mdnsWait)
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		v.running = false
+		v.last = time.Now()
+		if err != nil {
+			return nil
+		}
+		v.services = localServices(services)
+		return mdnsBrowsedMsg{}
+	}
+}
+
+// localServices keeps the services advertised by this machine, found by
+// host name or address, keyed by port.
+func localServices(services []mdns.Service) map[uint32][]mdns.Service {
+	local := make(map[string]bool)
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, a := range addrs {
+			if ipnet, ok := a.(*net.IPNet); ok {
+				local[ipnet.IP.String()] = true
+			}
+		}
+	}
+	hostname, _ := os.Hostname()
+	hostname = strings.ToLower(strings.TrimSuffix(hostname, ".local"))
+
+	byPort := make(map[uint32][]mdns.Service)
+	for _, s := range services {
+		isLocal := hostname != "" && strings.EqualFold(strings.TrimSuffix(s.Host, ".local."), hostname)
+		for _, ip := range s.Addrs {
+			isLocal = isLocal || local[ip.String()]
+		}
+		if isLocal {
+			byPort[s.Port] = append(byPort[s.Port], s)
+		}
+	}
+	return byPort
+}
@@ -0,0 +1,263 @@
+// Package mdns browses the DNS-SD services advertised over multicast DNS
+// (Bonjour, Avahi) on the local network.
+package mdns
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	typeA    = 1
+	typePTR  = 12
+	typeSRV  = 33
+	typeAAAA = 28
+	classIN  = 1
+
+	// servicesName is the meta query listing every advertised service type
+	servicesName = "_services._dns-sd._udp.local."
+)
+
+var group = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// Service is an advertised service instance.
+type Service struct {
+	Name  string // Instance name, e.g. "Living Room"
+	Type  string // e.g. "_airplay._tcp"
+	Host  string // Target host, e.g. "mac-mini.local."
+	Port  uint32
+	Addrs []net.IP // Addresses of Host given along with the answer
+}
+
+// Browse asks for every service type, then for the instances of each, and
+// collects the answers for wait per round. The queries use a random source
+// port, so responders answer with unicast ("legacy" queries, RFC 6762
+// section 6.7) and port 5353 doesn't need to be free.
+func Browse(ctx context.Context, wait time.Duration) ([]Service, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mDNS socket: %w", err)
+	}
+	defer conn.Close()
+
+	r := newRecords()
+	if err := query(ctx, conn, wait, r, servicesName); err != nil {
+		return nil, err
+	}
+	types := r.ptr[servicesName]
+	if len(types) == 0 {
+		return nil, nil
+	}
+	if err := query(ctx, conn, wait, r, types...); err != nil {
+		return nil, err
+	}
+	return r.services(types), nil
+}
+
+// query sends PTR questions for names and reads answers into r until wait
+// passes.
+func query(ctx context.Context, conn *net.UDPConn, wait time.Duration, r *records, names ...string) error {
+	msg, err := encodeQuery(names)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.WriteToUDP(msg, group); err != nil {
+		return fmt.Errorf("failed to send mDNS query: %w", err)
+	}
+
+	deadline := time.Now().Add(wait)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetReadDeadline(deadline)
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var ne net.Error
+		if errors.As(err, &ne) && ne.Timeout() {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read mDNS answer: %w", err)
+		}
+		// Skip what doesn't parse, there may be odd responders around
+		_ = r.parse(buf[:n])
+	}
+}
+
+// encodeQuery builds a query message with a PTR question per name.
+func encodeQuery(names []string) ([]byte, error) {
+	msg := make([]byte, 12, 512)
+	binary.BigEndian.PutUint16(msg[4:], uint16(len(names)))
+	for _, name := range names {
+		for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+			if len(label) == 0 || len(label) > 63 {
+				return nil, fmt.Errorf("invalid mDNS name %q", name)
+			}
+			msg = append(msg, byte(len(label)))
+			msg = append(msg, label...)
+		}
+		msg = append(msg, 0)
+		msg = binary.BigEndian.AppendUint16(msg, typePTR)
+		msg = binary.BigEndian.AppendUint16(msg, classIN)
+	}
+	return msg, nil
+}
+
+type srv struct {
+	host string
+	port uint32
+}
+
+// records accumulates the answers of every responder, keyed by lower case
+// owner name.
+type records struct {
+	ptr   map[string][]string
+	srv   map[string]srv
+	addrs map[string][]net.IP
+}
+
+func newRecords() *records {
+	return &records{
+		ptr:   make(map[string][]string),
+		srv:   make(map[string]srv),
+		addrs: make(map[string][]net.IP),
+	}
+}
+
+// parse reads the answer, authority and additional records of a message.
+func (r *records) parse(msg []byte) error {
+	if len(msg) < 12 {
+		return errors.New("short message")
+	}
+	qd := int(binary.BigEndian.Uint16(msg[4:]))
+	rr := int(binary.BigEndian.Uint16(msg[6:])) + int(binary.BigEndian.Uint16(msg[8:])) + int(binary.BigEndian.Uint16(msg[10:]))
+
+	off := 12
+	for range qd {
+		_, next, err := readName(msg, off)
+		if err != nil {
+			return err
+		}
+		off = next + 4
+	}
+	for range rr {
+		owner, next, err := readName(msg, off)
+		if err != nil {
+			return err
+		}
+		if next+10 > len(msg) {
+			return errors.New("short record")
+		}
+		typ := binary.BigEndian.Uint16(msg[next:])
+		length := int(binary.BigEndian.Uint16(msg[next+8:]))
+		data := next + 10
+		if data+length > len(msg) {
+			return errors.New("short record data")
+		}
+		off = data + length
+		key := strings.ToLower(owner)
+
+		switch typ {
+		case typePTR:
+			target, _, err := readName(msg, data)
+			if err != nil {
+				return err
+			}
+			if !contains(r.ptr[key], target) {
+				r.ptr[key] = append(r.ptr[key], target)
+			}
+		case typeSRV:
+			if length < 7 {
+				return errors.New("short SRV record")
+			}
+			host, _, err := readName(msg, data+6)
+			if err != nil {
+				return err
+			}
+			r.srv[key] = srv{host: host, port: uint32(binary.BigEndian.Uint16(msg[data+4:]))}
+		case typeA, typeAAAA:
+			if length == net.IPv4len || length == net.IPv6len {
+				ip := net.IP(append([]byte(nil), msg[data:data+length]...))
+				r.addrs[key] = append(r.addrs[key], ip)
+			}
+		}
+	}
+	return nil
+}
+
+// services joins the instances of types with their SRV and address
+// records.
+func (r *records) services(types []string) []Service {
+	var out []Service
+	for _, t := range types {
+		for _, instance := range r.ptr[strings.ToLower(t)] {
+			s, ok := r.srv[strings.ToLower(instance)]
+			if !ok {
+				continue
+			}
+			out = append(out, Service{
+				Name:  strings.TrimSuffix(instance, "."+t),
+				Type:  strings.TrimSuffix(t, ".local."),
+				Host:  s.host,
+				Port:  s.port,
+				Addrs: r.addrs[strings.ToLower(s.host)],
+			})
+		}
+	}
+	return out
+}
+
+// readName reads a possibly compressed domain name at off, returning it
+// with a trailing dot and the offset right after it.
+func readName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	end := -1
+	for jumps := 0; ; {
+		if off >= len(msg) {
+			return "", 0, errors.New("name out of bounds")
+		}
+		n := int(msg[off])
+		switch {
+		case n == 0:
+			if end < 0 {
+				end = off + 1
+			}
+			return strings.Join(labels, ".") + ".", end, nil
+		case n&0xC0 == 0xC0:
+			if off+1 >= len(msg) {
+				return "", 0, errors.New("name out of bounds")
+			}
+			if jumps++; jumps > 16 {
+				return "", 0, errors.New("name compression loop")
+			}
+			if end < 0 {
+				end = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3FFF)
+		default:
+			if off+1+n > len(msg) {
+				return "", 0, errors.New("name out of bounds")
+			}
+			labels = append(labels, string(msg[off+1:off+1+n]))
+			off += 1 + n
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
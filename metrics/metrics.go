@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"port-monitor/scanner"
+)
+
+// Sink ships one scan's worth of gauges to an observability backend.
+type Sink interface {
+	Emit(procs []scanner.ProcessInfo, now time.Time) error
+}
+
+// gauge is a single measurement with its labels.
+type gauge struct {
+	name   string
+	value  float64
+	labels map[string]string
+}
+
+// gauges turns a scan into per-process and per-port measurements.
+// Processes without sockets are skipped to keep cardinality sane.
+func gauges(procs []scanner.ProcessInfo) []gauge {
+	var out []gauge
+	for _, p := range procs {
+		if len(p.Connections) == 0 {
+			continue
+		}
+		proc := map[string]string{"pid": strconv.Itoa(int(p.PID)), "name": p.Name, "user": p.User}
+		out = append(out,
+			gauge{"port_monitor.process.cpu_percent", p.CPUPercent, proc},
+			gauge{"port_monitor.process.memory_bytes", float64(p.MemoryUsage), proc},
+			gauge{"port_monitor.process.connections", float64(len(p.Connections)), proc},
+		)
+
+		// Established connections per listening port
+		established := make(map[uint32]int)
+		for _, c := range p.Connections {
+			if c.Status == "LISTEN" {
+				established[c.Port] = 0
+			}
+		}
+		for _, c := range p.Connections {
+			if _, ok := established[c.Port]; ok && c.Status == "ESTABLISHED" {
+				established[c.Port]++
+			}
+		}
+		for port, n := range established {
+			out = append(out, gauge{"port_monitor.port.established", float64(n), map[string]string{
+				"port": fmt.Sprint(port), "pid": proc["pid"], "name": p.Name,
+			}})
+		}
+	}
+	return out
+}
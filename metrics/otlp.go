@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"port-monitor/scanner"
+)
+
+// OTLP posts gauges to an OpenTelemetry collector using OTLP/HTTP with
+// JSON encoding, so no protobuf dependency is needed.
+type OTLP struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewOTLP takes the collector base URL, e.g. http://localhost:4318.
+func NewOTLP(endpoint string) *OTLP {
+	return &OTLP{
+		endpoint: strings.TrimSuffix(endpoint, "/") + "/v1/metrics",
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type otlpAttr struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+type otlpPoint struct {
+	Attributes   []otlpAttr `json:"attributes"`
+	TimeUnixNano string     `json:"timeUnixNano"`
+	AsDouble     float64    `json:"asDouble"`
+}
+
+type otlpMetric struct {
+	Name  string `json:"name"`
+	Gauge struct {
+		DataPoints []otlpPoint `json:"dataPoints"`
+	} `json:"gauge"`
+}
+
+func attrs(m map[string]string) []otlpAttr {
+	var out []otlpAttr
+	for k, v := range m {
+		a := otlpAttr{Key: k}
+		a.Value.StringValue = v
+		out = append(out, a)
+	}
+	return out
+}
+
+func (o *OTLP) Emit(procs []scanner.ProcessInfo, now time.Time) error {
+	ts := strconv.FormatInt(now.UnixNano(), 10)
+
+	// One metric per name, one data point per label set
+	byName := make(map[string]*otlpMetric)
+	var order []string
+	for _, g := range gauges(procs) {
+		m, ok := byName[g.name]
+		if !ok {
+			m = &otlpMetric{Name: g.name}
+			byName[g.name] = m
+			order = append(order, g.name)
+		}
+		m.Gauge.DataPoints = append(m.Gauge.DataPoints, otlpPoint{
+			Attributes:   attrs(g.labels),
+			TimeUnixNano: ts,
+			AsDouble:     g.value,
+		})
+	}
+	var metrics []otlpMetric
+	for _, name := range order {
+		metrics = append(metrics, *byName[name])
+	}
+
+	host, _ := os.Hostname()
+	body := map[string]any{
+		"resourceMetrics": []any{map[string]any{
+			"resource": map[string]any{"attributes": attrs(map[string]string{
+				"service.name": "port-monitor",
+				"host.name":    host,
+			})},
+			"scopeMetrics": []any{map[string]any{
+				"scope":   map[string]any{"name": "port-monitor"},
+				"metrics": metrics,
+			}},
+		}},
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp, err := o.client.Post(o.endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to reach OTLP collector: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("OTLP collector returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"port-monitor/scanner"
+)
+
+// maxDatagram keeps packets below common MTUs.
+const maxDatagram = 1400
+
+// StatsD sends gauges over UDP using DogStatsD tags (name:value|g|#k:v,...),
+// which most StatsD servers and agents understand.
+type StatsD struct {
+	conn net.Conn
+}
+
+func NewStatsD(addr string) (*StatsD, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach statsd at %s: %w", addr, err)
+	}
+	return &StatsD{conn: conn}, nil
+}
+
+func (s *StatsD) Emit(procs []scanner.ProcessInfo, _ time.Time) error {
+	var buf bytes.Buffer
+	for _, g := range gauges(procs) {
+		line := fmt.Sprintf("%s:%g|g|#%s\n", g.name, g.value, tags(g.labels))
+		if buf.Len()+len(line) > maxDatagram {
+			if _, err := s.conn.Write(buf.Bytes()); err != nil {
+				return err
+			}
+			buf.Reset()
+		}
+		buf.WriteString(line)
+	}
+	if buf.Len() > 0 {
+		_, err := s.conn.Write(buf.Bytes())
+		return err
+	}
+	return nil
+}
+
+func tags(labels map[string]string) string {
+	var out []string
+	for k, v := range labels {
+		// ',' and '|' are separators in the line format
+		v = strings.NewReplacer(",", "_", "|", "_", "#", "_").Replace(v)
+		out = append(out, k+":"+v)
+	}
+	sort.Strings(out)
+	return strings.Join(out, ",")
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"port-monitor/history"
+	"port-monitor/scanner"
+)
+
+const defaultFreeRange = "3000-3999"
+
+type nextFreeMsg struct {
+	port uint32
+	err  error
+}
+
+// runNextFree prints the lowest port in a range that nothing listens on
+// now or did recently, for scripts picking a dev server port.
+func runNextFree(args []string) error {
+	fs := flag.NewFlagSet("next-free", flag.ExitOnError)
+	portRange := fs.String("range", defaultFreeRange, "port range to search, lo-hi")
+	historyPath := fs.String("history", "", "history file of recently used ports (default: user cache dir)")
+	since := fs.Duration("since", 7*24*time.Hour, "how far back history counts as recently used")
+	fs.Parse(args)
+
+	lo, hi, err := parsePortRange(*portRange)
+	if err != nil {
+		return err
+	}
+	procs, err := scanner.Scan(context.Background(), scanner.Options{ListenOnly: true})
+	if err != nil {
+		return err
+	}
+	path, err := resolveHistoryPath(*historyPath)
+	if err != nil {
+		return err
+	}
+
+	port, err := nextFreePort(lo, hi, procs, path, time.Now().Add(-*since))
+	if err != nil {
+		return err
+	}
+	fmt.Println(port)
+	return nil
+}
+
+// nextFreeCmd searches from the selected port, or the default range,
+// in the background.
+func nextFreeCmd(from uint32, procs []scanner.ProcessInfo, historyPath string) tea.Cmd {
+	return func() tea.Msg {
+		lo, hi, _ := parsePortRange(defaultFreeRange)
+		if from > 0 {
+			lo, hi = from, min(from+999, 65535)
+		}
+		port, err := nextFreePort(lo, hi, procs, historyPath, time.Now().Add(-7*24*time.Hour))
+		return nextFreeMsg{port: port, err: err}
+	}
+}
+
+// nextFreePort returns the lowest port in [lo, hi] that no process in
+// procs or in history since the cutoff listened on, and that can be bound
+// right now (which also catches sockets of processes we can't see).
+// A missing history file is not an error.
+func nextFreePort(lo, hi uint32, procs []scanner.ProcessInfo, historyPath string, cutoff time.Time) (uint32, error) {
+	used := make(map[uint32]bool)
+	markListeners := func(procs []scanner.ProcessInfo) {
+		for _, p := range procs {
+			for _, c := range p.Connections {
+				if c.Status == "LISTEN" {
+					used[c.Port] = true
+				}
+			}
+		}
+	}
+	markListeners(procs)
+
+	if historyPath != "" {
+		snaps, err := history.Load(historyPath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return 0, err
+		}
+		for _, s := range snaps {
+			if s.Time.After(cutoff) {
+				markListeners(s.Processes)
+			}
+		}
+	}
+
+	for port := lo; port <= hi; port++ {
+		if !used[port] && canBind(port) {
+			return port, nil
+		}
+	}
+	return 0, withCode(exitNoMatch, fmt.Errorf("no free port in %d-%d", lo, hi))
+}
+
+// canBind reports whether a TCP listener can take port on all interfaces.
+func canBind(port uint32) bool {
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
+
+// parsePortRange parses "3000-3999" or a single port.
+func parsePortRange(s string) (uint32, uint32, error) {
+	loStr, hiStr, found := strings.Cut(s, "-")
+	if !found {
+		hiStr = loStr
+	}
+	lo, err1 := strconv.ParseUint(strings.TrimSpace(loStr), 10, 16)
+	hi, err2 := strconv.ParseUint(strings.TrimSpace(hiStr), 10, 16)
+	if err1 != nil || err2 != nil || lo == 0 || lo > hi {
+		return 0, 0, usageError("invalid port range %q, expected lo-hi", s)
+	}
+	return uint32(lo), uint32(hi), nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// renice sets the niceness of the monitor itself to n. On Linux niceness
+// belongs to each thread, and PRIO_PROCESS with pid 0 only reaches the
+// calling one, so every thread of the process is reniced. Threads started
+// later inherit the value from the thread that creates them.
+func renice(n int) error {
+	tasks, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return syscall.Setpriority(syscall.PRIO_PROCESS, 0, n)
+	}
+	for _, t := range tasks {
+		tid, err := strconv.Atoi(t.Name())
+		if err != nil {
+			continue
+		}
+		// A thread may exit between listing and renicing
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, tid, n); err != nil && err != syscall.ESRCH {
+			return err
+		}
+	}
+	return nil
+}
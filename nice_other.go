@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+import "errors"
+
+func renice(n int) error {
+	return errors.New("renice is not supported on this platform")
+}
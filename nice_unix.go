@@ -0,0 +1,10 @@
+//go:build unix && !linux
+
+package main
+
+import "syscall"
+
+// renice sets the niceness of the monitor itself to n.
+func renice(n int) error {
+	return syscall.Setpriority(syscall.PRIO_PROCESS, 0, n)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"sync"
+
+	"port-monitor/scanner"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type packagesResolvedMsg struct{}
+
+// packageCache maps executable paths to the package that installed them.
+// Paths no package manager claims are cached as "" so they aren't asked
+// again on every scan.
+type packageCache struct {
+	mu      sync.Mutex
+	owners  map[string]string
+	pending map[string]bool
+}
+
+func newPackageCache() *packageCache {
+	return &packageCache{
+		owners:  make(map[string]string),
+		pending: make(map[string]bool),
+	}
+}
+
+// owner returns the package of exe, or "" if unknown.
+func (c *packageCache) owner(exe string) string {
+	if c == nil {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.owners[exe]
+}
+
+// lookup returns the package of exe and whether it was looked up yet.
+func (c *packageCache) lookup(exe string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pkg, ok := c.owners[exe]
+	return pkg, ok
+}
+
+// resolveCmd looks up the executables of processes with connections that
+// aren't cached yet. Queries run one at a time, dpkg holds a lock anyway.
+func (c *packageCache) resolveCmd(procs []scanner.ProcessInfo) tea.Cmd {
+	var exes []string
+	c.mu.Lock()
+	for _, p := range procs {
+		if p.Exe == "" || len(p.Connections) == 0 {
+			continue
+		}
+		if _, ok := c.owners[p.Exe]; ok || c.pending[p.Exe] {
+			continue
+		}
+		c.pending[p.Exe] = true
+		exes = append(exes, p.Exe)
+	}
+	c.mu.Unlock()
+
+	if len(exes) == 0 {
+		return nil
+	}
+
+	return func() tea.Msg {
+		for _, exe := range exes {
+			pkg := scanner.PackageOf(exe)
+			c.mu.Lock()
+			c.owners[exe] = pkg
+			delete(c.pending, exe)
+			c.mu.Unlock()
+		}
+		return packagesResolvedMsg{}
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// paletteAction is an entry of the command palette. Running it replays its
+// key, so the palette can't drift from what the keys do.
+type paletteAction struct {
+	name string
+	key  string
+}
+
+// paletteActions lists every action reachable from a key.
+var paletteActions = []paletteAction{
+	{"Switch view tab", "tab"},
+	{"Switch host", "H"},
+	{"Select process", " "},
+	{"Kill selected processes", "k"},
+	{"Filter: toggle processes without ports", "f"},
+	{"Filter: toggle kernel threads", "t"},
+	{"Filter: toggle root daemons", "r"},
+	{"Filter: only exposed ports", "e"},
+	{"Filter: only stopped and zombie processes", "z"},
+	{"Filter: search", "/"},
+	{"Filter: expression", "|"},
+	{"Sort: next column", "s"},
+	{"Sort: reverse order", "o"},
+	{"Show bind addresses", "a"},
+	{"Show all ports of process", "v"},
+	{"Show memory percent and swap", "m"},
+	{"Show CPU as a share of all cores", "%"},
+	{"Choose columns", "O"},
+	{"Toggle command layout", "c"},
+	{"Toggle row per port", "l"},
+	{"Group workers / by user", "w"},
+	{"Expand group", "enter"},
+	{"Next connection", "J"},
+	{"Previous connection", "K"},
+	{"Drop connection", "x"},
+	{"Block port in firewall", "F"},
+	{"Resolve DNS names", "n"},
+	{"Watch port traffic", "W"},
+	{"Probe latency", "L"},
+	{"Find next free port", "N"},
+	{"List router port mappings (UPnP)", "U"},
+	{"Hold port", "V"},
+	{"Forward port", "T"},
+	{"Probe: test HTTP", "i"},
+	{"Probe: test TCP", "I"},
+	{"Capture packets", "p"},
+	{"Capture packets to pcap file", "P"},
+	{"Attach debugger, here or in new window", "B"},
+	{"Show debugger attach URL", "A"},
+	{"Dump stacks (SIGQUIT)", "Q"},
+	{"Dump core", "C"},
+	{"Open pprof UI", "g"},
+	{"Record CPU profile", "G"},
+	{"Restart service", "R"},
+	{"Stop service", "S"},
+	{"Explain process", "E"},
+	{"Export process as JSON", "X"},
+	{"Security: suspicious listeners", "!"},
+	{"Show event log", "M"},
+	{"Command prompt", ":"},
+	{"History: previous snapshot", "["},
+	{"History: next snapshot", "]"},
+	{"History: first snapshot", "{"},
+	{"History: last snapshot", "}"},
+	{"Quit", "q"},
+}
+
+// paletteMax caps the number of matches shown.
+const paletteMax = 12
+
+// paletteMatches returns the actions matching query, best first.
+func paletteMatches(query string) []paletteAction {
+	type scored struct {
+		a     paletteAction
+		score int
+	}
+	var matches []scored
+	for _, a := range paletteActions {
+		if score, ok := fuzzyScore(query, a.name); ok {
+			matches = append(matches, scored{a, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b scored) int { return b.score - a.score })
+	actions := make([]paletteAction, len(matches))
+	for i, s := range matches {
+		actions[i] = s.a
+	}
+	return actions
+}
+
+// fuzzyScore reports whether the runes of query appear in text in order,
+// ignoring case, and scores the match higher the more of them are
+// adjacent or start a word, and highest when it is a substring.
+func fuzzyScore(query, text string) (int, bool) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	text = strings.ToLower(text)
+	q, t := []rune(query), []rune(text)
+	score, qi, prev := 0, 0, -2
+	if query != "" && strings.Contains(text, query) {
+		score += 3 * len(q)
+	}
+	for i, r := range t {
+		if qi == len(q) {
+			break
+		}
+		if r != q[qi] {
+			continue
+		}
+		score++
+		if i == prev+1 {
+			score += 2
+		}
+		if i == 0 || !unicode.IsLetter(t[i-1]) {
+			score += 3
+		}
+		prev = i
+		qi++
+	}
+	return score, qi == len(q)
+}
+
+// keyLabel names a key as the help line does.
+func keyLabel(key string) string {
+	switch key {
+	case " ":
+		return "Space"
+	case "tab":
+		return "Tab"
+	case "enter":
+		return "Enter"
+	}
+	return key
+}
+
+// keyMsg builds the message bubbletea sends for key.
+func keyMsg(key string) tea.KeyMsg {
+	switch key {
+	case " ":
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(key)}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+// paletteView renders the palette with the matches of what was typed.
+func (m model) paletteView() string {
+	var b strings.Builder
+	b.WriteString(m.paletteInput.View())
+	matches := paletteMatches(m.paletteInput.Value())
+	if len(matches) == 0 {
+		b.WriteString("\n\nNo matching action.")
+	}
+	start := max(m.paletteCursor-paletteMax+1, 0)
+	width := max(m.width-16, 20)
+	for i := start; i < len(matches) && i < start+paletteMax; i++ {
+		marker := "  "
+		if i == m.paletteCursor {
+			marker = glyph("▸ ", "> ")
+		}
+		line := fmt.Sprintf("%s%-*s [%s]", marker, 40, matches[i].name, keyLabel(matches[i].key))
+		line = ellipsize(line, width)
+		if i == m.paletteCursor {
+			line = lipgloss.NewStyle().Bold(true).Render(line)
+		}
+		b.WriteString("\n" + line)
+	}
+	if n := len(matches) - start - paletteMax; n > 0 {
+		fmt.Fprintf(&b, "\n  %d more", n)
+	}
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"port-monitor/filter"
+	"port-monitor/scanner"
+)
+
+// noColor is set by the NO_COLOR convention (https://no-color.org). Styles
+// drop their colors on their own; what only colors told apart, like the
+// cursor row and the active tab, gets a textual marker instead.
+var noColor = os.Getenv("NO_COLOR") != ""
+
+// activeTab renders the label of the active tab.
+func activeTab(label string) string {
+	if noColor {
+		label = "[" + label + "]"
+	}
+	return activeTabStyle.Render(label)
+}
+
+// tableView renders the table with load colors, marking the cursor row
+// with ">" when it can't be highlighted.
+func (m model) tableView() string {
+	rows := m.table.Rows()
+	cursor := m.table.Cursor()
+	if !noColor || cursor < 0 || cursor >= len(rows) || isPlaceholder(rows[cursor]) {
+		return m.heatCells(m.table.View())
+	}
+	rows = slices.Clone(rows)
+	rows[cursor] = slices.Clone(rows[cursor])
+	rows[cursor][0] = ">" + rows[cursor][0]
+	t := m.table
+	t.SetRows(rows)
+	return t.View()
+}
+
+// runPlain is the --plain mode for screen readers and dumb terminals: no
+// screen redraws, colors or borders. It prints every listening port on a
+// line of its own, then one line per change until interrupted.
+func runPlain(src processSource, match filter.Predicate, interval time.Duration) error {
+	if match == nil {
+		match = func(scanner.ProcessInfo) bool { return true }
+	}
+	scan := func() ([]scanner.ProcessInfo, error) {
+		procs, err := src.ScanProcesses()
+		if err != nil {
+			return nil, err
+		}
+		return slices.DeleteFunc(procs, func(p scanner.ProcessInfo) bool { return !match(p) }), nil
+	}
+
+	prev, err := scan()
+	if err != nil {
+		return err
+	}
+	lines := plainListeners(prev)
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	fmt.Printf("%d listening ports. Watching for changes, press Ctrl+C to stop.\n", len(lines))
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case now := <-ticker.C:
+			curr, err := scan()
+			if err != nil {
+				fmt.Println("Scan failed:", err)
+				continue
+			}
+			for _, e := range scanner.Diff(prev, curr, now) {
+				if e.Type == scanner.PortOpened || e.Type == scanner.PortClosed {
+					fmt.Printf("%s %s\n", now.Format("15:04:05"), e)
+				}
+			}
+			prev = curr
+		}
+	}
+}
+
+// plainListeners describes each listening socket in a sentence, ordered by
+// port.
+func plainListeners(procs []scanner.ProcessInfo) []string {
+	type listener struct {
+		c scanner.Connection
+		p scanner.ProcessInfo
+	}
+	var all []listener
+	for _, p := range procs {
+		for _, c := range p.Connections {
+			if c.Status == "LISTEN" {
+				all = append(all, listener{c, p})
+			}
+		}
+	}
+	slices.SortStableFunc(all, func(a, b listener) int {
+		return int(a.c.Port) - int(b.c.Port)
+	})
+
+	lines := make([]string, 0, len(all))
+	for _, l := range all {
+		addr := l.c.LocalAddr
+		if addr == "" {
+			addr = "*"
+		}
+		line := fmt.Sprintf("Port %d %s on %s: %s, PID %d, user %s", l.c.Port, l.c.Protocol, addr, l.p.Name, l.p.PID, l.p.User)
+		if l.c.IsExposed() {
+			line += ", exposed to the network"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,314 @@
+// Package portmap asks the local router which ports it forwards from the
+// internet, over UPnP IGD, falling back to NAT-PMP for at least the
+// external address.
+package portmap
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	discoverWait = 2 * time.Second
+	maxMappings  = 512 // Bound on GetGenericPortMappingEntry calls
+)
+
+var ssdpGroup = &net.UDPAddr{IP: net.IPv4(239, 255, 255, 250), Port: 1900}
+
+// Mapping is a port the router forwards to a machine on the LAN.
+type Mapping struct {
+	Protocol     string // tcp or udp
+	ExternalPort uint32
+	RemoteHost   string // Only this internet host may connect; empty for any
+	InternalHost string
+	InternalPort uint32
+	Description  string
+	Enabled      bool
+}
+
+// Gateway is what the router told about itself.
+type Gateway struct {
+	Addr       string // LAN address of the router
+	Protocol   string // UPnP or NAT-PMP
+	ExternalIP string
+	Mappings   []Mapping
+
+	// Listed is false over NAT-PMP, which has no way to list mappings.
+	Listed bool
+}
+
+// Query finds the router and lists its port mappings.
+func Query(ctx context.Context) (Gateway, error) {
+	location, err := discover(ctx)
+	if err == nil {
+		return queryUPnP(ctx, location)
+	}
+	gw, perr := queryNATPMP(ctx)
+	if perr != nil {
+		return Gateway{}, fmt.Errorf("no UPnP gateway (%v) and no NAT-PMP gateway (%v)", err, perr)
+	}
+	return gw, nil
+}
+
+// discover finds the description URL of an Internet Gateway Device with an
+// SSDP M-SEARCH.
+func discover(ctx context.Context) (string, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	for _, st := range []string{
+		"urn:schemas-upnp-org:device:InternetGatewayDevice:1",
+		"urn:schemas-upnp-org:device:InternetGatewayDevice:2",
+	} {
+		req := "M-SEARCH * HTTP/1.1\r\n" +
+			"HOST: 239.255.255.250:1900\r\n" +
+			"MAN: \"ssdp:discover\"\r\n" +
+			"MX: 1\r\n" +
+			"ST: " + st + "\r\n\r\n"
+		if _, err := conn.WriteToUDP([]byte(req), ssdpGroup); err != nil {
+			return "", err
+		}
+	}
+
+	deadline := time.Now().Add(discoverWait)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetReadDeadline(deadline)
+	buf := make([]byte, 2048)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return "", errors.New("no answer to SSDP discovery")
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:n])), nil)
+		if err != nil {
+			continue
+		}
+		if location := resp.Header.Get("Location"); location != "" {
+			return location, nil
+		}
+	}
+}
+
+type upnpService struct {
+	Type       string `xml:"serviceType"`
+	ControlURL string `xml:"controlURL"`
+}
+
+// queryUPnP reads the device description at location and lists the
+// mappings of its WAN connection service.
+func queryUPnP(ctx context.Context, location string) (Gateway, error) {
+	base, err := url.Parse(location)
+	if err != nil {
+		return Gateway{}, fmt.Errorf("invalid gateway location %q: %w", location, err)
+	}
+	gw := Gateway{Addr: base.Hostname(), Protocol: "UPnP", Listed: true}
+
+	body, err := get(ctx, location)
+	if err != nil {
+		return gw, err
+	}
+	svc, err := wanService(body)
+	if err != nil {
+		return gw, err
+	}
+	control, err := base.Parse(svc.ControlURL)
+	if err != nil {
+		return gw, fmt.Errorf("invalid control URL %q: %w", svc.ControlURL, err)
+	}
+
+	if fields, err := soap(ctx, control.String(), svc.Type, "GetExternalIPAddress", ""); err == nil {
+		gw.ExternalIP = fields["NewExternalIPAddress"]
+	}
+	for i := range maxMappings {
+		fields, err := soap(ctx, control.String(), svc.Type, "GetGenericPortMappingEntry",
+			fmt.Sprintf("<NewPortMappingIndex>%d</NewPortMappingIndex>", i))
+		if err != nil {
+			// A fault (SpecifiedArrayIndexInvalid) ends the list
+			break
+		}
+		external, _ := strconv.ParseUint(fields["NewExternalPort"], 10, 16)
+		internal, _ := strconv.ParseUint(fields["NewInternalPort"], 10, 16)
+		gw.Mappings = append(gw.Mappings, Mapping{
+			Protocol:     strings.ToLower(fields["NewProtocol"]),
+			ExternalPort: uint32(external),
+			RemoteHost:   fields["NewRemoteHost"],
+			InternalHost: fields["NewInternalClient"],
+			InternalPort: uint32(internal),
+			Description:  fields["NewPortMappingDescription"],
+			Enabled:      fields["NewEnabled"] != "0",
+		})
+	}
+	return gw, nil
+}
+
+// wanService finds the WANIPConnection or WANPPPConnection service in a
+// device description, wherever it is nested.
+func wanService(description []byte) (upnpService, error) {
+	d := xml.NewDecoder(bytes.NewReader(description))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return upnpService{}, errors.New("gateway has no WAN connection service")
+		}
+		if err != nil {
+			return upnpService{}, fmt.Errorf("invalid gateway description: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "service" {
+			continue
+		}
+		var svc upnpService
+		if err := d.DecodeElement(&svc, &start); err != nil {
+			return upnpService{}, fmt.Errorf("invalid gateway description: %w", err)
+		}
+		if strings.Contains(svc.Type, ":WANIPConnection:") || strings.Contains(svc.Type, ":WANPPPConnection:") {
+			return svc, nil
+		}
+	}
+}
+
+// soap calls action on a UPnP service and returns the leaf elements of
+// the answer by name.
+func soap(ctx context.Context, control, service, action, args string) (map[string]string, error) {
+	envelope := `<?xml version="1.0"?>` +
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">` +
+		`<s:Body><u:` + action + ` xmlns:u="` + service + `">` + args + `</u:` + action + `></s:Body></s:Envelope>`
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, control, strings.NewReader(envelope))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", `"`+service+"#"+action+`"`)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", action, resp.Status)
+	}
+
+	fields := make(map[string]string)
+	d := xml.NewDecoder(resp.Body)
+	var name string
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return fields, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid answer: %w", action, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name = t.Name.Local
+		case xml.CharData:
+			if name != "" {
+				fields[name] += string(t)
+			}
+		case xml.EndElement:
+			name = ""
+		}
+	}
+}
+
+var client = &http.Client{Timeout: 3 * time.Second}
+
+func get(ctx context.Context, location string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch gateway description: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch gateway description: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// queryNATPMP asks the default gateway for its external address (RFC 6886
+// opcode 0). NAT-PMP can create mappings but not list them.
+func queryNATPMP(ctx context.Context) (Gateway, error) {
+	router, err := defaultGateway()
+	if err != nil {
+		return Gateway{}, err
+	}
+	conn, err := net.DialUDP("udp4", nil, &net.UDPAddr{IP: router, Port: 5351})
+	if err != nil {
+		return Gateway{}, err
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(discoverWait)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+	if _, err := conn.Write([]byte{0, 0}); err != nil {
+		return Gateway{}, err
+	}
+	buf := make([]byte, 16)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return Gateway{}, fmt.Errorf("no answer from %s", router)
+	}
+	if n < 12 || buf[1] != 128 {
+		return Gateway{}, fmt.Errorf("invalid NAT-PMP answer from %s", router)
+	}
+	if code := binary.BigEndian.Uint16(buf[2:]); code != 0 {
+		return Gateway{}, fmt.Errorf("NAT-PMP error %d from %s", code, router)
+	}
+	return Gateway{
+		Addr:       router.String(),
+		Protocol:   "NAT-PMP",
+		ExternalIP: net.IP(buf[8:12]).String(),
+	}, nil
+}
+
+// defaultGateway reads the IPv4 default route. Only Linux exposes it
+// without running a command.
+func defaultGateway() (net.IP, error) {
+	f, err := os.Open("/proc/net/route")
+	if err != nil {
+		return nil, errors.New("default gateway unknown on this OS")
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+		b, err := hex.DecodeString(fields[2])
+		if err != nil || len(b) != 4 {
+			continue
+		}
+		// Little endian
+		return net.IPv4(b[3], b[2], b[1], b[0]), nil
+	}
+	return nil, errors.New("no default route")
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"port-monitor/scanner"
+)
+
+// portEntries formats the ports of a process as shown in the Ports column:
+// listeners first with their markers, then the rest.
+func (m *model) portEntries(p scanner.ProcessInfo) []string {
+	var listenPorts []string
+	var otherPorts []string
+	for _, c := range p.Connections {
+		if c.Status == "LISTEN" {
+			listenPorts = append(listenPorts, m.listenEntry(p, c))
+		} else {
+			otherPorts = append(otherPorts, fmt.Sprintf("%d(E)", c.Port))
+		}
+	}
+	for _, port := range m.diff.closedPortsOf(p.PID) {
+		listenPorts = append(listenPorts, fmt.Sprintf("-%d(L)", port))
+	}
+	return append(listenPorts, otherPorts...)
+}
+
+// listenEntry formats a listening port of p with its exposure, health
+// and change markers. Debugger ports are marked (D) instead of (L).
+func (m *model) listenEntry(p scanner.ProcessInfo, c scanner.Connection) string {
+	entry := fmt.Sprintf("%d(L)", c.Port)
+	if m.showBindAddr {
+		entry = hostPort(c.LocalAddr, c.Port)
+	}
+	if debugServer(p, c) != "" {
+		entry = strings.TrimSuffix(entry, "(L)") + "(D)"
+	}
+	if c.IsExposed() {
+		entry += "!"
+	}
+	if r, ok := m.health[c.Port]; ok {
+		entry += healthMark(r.Status)
+	}
+	if m.diff.isNewPort(p.PID, c.Port) {
+		entry = "+" + entry
+	}
+	return entry
+}
+
+// portsModal lists every port of p, wrapped to the modal width, since the
+// Ports cell truncates whatever doesn't fit.
+func (m *model) portsModal(p scanner.ProcessInfo) string {
+	entries := m.portEntries(p)
+	if len(entries) == 0 {
+		return fmt.Sprintf("%s (PID %d) has no ports.", p.Name, p.PID)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Ports of %s (PID %d): %d\n", p.Name, p.PID, len(entries))
+	width := max(m.width-8, 20)
+	line := 0
+	for i, e := range entries {
+		if i > 0 {
+			if line+2+len(e) > width {
+				b.WriteString(",\n")
+				line = 0
+			} else {
+				b.WriteString(", ")
+				line += 2
+			}
+		}
+		b.WriteString(e)
+		line += len(e)
+	}
+	return b.String()
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"port-monitor/scanner"
+)
+
+const (
+	pprofTimeout        = 500 * time.Millisecond
+	pprofProfileSeconds = 10
+)
+
+type pprofProbedMsg struct{}
+
+type profileSavedMsg struct {
+	file string
+	err  error
+}
+
+// pprofCache remembers which listeners of Go processes serve
+// net/http/pprof. Every pid:port is probed once.
+type pprofCache struct {
+	mu      sync.Mutex
+	found   map[string]string // pid:port -> pprof index URL, "" when absent
+	pending map[string]bool
+}
+
+func newPprofCache() *pprofCache {
+	return &pprofCache{
+		found:   make(map[string]string),
+		pending: make(map[string]bool),
+	}
+}
+
+func pprofKey(pid int32, port uint32) string {
+	return fmt.Sprintf("%d:%d", pid, port)
+}
+
+// endpoint returns the pprof index URL served by p, or "".
+func (c *pprofCache) endpoint(p scanner.ProcessInfo) string {
+	if c == nil {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, conn := range sortedConnections(p.Connections) {
+		if url := c.found[pprofKey(p.PID, conn.Port)]; url != "" && conn.Status == "LISTEN" {
+			return url
+		}
+	}
+	return ""
+}
+
+// probeCmd requests /debug/pprof/ from the TCP listeners of Go processes
+// that haven't been probed yet.
+func (c *pprofCache) probeCmd(procs []scanner.ProcessInfo) tea.Cmd {
+	urls := make(map[string]string)
+	c.mu.Lock()
+	for _, p := range procs {
+		if p.Runtime != "go" {
+			continue
+		}
+		for _, conn := range p.Connections {
+			if conn.Status != "LISTEN" || !strings.HasPrefix(conn.Protocol, "tcp") {
+				continue
+			}
+			key := pprofKey(p.PID, conn.Port)
+			if _, ok := c.found[key]; ok || c.pending[key] {
+				continue
+			}
+			c.pending[key] = true
+			urls[key] = fmt.Sprintf("http://%s/debug/pprof/", hostPort(dialAddr(conn.LocalAddr), conn.Port))
+		}
+	}
+	c.mu.Unlock()
+
+	if len(urls) == 0 {
+		return nil
+	}
+
+	return func() tea.Msg {
+		var wg sync.WaitGroup
+		for key, url := range urls {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if !servesPprof(url) {
+					url = ""
+				}
+				c.mu.Lock()
+				c.found[key] = url
+				delete(c.pending, key)
+				c.mu.Unlock()
+			}()
+		}
+		wg.Wait()
+		return pprofProbedMsg{}
+	}
+}
+
+// dialAddr maps wildcard bind addresses to loopback.
+func dialAddr(addr string) string {
+	ip := net.ParseIP(addr)
+	switch {
+	case ip == nil || ip.Equal(net.IPv4zero):
+		return "127.0.0.1"
+	case ip.Equal(net.IPv6unspecified):
+		return "::1"
+	}
+	return addr
+}
+
+// servesPprof reports whether url is a net/http/pprof index page.
+func servesPprof(url string) bool {
+	client := http.Client{Timeout: pprofTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	return strings.Contains(string(body), "Types of profiles available")
+}
+
+// openPprofUI serves the heap profile in pprof's web UI, which opens the
+// browser, through the Go toolchain. Without one the index page is opened.
+func openPprofUI(index string) (string, error) {
+	if _, err := exec.LookPath("go"); err == nil {
+		cmd := exec.Command("go", "tool", "pprof", "-http=localhost:0", index+"heap")
+		if err := cmd.Start(); err != nil {
+			return "", fmt.Errorf("failed to start pprof: %w", err)
+		}
+		go cmd.Wait()
+		return "Opening pprof UI for " + index + "heap", nil
+	}
+	if err := openBrowser(index); err != nil {
+		return "", err
+	}
+	return "Opened " + index, nil
+}
+
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open browser: %w", err)
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// downloadProfileCmd records a CPU profile of name from index into the
+// user cache dir, for `go tool pprof`.
+func downloadProfileCmd(index, name string) tea.Cmd {
+	return func() tea.Msg {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return profileSavedMsg{err: fmt.Errorf("failed to find cache dir: %w", err)}
+		}
+		dir = filepath.Join(dir, "port-monitor", "profiles")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return profileSavedMsg{err: fmt.Errorf("failed to create profile dir: %w", err)}
+		}
+
+		client := http.Client{Timeout: (pprofProfileSeconds + 10) * time.Second}
+		resp, err := client.Get(fmt.Sprintf("%sprofile?seconds=%d", index, pprofProfileSeconds))
+		if err != nil {
+			return profileSavedMsg{err: err}
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return profileSavedMsg{err: fmt.Errorf("profile request failed: %s", resp.Status)}
+		}
+
+		file := filepath.Join(dir, fmt.Sprintf("cpu-%s-%s.pb.gz", name, time.Now().Format("20060102-150405")))
+		f, err := os.Create(file)
+		if err != nil {
+			return profileSavedMsg{err: err}
+		}
+		defer f.Close()
+		if _, err := io.Copy(f, resp.Body); err != nil {
+			return profileSavedMsg{err: err}
+		}
+		return profileSavedMsg{file: file}
+	}
+}
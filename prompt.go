@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"port-monitor/scanner"
+)
+
+// promptHelp lists the commands of the : prompt.
+const promptHelp = "Commands: kill PORT, goto PORT, filter TEXT"
+
+// runPrompt runs a line typed at the : prompt.
+func (m *model) runPrompt(line string) tea.Cmd {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	name, args := fields[0], fields[1:]
+
+	switch name {
+	case "kill", "k":
+		port, err := promptPort(args)
+		if err != nil {
+			return m.notify(fmt.Sprintf("Error: %v", err))
+		}
+		if m.replaying {
+			return m.notify("Kill is disabled while replaying history.")
+		}
+		var pids []int32
+		for _, p := range m.processes {
+			if listensOn(p, port) {
+				pids = append(pids, p.PID)
+			}
+		}
+		if len(pids) == 0 {
+			return m.notify(fmt.Sprintf("Nothing listens on port %d.", port))
+		}
+		if pids = m.unprotected(pids); len(pids) == 0 {
+			return m.notify(protectedNote)
+		}
+		m.pendingPids = killOrder(pids, m.processes)
+		m.confirming = true
+	case "goto", "g":
+		port, err := promptPort(args)
+		if err != nil {
+			return m.notify(fmt.Sprintf("Error: %v", err))
+		}
+		return m.gotoPort(port)
+	case "filter", "f", "/":
+		m.textInput.SetValue(strings.Join(args, " "))
+		m.updateTable()
+	default:
+		return m.notify(fmt.Sprintf("Unknown command %q. %s", name, promptHelp))
+	}
+	return nil
+}
+
+// promptPort parses the port argument of a prompt command.
+func promptPort(args []string) (uint32, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("expected a port. %s", promptHelp)
+	}
+	port, err := strconv.ParseUint(args[0], 10, 16)
+	if err != nil || port == 0 {
+		return 0, fmt.Errorf("invalid port %q", args[0])
+	}
+	return uint32(port), nil
+}
+
+// gotoPort moves the cursor to the row listening on port, or else the
+// first one with any socket on it.
+func (m *model) gotoPort(port uint32) tea.Cmd {
+	i := -1
+	for _, match := range []func(rowItem) bool{
+		func(it rowItem) bool { return it.port != nil && it.port.Port == port },
+		func(it rowItem) bool { return it.port == nil && listensOn(it.p, port) },
+		func(it rowItem) bool { return usesPort(it.p, port) },
+	} {
+		for j, it := range m.rowItems {
+			if match(it) {
+				i = j
+				break
+			}
+		}
+		if i >= 0 {
+			break
+		}
+	}
+	if i < 0 {
+		for _, p := range m.processes {
+			if usesPort(p, port) {
+				return m.notify(fmt.Sprintf("Port %d is hidden by the current tab or filters.", port))
+			}
+		}
+		return m.notify(fmt.Sprintf("Nothing uses port %d.", port))
+	}
+	m.table.SetCursor(i)
+	m.ensureRows()
+	return nil
+}
+
+func usesPort(p scanner.ProcessInfo, port uint32) bool {
+	for _, c := range p.Connections {
+		if c.Port == port {
+			return true
+		}
+	}
+	return false
+}
+
+// notify shows a message in the status line until it times out.
+func (m *model) notify(msg string) tea.Cmd {
+	m.notification = msg
+	return waitNotificationCmd()
+}
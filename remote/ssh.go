@@ -0,0 +1,190 @@
+package remote
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"port-monitor/scanner"
+)
+
+// remoteScript collects everything a scan needs in a single round trip.
+// It relies on ss and procps, so the remote host has to be Linux.
+const remoteScript = `id -un; echo ---; ss -tunapH; echo ---; ps -eo pid=,ppid=,user:32=,pcpu=,pmem=,rss=,args=`
+
+// SSH scans a remote host by running ss and ps over ssh, so nothing has to
+// be installed there. Key based authentication is required since the TUI
+// owns the terminal and can't answer password prompts.
+type SSH struct {
+	Host string // user@server, or any alias from ~/.ssh/config
+}
+
+func (s SSH) command(remoteCmd string) *exec.Cmd {
+	return exec.Command("ssh",
+		"-o", "BatchMode=yes",
+		// Reuse one connection across scans instead of handshaking every few seconds
+		"-o", "ControlMaster=auto",
+		"-o", "ControlPath=~/.ssh/port-monitor-%C",
+		"-o", "ControlPersist=60",
+		// A host starting with - would otherwise be read as an option
+		"--", s.Host, remoteCmd,
+	)
+}
+
+func (s SSH) run(remoteCmd string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := s.command(remoteCmd)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ssh %s: %w: %s", s.Host, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+func (s SSH) ScanProcesses() ([]scanner.ProcessInfo, error) {
+	out, err := s.run(remoteScript)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := strings.SplitN(string(out), "---\n", 3)
+	if len(sections) != 3 {
+		return nil, fmt.Errorf("unexpected output from %s", s.Host)
+	}
+
+	currentUser := strings.TrimSpace(sections[0])
+	conns := parseSS(sections[1])
+	return parsePS(sections[2], currentUser, conns), nil
+}
+
+func (s SSH) KillProcess(pid int32) error {
+	_, err := s.run(fmt.Sprintf("kill -9 %d", pid))
+	return err
+}
+
+var ssUserRe = regexp.MustCompile(`pid=(\d+)`)
+
+// parseSS maps `ss -tunapH` output to connections per PID.
+func parseSS(out string) map[int32][]scanner.Connection {
+	conns := make(map[int32][]scanner.Connection)
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 7 {
+			continue // No owning process visible (needs root)
+		}
+
+		localAddr, localPort := splitAddr(fields[4])
+		remoteAddr, remotePort := splitAddr(fields[5])
+		proto := fields[0]
+		if strings.Contains(localAddr, ":") {
+			proto += "6"
+		}
+		c := scanner.Connection{
+			Protocol:   proto,
+			LocalAddr:  localAddr,
+			Port:       localPort,
+			RemoteAddr: remoteAddr,
+			RemotePort: remotePort,
+			Status:     ssState(fields[1]),
+		}
+
+		for _, m := range ssUserRe.FindAllStringSubmatch(strings.Join(fields[6:], " "), -1) {
+			pid, err := strconv.ParseInt(m[1], 10, 32)
+			if err == nil {
+				conns[int32(pid)] = append(conns[int32(pid)], c)
+			}
+		}
+	}
+	return conns
+}
+
+// ssState translates ss state names into the ones gopsutil reports locally.
+func ssState(s string) string {
+	switch s {
+	case "ESTAB":
+		return "ESTABLISHED"
+	case "UNCONN":
+		return "NONE"
+	}
+	return strings.ReplaceAll(s, "-", "_")
+}
+
+// splitAddr splits ss addresses like "127.0.0.1:80", "[::]:22",
+// "127.0.0.53%lo:53" or "*:*".
+func splitAddr(s string) (string, uint32) {
+	i := strings.LastIndex(s, ":")
+	if i < 0 {
+		return s, 0
+	}
+	host, port := s[:i], s[i+1:]
+	host = strings.Trim(host, "[]")
+	if j := strings.Index(host, "%"); j >= 0 {
+		host = host[:j]
+	}
+	if host == "*" {
+		host = "0.0.0.0"
+	}
+	if port == "*" {
+		return "", 0
+	}
+	p, _ := strconv.ParseUint(port, 10, 32)
+	return host, uint32(p)
+}
+
+// parsePS builds processes from `ps -eo pid=,ppid=,user:32=,pcpu=,pmem=,rss=,args=`.
+func parsePS(out, currentUser string, conns map[int32][]scanner.Connection) []scanner.ProcessInfo {
+	var results []scanner.ProcessInfo
+	sc := bufio.NewScanner(strings.NewReader(out))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 7 {
+			continue
+		}
+		pid, err := strconv.ParseInt(fields[0], 10, 32)
+		if err != nil {
+			continue
+		}
+		ppid, _ := strconv.ParseInt(fields[1], 10, 32)
+		cpu, _ := strconv.ParseFloat(fields[3], 64)
+		memPct, _ := strconv.ParseFloat(fields[4], 64)
+		rssKB, _ := strconv.ParseUint(fields[5], 10, 64)
+		args := strings.Join(fields[6:], " ")
+
+		name := filepath.Base(fields[6])
+		kernel := strings.HasPrefix(args, "[") && strings.HasSuffix(args, "]")
+		if kernel {
+			name = strings.Trim(args, "[]")
+			args = ""
+		}
+
+		pType := scanner.SystemProcess
+		if fields[2] == currentUser {
+			pType = scanner.UserProcess
+		}
+
+		results = append(results, scanner.ProcessInfo{
+			PID:           int32(pid),
+			PPID:          int32(ppid),
+			Name:          name,
+			User:          fields[2],
+			Type:          pType,
+			Connections:   conns[int32(pid)],
+			Command:       args,
+			AppType:       "Remote",
+			Runtime:       scanner.RuntimeOf("", args),
+			CPUPercent:    cpu,
+			MemoryUsage:   rssKB * 1024,
+			MemoryPercent: memPct,
+			KernelThread:  kernel,
+		})
+	}
+	return results
+}
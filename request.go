@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"port-monitor/scanner"
+)
+
+const (
+	requestTimeout  = 2 * time.Second
+	bannerWait      = 500 * time.Millisecond
+	responsePreview = 1024 // Bytes shown in the response modal
+)
+
+type testResponseMsg struct {
+	title string
+	text  string
+	err   error
+}
+
+// testRequestCmd sends an HTTP GET / to the listener of c, or in raw mode
+// reads the banner servers like SSH, SMTP or MySQL send on connect,
+// sending an empty line when none comes, and returns the first bytes of
+// the answer.
+func testRequestCmd(c scanner.Connection, raw bool) tea.Cmd {
+	addr := hostPort(dialAddr(c.LocalAddr), c.Port)
+	return func() tea.Msg {
+		title := "GET / from " + addr
+		if raw {
+			title = "Raw TCP " + addr
+		}
+
+		start := time.Now()
+		conn, err := net.DialTimeout("tcp", addr, requestTimeout)
+		if err != nil {
+			return testResponseMsg{title: title, err: err}
+		}
+		defer conn.Close()
+
+		buf := make([]byte, responsePreview)
+		var n int
+		if raw {
+			conn.SetReadDeadline(time.Now().Add(bannerWait))
+			n, err = io.ReadAtLeast(conn, buf, 1)
+			if n == 0 {
+				conn.Write([]byte("\r\n"))
+			}
+		} else {
+			fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: %s\r\nUser-Agent: port-monitor\r\nConnection: close\r\n\r\n", addr)
+		}
+		if n == 0 {
+			conn.SetReadDeadline(time.Now().Add(requestTimeout))
+			n, err = io.ReadFull(conn, buf)
+		}
+		took := time.Since(start).Round(time.Millisecond)
+
+		if n == 0 {
+			if err == nil || err == io.EOF || err == io.ErrUnexpectedEOF {
+				return testResponseMsg{title: title, text: fmt.Sprintf("Connection closed without an answer after %s", took)}
+			}
+			return testResponseMsg{title: title, err: err}
+		}
+		text := fmt.Sprintf("%d bytes in %s", n, took)
+		if n == responsePreview {
+			text = fmt.Sprintf("First %d bytes in %s", n, took)
+		}
+		return testResponseMsg{title: title, text: text + "\n\n" + printable(buf[:n])}
+	}
+}
+
+// printable shows a response as text, escaping control and invalid bytes
+// so binary protocols don't garble the terminal.
+func printable(b []byte) string {
+	var sb strings.Builder
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&sb, `\x%02x`, b[0])
+		case r == '\n' || r == '\t':
+			sb.WriteRune(r)
+		case r == '\r':
+			// Dropped: CRLF line ends are the norm
+		case !unicode.IsPrint(r):
+			fmt.Fprintf(&sb, `\x%02x`, r)
+		default:
+			sb.WriteRune(r)
+		}
+		b = b[size:]
+	}
+	return sb.String()
+}
+
+// clipText cuts text to fit a modal of at most width columns and height
+// lines.
+func clipText(text string, width, height int) string {
+	lines := strings.Split(text, "\n")
+	if len(lines) > height {
+		lines = append(lines[:height-1], ellipsis())
+	}
+	for i, line := range lines {
+		lines[i] = ellipsize(line, width)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// holdPort binds port on all interfaces with a placeholder listener that
+// accepts and immediately closes connections, so clients fail fast
+// instead of hanging, until the listener is closed.
+func holdPort(port uint32) (net.Listener, error) {
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	return l, nil
+}
+
+// reservations are ports the TUI holds. A nil listener means the port is
+// still in use and is taken as soon as it's freed.
+type reservations map[uint32]net.Listener
+
+// toggle reserves port, or releases it if reserved, and describes what
+// happened.
+func (r reservations) toggle(port uint32) string {
+	if l, ok := r[port]; ok {
+		if l != nil {
+			l.Close()
+		}
+		delete(r, port)
+		return fmt.Sprintf("Released port %d", port)
+	}
+	l, err := holdPort(port)
+	r[port] = l
+	if err != nil {
+		return fmt.Sprintf("Port %d is in use, holding it as soon as it's free", port)
+	}
+	return fmt.Sprintf("Holding port %d, press V again to release", port)
+}
+
+// retry takes the waiting ports that have been freed and returns them.
+func (r reservations) retry() []uint32 {
+	var taken []uint32
+	for port, l := range r {
+		if l != nil {
+			continue
+		}
+		if l, err := holdPort(port); err == nil {
+			r[port] = l
+			taken = append(taken, port)
+		}
+	}
+	slices.Sort(taken)
+	return taken
+}
+
+func (r reservations) releaseAll() {
+	for port, l := range r {
+		if l != nil {
+			l.Close()
+		}
+		delete(r, port)
+	}
+}
+
+// status lists the reserved ports for the status bar.
+func (r reservations) status() string {
+	ports := make([]uint32, 0, len(r))
+	for port := range r {
+		ports = append(ports, port)
+	}
+	slices.Sort(ports)
+	parts := make([]string, len(ports))
+	for i, port := range ports {
+		parts[i] = strconv.FormatUint(uint64(port), 10)
+		if r[port] == nil {
+			parts[i] += " (waiting)"
+		}
+	}
+	return "Holding: " + strings.Join(parts, ", ")
+}
+
+// runHold holds ports from the command line until interrupted, e.g. while
+// a service restarts.
+func runHold(args []string) error {
+	fs := flag.NewFlagSet("hold", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return usageError("usage: port-monitor hold PORT...")
+	}
+
+	r := make(reservations)
+	defer r.releaseAll()
+	for _, arg := range fs.Args() {
+		port, err := strconv.ParseUint(arg, 10, 16)
+		if err != nil || port == 0 {
+			return usageError("invalid port %q", arg)
+		}
+		l, err := holdPort(uint32(port))
+		if err != nil {
+			return fmt.Errorf("failed to hold port %d: %w", port, err)
+		}
+		r[uint32(port)] = l
+	}
+
+	log.Printf("holding %s, Ctrl+C to release", strings.Join(fs.Args(), ", "))
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+	return nil
+}
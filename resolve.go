@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"port-monitor/scanner"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	dnsTimeout     = time.Second
+	dnsMaxParallel = 8
+	dnsTTL         = time.Hour
+	dnsFailTTL     = 5 * time.Minute
+	dnsMaxEntries  = 4096
+)
+
+type dnsResolvedMsg struct{}
+
+// dnsCache holds reverse DNS names of remote peers. Failed lookups are
+// cached as an empty name so they aren't retried on every scan, but for
+// less time than names, and the oldest entries go when the cache is full.
+type dnsCache struct {
+	mu      sync.Mutex
+	names   map[string]dnsEntry
+	pending map[string]bool
+}
+
+type dnsEntry struct {
+	name    string
+	expires time.Time
+}
+
+func newDNSCache() *dnsCache {
+	return &dnsCache{
+		names:   make(map[string]dnsEntry),
+		pending: make(map[string]bool),
+	}
+}
+
+// store caches the result of looking up ip. Called with c.mu held.
+func (c *dnsCache) store(ip, name string, now time.Time) {
+	ttl := dnsTTL
+	if name == "" {
+		ttl = dnsFailTTL
+	}
+	if _, ok := c.names[ip]; !ok && len(c.names) >= dnsMaxEntries {
+		c.evict(now)
+	}
+	c.names[ip] = dnsEntry{name: name, expires: now.Add(ttl)}
+}
+
+// evict drops expired entries, or the one expiring first if none has.
+func (c *dnsCache) evict(now time.Time) {
+	var oldest string
+	for ip, e := range c.names {
+		if now.After(e.expires) {
+			delete(c.names, ip)
+			continue
+		}
+		if oldest == "" || e.expires.Before(c.names[oldest].expires) {
+			oldest = ip
+		}
+	}
+	if len(c.names) >= dnsMaxEntries {
+		delete(c.names, oldest)
+	}
+}
+
+// name returns the hostname for ip, or "" if unknown.
+func (c *dnsCache) name(ip string) string {
+	if c == nil {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// An expired name is still shown until the lookup is refreshed
+	return c.names[ip].name
+}
+
+// resolveCmd looks up the remote peers of established connections that
+// aren't cached yet, or whose cached lookup has expired.
+func (c *dnsCache) resolveCmd(procs []scanner.ProcessInfo) tea.Cmd {
+	var ips []string
+	now := time.Now()
+	c.mu.Lock()
+	for _, p := range procs {
+		for _, conn := range p.Connections {
+			ip := conn.RemoteAddr
+			if conn.Status != "ESTABLISHED" || ip == "" {
+				continue
+			}
+			if e, ok := c.names[ip]; (ok && now.Before(e.expires)) || c.pending[ip] {
+				continue
+			}
+			c.pending[ip] = true
+			ips = append(ips, ip)
+		}
+	}
+	c.mu.Unlock()
+
+	if len(ips) == 0 {
+		return nil
+	}
+
+	return func() tea.Msg {
+		sem := make(chan struct{}, dnsMaxParallel)
+		var wg sync.WaitGroup
+		for _, ip := range ips {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				ctx, cancel := context.WithTimeout(context.Background(), dnsTimeout)
+				defer cancel()
+
+				var name string
+				if names, err := net.DefaultResolver.LookupAddr(ctx, ip); err == nil && len(names) > 0 {
+					name = strings.TrimSuffix(names[0], ".")
+				}
+
+				c.mu.Lock()
+				c.store(ip, name, time.Now())
+				delete(c.pending, ip)
+				c.mu.Unlock()
+			}()
+		}
+		wg.Wait()
+		return dnsResolvedMsg{}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"port-monitor/scanner"
+)
+
+// respawnWindow is how long a killed process is watched for coming back.
+const respawnWindow = time.Minute
+
+// respawnWatch remembers a killed process that was listening on ports, so
+// a supervisor restarting it under a new PID can be reported.
+type respawnWatch struct {
+	pid     int32
+	name    string
+	command string
+	ports   []uint32
+	until   time.Time
+}
+
+func newRespawnWatch(p scanner.ProcessInfo, now time.Time) (respawnWatch, bool) {
+	var ports []uint32
+	for _, c := range p.Connections {
+		if c.Status == "LISTEN" && !slices.Contains(ports, c.Port) {
+			ports = append(ports, c.Port)
+		}
+	}
+	if len(ports) == 0 {
+		return respawnWatch{}, false
+	}
+	return respawnWatch{
+		pid:     p.PID,
+		name:    p.Name,
+		command: p.Command,
+		ports:   ports,
+		until:   now.Add(respawnWindow),
+	}, true
+}
+
+// checkRespawns looks for watched processes that are listening again under a
+// new PID. It returns the watches still pending and a message per respawn.
+func checkRespawns(watches []respawnWatch, procs []scanner.ProcessInfo, now time.Time) ([]respawnWatch, []string) {
+	var pending []respawnWatch
+	var msgs []string
+
+	for _, w := range watches {
+		found := false
+		for _, p := range procs {
+			if p.PID == w.pid || (p.Name != w.name && p.Command != w.command) {
+				continue
+			}
+			for _, c := range p.Connections {
+				if c.Status == "LISTEN" && slices.Contains(w.ports, c.Port) {
+					msgs = append(msgs, fmt.Sprintf("%s respawned on %d (pid %d)", p.Name, c.Port, p.PID))
+					found = true
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+
+		if !found && now.Before(w.until) {
+			pending = append(pending, w)
+		}
+	}
+
+	return pending, msgs
+}
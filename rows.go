@@ -0,0 +1,165 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/charmbracelet/bubbles/table"
+
+	"port-monitor/scanner"
+)
+
+// rowBuffer is how many rows beyond those the table renders are formatted
+// ahead, so scrolling a little doesn't need formatting.
+const rowBuffer = 50
+
+// rowItem is a process shown as a table row, with the text of its Name
+// cell (group markers, tree lines). In the per-port layout port is the
+// listener the row stands for.
+type rowItem struct {
+	p     scanner.ProcessInfo
+	label string
+	port  *scanner.Connection
+}
+
+// perPortItems expands every process into one row per listening port, named
+// after the process and its project. Processes without listeners are
+// dropped. byPort orders the rows by port number.
+func perPortItems(items []rowItem, byPort, desc bool) []rowItem {
+	var out []rowItem
+	for _, it := range items {
+		seen := make(map[uint32]bool)
+		for _, c := range sortedConnections(it.p.Connections) {
+			if c.Status != "LISTEN" || seen[c.Port] {
+				continue
+			}
+			seen[c.Port] = true
+			label := it.label
+			if project := projectOf(it.p); project != "" {
+				label += " " + project
+			}
+			out = append(out, rowItem{p: it.p, label: label, port: &c})
+		}
+	}
+	if byPort {
+		sort.SliceStable(out, func(i, j int) bool {
+			if desc {
+				return out[i].port.Port > out[j].port.Port
+			}
+			return out[i].port.Port < out[j].port.Port
+		})
+	}
+	return out
+}
+
+// projectOf names what a process is serving: its compose or Procfile
+// service, git checkout, or working directory.
+func projectOf(p scanner.ProcessInfo) string {
+	switch {
+	case p.Service != "":
+		return p.Service
+	case p.GitRepo != "":
+		return p.GitRepo
+	case p.Cwd != "" && p.Cwd != "/":
+		return filepath.Base(p.Cwd)
+	}
+	return ""
+}
+
+// anchorIndex finds where the row at cursor in old went in items: the same
+// process and port, else the same process, else a row that was next to it.
+// It returns -1 when none is left.
+func anchorIndex(old, items []rowItem, cursor int) int {
+	same := func(a, b rowItem) bool {
+		return a.p.PID == b.p.PID && (a.port == nil) == (b.port == nil) && (a.port == nil || a.port.Port == b.port.Port)
+	}
+	samePID := func(a, b rowItem) bool { return a.p.PID == b.p.PID }
+	for _, i := range []int{cursor, cursor + 1, cursor - 1} {
+		if i < 0 || i >= len(old) {
+			continue
+		}
+		for _, eq := range []func(a, b rowItem) bool{same, samePID} {
+			if j := slices.IndexFunc(items, func(it rowItem) bool { return eq(it, old[i]) }); j >= 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// selectedItem returns the row under the table cursor, if any.
+func (m *model) selectedItem() *rowItem {
+	i := m.table.Cursor()
+	if i < 0 || i >= len(m.rowItems) || len(m.table.Rows()) != len(m.rowItems) {
+		return nil
+	}
+	return &m.rowItems[i]
+}
+
+// placeholderRow stands in for a row that hasn't been formatted yet. It
+// carries the PID, which is all cursor handling and selection need.
+func placeholderRow(pid int32) table.Row {
+	return table.Row{"", strconv.FormatInt(int64(pid), 10)}
+}
+
+// pidCell is the PID column of a row; user rows have no PID of their own.
+func pidCell(pid int32) string {
+	if pid < 0 {
+		return ""
+	}
+	return strconv.FormatInt(int64(pid), 10)
+}
+
+func isPlaceholder(row table.Row) bool {
+	return len(row) <= 2
+}
+
+// materializeRows formats the rows the table renders around cursor (it
+// draws up to a page above and below) plus a buffer. On hosts with
+// thousands of processes this keeps a refresh to formatting a few dozen
+// rows instead of all of them.
+func (m *model) materializeRows(rows []table.Row, cursor int) {
+	reach := m.table.Height() + rowBuffer
+	for i := max(0, cursor-reach); i < min(len(rows), cursor+reach+1); i++ {
+		if isPlaceholder(rows[i]) {
+			rows[i] = m.itemRow(m.rowItems[i])
+		}
+	}
+}
+
+// itemRow formats a row, narrowing the Ports cell to the row's own port in
+// the per-port layout, where it also says what the port forwards to.
+func (m *model) itemRow(it rowItem) table.Row {
+	row := m.processRow(it.p, it.label)
+	if it.port != nil {
+		row[3] = m.listenEntry(it.p, *it.port)
+		for _, n := range []string{forwardNote(it.p, *it.port), m.vmPorts.note(it.p, *it.port)} {
+			if n != "" {
+				row[3] += " " + n
+			}
+		}
+	}
+	if asciiOnly {
+		asciiRow(row, m.table.Columns())
+	}
+	return row
+}
+
+// ensureRows formats rows that scrolled into view after the cursor moved.
+func (m *model) ensureRows() {
+	rows := m.table.Rows()
+	if len(rows) != len(m.rowItems) {
+		return
+	}
+	cursor := m.table.Cursor()
+	h := m.table.Height()
+	for i := max(0, cursor-h); i < min(len(rows), cursor+h+1); i++ {
+		if isPlaceholder(rows[i]) {
+			m.materializeRows(rows, cursor)
+			m.table.SetRows(rows)
+			return
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package rules
+
+import (
+	"fmt"
+	"slices"
+
+	"port-monitor/scanner"
+)
+
+const (
+	ActionKill   = "kill"
+	ActionNotify = "notify"
+)
+
+// Rule reserves a port for a set of programs, e.g.
+// "if any process other than myapp binds port 3000, kill it".
+type Rule struct {
+	Port   uint32   `json:"port"`
+	Allow  []string `json:"allow"`  // Process names allowed to bind the port
+	Action string   `json:"action"` // "kill" (default) or "notify"
+}
+
+func (r Rule) action() string {
+	if r.Action == "" {
+		return ActionKill
+	}
+	return r.Action
+}
+
+// Validate checks rules read from the config file, so that a misspelt
+// action is reported instead of silently falling back to another one.
+func Validate(rs []Rule) error {
+	for i, r := range rs {
+		switch r.Action {
+		case "", ActionKill, ActionNotify:
+		default:
+			return fmt.Errorf("rule %d (port %d): unknown action %q, want %q or %q", i+1, r.Port, r.Action, ActionKill, ActionNotify)
+		}
+	}
+	return nil
+}
+
+// Violation is a process bound to a port it isn't allowed to use.
+type Violation struct {
+	Rule    Rule
+	Action  string
+	Process scanner.ProcessInfo
+}
+
+// Evaluate returns every process breaking one of the rules.
+// A process breaking several rules is reported once per rule.
+func Evaluate(rs []Rule, procs []scanner.ProcessInfo) []Violation {
+	var out []Violation
+	for _, r := range rs {
+		for _, p := range procs {
+			if slices.Contains(r.Allow, p.Name) || !binds(p, r.Port) {
+				continue
+			}
+			out = append(out, Violation{Rule: r, Action: r.action(), Process: p})
+		}
+	}
+	return out
+}
+
+// binds reports whether the process listens on port: a TCP socket in
+// LISTEN, or a UDP socket bound to it without a peer. Connections accepted
+// on the port belong to the listener, and clients connecting to it get an
+// ephemeral local port, so neither matches.
+func binds(p scanner.ProcessInfo, port uint32) bool {
+	for _, c := range p.Connections {
+		if c.Port != port {
+			continue
+		}
+		if c.IsListener() {
+			return true
+		}
+	}
+	return false
+}
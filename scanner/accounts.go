@@ -0,0 +1,21 @@
+package scanner
+
+import (
+	"runtime"
+	"strings"
+)
+
+// IsSystemAccount reports whether name is the superuser or a built-in
+// service account: root on Unix, NT AUTHORITY\SYSTEM, LOCAL SERVICE and
+// NETWORK SERVICE on Windows.
+func IsSystemAccount(name string) bool {
+	return name == "root" || strings.HasPrefix(strings.ToUpper(name), `NT AUTHORITY\`)
+}
+
+// sameAccount compares user names; Windows account names are case-insensitive.
+func sameAccount(a, b string) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
@@ -0,0 +1,117 @@
+package scanner
+
+import (
+	"maps"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Classifier decides the AppType of a process, or returns "" to leave the
+// decision to the next classifier.
+type Classifier interface {
+	Classify(p ProcessInfo) string
+}
+
+// ClassifierFunc adapts a function to the Classifier interface.
+type ClassifierFunc func(p ProcessInfo) string
+
+func (f ClassifierFunc) Classify(p ProcessInfo) string {
+	return f(p)
+}
+
+var (
+	classifierMu sync.RWMutex
+	custom       []Classifier
+)
+
+// RegisterClassifier adds a classifier consulted before the built-in ones.
+// Register classifiers before scanning: a Scanner keeps the type of a
+// process it has classified already.
+func RegisterClassifier(c Classifier) {
+	classifierMu.Lock()
+	custom = append(custom, c)
+	classifierMu.Unlock()
+}
+
+// hostClassifier marks a platform classifier that reads the process from
+// this machine (its /proc files, its parent) rather than from ProcessInfo.
+type hostClassifier struct {
+	Classifier
+}
+
+// classify runs the custom classifiers, then the platform's, then the
+// portable fallbacks. Unless live, p doesn't come from this machine and
+// the host classifiers are skipped.
+func classify(p ProcessInfo, live bool) string {
+	classifierMu.RLock()
+	chain := append(append([]Classifier(nil), custom...), platformClassifiers...)
+	classifierMu.RUnlock()
+
+	for _, c := range append(chain, devToolClassifier) {
+		if _, host := c.(hostClassifier); host && !live {
+			continue
+		}
+		if t := c.Classify(p); t != "" {
+			return t
+		}
+	}
+	return "Binary"
+}
+
+// classifyKernel spots kernel threads, on the platforms that have them.
+func classifyKernel(p ProcessInfo) string {
+	if p.KernelThread {
+		return "Kernel"
+	}
+	return ""
+}
+
+// devToolClassifier spots programs run from source during development.
+var devToolClassifier = ClassifierFunc(func(p ProcessInfo) string {
+	if strings.Contains(p.Command, " go run ") || strings.HasPrefix(filepath.Base(p.Cwd), "apps") {
+		return "Dev Tool"
+	}
+	return ""
+})
+
+// appTypeEntry is a remembered classification, valid while the process
+// still has the same start time and the fields the classifiers look at.
+type appTypeEntry struct {
+	live              bool
+	started           time.Time
+	ppid              int32
+	exe, cwd, command string
+	appType           string
+}
+
+func (e appTypeEntry) matches(p ProcessInfo, live bool) bool {
+	return e.live == live && e.started.Equal(p.Started) && e.ppid == p.PPID && e.exe == p.Exe && e.cwd == p.Cwd && e.command == p.Command
+}
+
+// appType classifies p, reusing the previous scan's result for the same
+// process. The platform classifiers read files of every process, which
+// isn't worth doing again every scan.
+func (s *Scanner) appType(p ProcessInfo, live bool) (string, appTypeEntry) {
+	s.appMu.Lock()
+	prev, ok := s.appPrev[p.PID]
+	s.appMu.Unlock()
+	if ok && prev.matches(p, live) {
+		return prev.appType, prev
+	}
+	e := appTypeEntry{live: live, started: p.Started, ppid: p.PPID, exe: p.Exe, cwd: p.Cwd, command: p.Command, appType: classify(p, live)}
+	return e.appType, e
+}
+
+// rememberAppTypes replaces the previous scan's classifications, dropping
+// exited processes. A narrow scan only saw some processes and adds to them.
+func (s *Scanner) rememberAppTypes(entries map[int32]appTypeEntry, narrow bool) {
+	s.appMu.Lock()
+	if narrow {
+		maps.Copy(s.appPrev, entries)
+	} else {
+		s.appPrev = entries
+	}
+	s.appMu.Unlock()
+}
@@ -0,0 +1,29 @@
+package scanner
+
+import "strings"
+
+// platformClassifiers on macOS: app bundles, and launchd jobs (daemons run
+// as root, agents in a user session).
+var platformClassifiers = []Classifier{
+	ClassifierFunc(classifyBundle),
+	ClassifierFunc(classifyLaunchd),
+}
+
+// classifyBundle spots programs run from an .app bundle.
+func classifyBundle(p ProcessInfo) string {
+	if strings.Contains(p.Exe, ".app/Contents/") || strings.HasPrefix(p.Cwd, "/Applications") || strings.HasSuffix(p.Name, ".app") {
+		return "GUI App"
+	}
+	return ""
+}
+
+// classifyLaunchd tells launchd daemons from agents among its children.
+func classifyLaunchd(p ProcessInfo) string {
+	if p.PPID != 1 {
+		return ""
+	}
+	if p.User == "root" || strings.HasPrefix(p.User, "_") {
+		return "Daemon"
+	}
+	return "Agent"
+}
@@ -0,0 +1,44 @@
+package scanner
+
+import "testing"
+
+func TestClassifyBundle(t *testing.T) {
+	tests := []struct {
+		name string
+		p    ProcessInfo
+		want string
+	}{
+		{"bundle executable", ProcessInfo{Exe: "/Applications/Slack.app/Contents/MacOS/Slack"}, "GUI App"},
+		{"helper in a bundle", ProcessInfo{Exe: "/Users/me/Applications/Code.app/Contents/Frameworks/Code Helper.app/Contents/MacOS/Code Helper"}, "GUI App"},
+		{"run from Applications", ProcessInfo{Exe: "/usr/local/bin/x", Cwd: "/Applications/Tool"}, "GUI App"},
+		{"named like a bundle", ProcessInfo{Name: "Finder.app"}, "GUI App"},
+		{"command line tool", ProcessInfo{Exe: "/opt/homebrew/bin/redis-server", Cwd: "/Users/me"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyBundle(tt.p); got != tt.want {
+				t.Errorf("classifyBundle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyLaunchd(t *testing.T) {
+	tests := []struct {
+		name string
+		p    ProcessInfo
+		want string
+	}{
+		{"root daemon", ProcessInfo{PPID: 1, User: "root"}, "Daemon"},
+		{"system account daemon", ProcessInfo{PPID: 1, User: "_mdnsresponder"}, "Daemon"},
+		{"user agent", ProcessInfo{PPID: 1, User: "me"}, "Agent"},
+		{"not a launchd job", ProcessInfo{PPID: 4242, User: "root"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyLaunchd(tt.p); got != tt.want {
+				t.Errorf("classifyLaunchd() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// platformClassifiers on Linux: kernel threads, desktop apps (launched
+// from a .desktop file or in a desktop app scope), systemd services, and
+// terminal programs versus detached daemons.
+var platformClassifiers = []Classifier{
+	ClassifierFunc(classifyKernel),
+	hostClassifier{ClassifierFunc(classifyCgroup)},
+	hostClassifier{ClassifierFunc(classifyDesktopLaunch)},
+	hostClassifier{ClassifierFunc(classifyTerminal)},
+}
+
+// classifyCgroup reads the systemd unit from the process's cgroup.
+// Desktop environments start apps in app-*.scope units.
+func classifyCgroup(p ProcessInfo) string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", p.PID))
+	if err != nil {
+		return ""
+	}
+	return cgroupAppType(data)
+}
+
+// cgroupAppType classifies the contents of /proc/<pid>/cgroup.
+func cgroupAppType(data []byte) string {
+	unit := string(bytes.TrimSpace(data[bytes.LastIndexByte(data, '/')+1:]))
+	switch {
+	case strings.HasPrefix(unit, "app-") && strings.HasSuffix(unit, ".scope"):
+		return "GUI App"
+	case strings.HasSuffix(unit, ".service") && !strings.HasPrefix(unit, "user@"):
+		return "Service"
+	}
+	return ""
+}
+
+// classifyDesktopLaunch looks for the variables GLib and Unity launchers
+// set when starting an application from its .desktop file.
+func classifyDesktopLaunch(p ProcessInfo) string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/environ", p.PID))
+	if err != nil {
+		return ""
+	}
+	return environAppType(data)
+}
+
+// environAppType classifies the contents of /proc/<pid>/environ.
+func environAppType(data []byte) string {
+	for _, kv := range bytes.Split(data, []byte{0}) {
+		if bytes.HasPrefix(kv, []byte("GIO_LAUNCHED_DESKTOP_FILE=")) || bytes.HasPrefix(kv, []byte("BAMF_DESKTOP_FILE_HINT=")) {
+			return "GUI App"
+		}
+	}
+	return ""
+}
+
+// classifyTerminal tells programs attached to a terminal from daemons that
+// were reparented to init.
+func classifyTerminal(p ProcessInfo) string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", p.PID))
+	if err != nil {
+		return ""
+	}
+	return statAppType(data, p.PPID)
+}
+
+// statAppType classifies the contents of /proc/<pid>/stat of a process
+// whose parent is ppid.
+func statAppType(data []byte, ppid int32) string {
+	// Fields after the parenthesized comm: state ppid pgrp session tty_nr
+	end := bytes.LastIndexByte(data, ')')
+	if end < 0 {
+		return ""
+	}
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) > 4 && fields[4] != "0" {
+		return "CLI"
+	}
+	if ppid == 1 {
+		return "Daemon"
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package scanner
+
+import "testing"
+
+func TestCgroupAppType(t *testing.T) {
+	tests := []struct {
+		name, cgroup, want string
+	}{
+		{"desktop app scope", "0::/user.slice/user-1000.slice/user@1000.service/app.slice/app-gnome-firefox-4242.scope\n", "GUI App"},
+		{"system service", "0::/system.slice/nginx.service\n", "Service"},
+		{"user manager", "0::/user.slice/user-1000.slice/user@1000.service\n", ""},
+		{"session scope", "0::/user.slice/user-1000.slice/session-2.scope\n", ""},
+		{"cgroup v1", "12:pids:/system.slice/sshd.service\n1:name=systemd:/system.slice/sshd.service\n", "Service"},
+		{"root", "0::/\n", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cgroupAppType([]byte(tt.cgroup)); got != tt.want {
+				t.Errorf("cgroupAppType(%q) = %q, want %q", tt.cgroup, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvironAppType(t *testing.T) {
+	tests := []struct {
+		name, environ, want string
+	}{
+		{"GIO launch", "HOME=/home/me\x00GIO_LAUNCHED_DESKTOP_FILE=/usr/share/applications/code.desktop\x00", "GUI App"},
+		{"BAMF hint", "BAMF_DESKTOP_FILE_HINT=/var/lib/snapd/desktop/applications/slack.desktop\x00", "GUI App"},
+		{"terminal", "HOME=/home/me\x00TERM=xterm-256color\x00", ""},
+		{"value only", "X=GIO_LAUNCHED_DESKTOP_FILE=\x00", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := environAppType([]byte(tt.environ)); got != tt.want {
+				t.Errorf("environAppType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatAppType(t *testing.T) {
+	tests := []struct {
+		name string
+		stat string
+		ppid int32
+		want string
+	}{
+		{"on a terminal", "4242 (vim) S 4000 4242 4000 34817 4242 4194304", 4000, "CLI"},
+		{"comm with spaces and parens", "4242 (tmux: server) (x) S 1 4242 4242 34816 -1", 1, "CLI"},
+		{"reparented daemon", "812 (redis-server) S 1 812 812 0 -1 4194560", 1, "Daemon"},
+		{"child without terminal", "900 (worker) S 812 812 812 0 -1", 812, ""},
+		{"truncated", "900 (worker) S 812", 812, ""},
+		{"garbage", "not a stat line", 1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statAppType([]byte(tt.stat), tt.ppid); got != tt.want {
+				t.Errorf("statAppType(%q, %d) = %q, want %q", tt.stat, tt.ppid, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,5 @@
+//go:build !linux && !darwin && !windows
+
+package scanner
+
+var platformClassifiers []Classifier
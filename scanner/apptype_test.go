@@ -0,0 +1,72 @@
+package scanner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDevToolClassifier(t *testing.T) {
+	tests := []struct {
+		name string
+		p    ProcessInfo
+		want string
+	}{
+		{"go by path", ProcessInfo{Command: "/usr/bin/go run main.go"}, ""},
+		{"go run with flags", ProcessInfo{Command: "go -C x go run ./cmd"}, "Dev Tool"},
+		{"apps checkout", ProcessInfo{Cwd: "/home/me/apps-web"}, "Dev Tool"},
+		{"other", ProcessInfo{Command: "/usr/sbin/nginx", Cwd: "/"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := devToolClassifier.Classify(tt.p); got != tt.want {
+				t.Errorf("Classify() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyKernel(t *testing.T) {
+	if got := classifyKernel(ProcessInfo{KernelThread: true}); got != "Kernel" {
+		t.Errorf("kernel thread = %q, want Kernel", got)
+	}
+	if got := classifyKernel(ProcessInfo{}); got != "" {
+		t.Errorf("process = %q, want none", got)
+	}
+}
+
+func TestAppTypeCache(t *testing.T) {
+	s := New(Options{})
+	calls := 0
+	RegisterClassifier(ClassifierFunc(func(p ProcessInfo) string {
+		if p.Name != "apptype-cache-test" {
+			return ""
+		}
+		calls++
+		return "Counted"
+	}))
+
+	started := time.Unix(1000, 0)
+	p := ProcessInfo{PID: -42, Name: "apptype-cache-test", Exe: "/bin/x", Started: started}
+	typ, e := s.appType(p, true)
+	if typ != "Counted" || calls != 1 {
+		t.Fatalf("first scan: type %q after %d calls", typ, calls)
+	}
+	s.rememberAppTypes(map[int32]appTypeEntry{p.PID: e}, false)
+
+	if typ, _ := s.appType(p, true); typ != "Counted" || calls != 1 {
+		t.Errorf("same process classified again: type %q after %d calls", typ, calls)
+	}
+
+	// A new process reusing the PID
+	p.Started = started.Add(time.Second)
+	s.appType(p, true)
+	if calls != 2 {
+		t.Errorf("reused PID not classified again: %d calls", calls)
+	}
+
+	// Exited processes are forgotten
+	s.rememberAppTypes(map[int32]appTypeEntry{}, false)
+	if _, ok := s.appPrev[p.PID]; ok {
+		t.Error("exited process still remembered")
+	}
+}
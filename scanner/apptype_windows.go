@@ -0,0 +1,46 @@
+package scanner
+
+import (
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// platformClassifiers on Windows: services started by the service control
+// manager, and installed applications.
+var platformClassifiers = []Classifier{
+	ClassifierFunc(classifyKernel),
+	hostClassifier{ClassifierFunc(classifyService)},
+	ClassifierFunc(classifyInstalled),
+}
+
+// classifyService spots processes started by the service control manager.
+func classifyService(p ProcessInfo) string {
+	parent, err := process.NewProcess(p.PPID)
+	if err != nil {
+		return ""
+	}
+	name, err := parent.Name()
+	if err != nil {
+		return ""
+	}
+	return parentAppType(name)
+}
+
+// parentAppType classifies a process by the name of its parent.
+func parentAppType(parent string) string {
+	if strings.EqualFold(parent, "services.exe") {
+		return "Service"
+	}
+	return ""
+}
+
+// classifyInstalled spots programs installed under Program Files or from
+// the Store.
+func classifyInstalled(p ProcessInfo) string {
+	exe := strings.ToLower(p.Exe)
+	if strings.Contains(exe, `\program files`) || strings.Contains(exe, `\windowsapps\`) {
+		return "GUI App"
+	}
+	return ""
+}
@@ -0,0 +1,41 @@
+package scanner
+
+import "testing"
+
+func TestParentAppType(t *testing.T) {
+	tests := []struct {
+		parent, want string
+	}{
+		{"services.exe", "Service"},
+		{"SERVICES.EXE", "Service"},
+		{"explorer.exe", ""},
+		{"svchost.exe", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.parent, func(t *testing.T) {
+			if got := parentAppType(tt.parent); got != tt.want {
+				t.Errorf("parentAppType(%q) = %q, want %q", tt.parent, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyInstalled(t *testing.T) {
+	tests := []struct {
+		name, exe, want string
+	}{
+		{"Program Files", `C:\Program Files\Mozilla Firefox\firefox.exe`, "GUI App"},
+		{"Program Files (x86)", `C:\Program Files (x86)\Steam\steam.exe`, "GUI App"},
+		{"Store app", `C:\Program Files\WindowsApps\Microsoft.WindowsTerminal_1.0\WindowsTerminal.exe`, "GUI App"},
+		{"user install", `C:\Users\me\AppData\Local\Programs\node.exe`, ""},
+		{"system", `C:\Windows\System32\svchost.exe`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyInstalled(ProcessInfo{Exe: tt.exe}); got != tt.want {
+				t.Errorf("classifyInstalled(%q) = %q, want %q", tt.exe, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package scanner
+
+import "time"
+
+// Cgroup is the control group of a process and the limits that apply to
+// it, the tightest of its own and its ancestors'.
+type Cgroup struct {
+	Path          string
+	MemoryLimit   uint64  // Bytes, 0 when unlimited
+	MemoryUsage   uint64  // Bytes charged to the cgroup, page cache included
+	CPUQuota      float64 // CPUs worth of time per period, 0 when unlimited
+	Throttled     uint64  // Periods the cgroup ran out of quota in
+	ThrottledTime time.Duration
+}
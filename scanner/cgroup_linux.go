@@ -0,0 +1,170 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// CgroupOf reads the cgroup of pid and its memory and CPU limits, from
+// the unified hierarchy (cgroup v2) or the memory and cpu controllers of
+// cgroup v1.
+func CgroupOf(pid int32) (Cgroup, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return Cgroup{}, err
+	}
+
+	var unified string
+	v1 := make(map[string]string)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		// hierarchy-ID:controller-list:path
+		parts := strings.SplitN(sc.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[0] == "0" && parts[1] == "" {
+			unified = parts[2]
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			v1[controller] = parts[2]
+		}
+	}
+
+	switch {
+	case v1["memory"] != "" || v1["cpu"] != "":
+		return cgroupV1(v1), nil
+	case unified != "":
+		return cgroupV2(unified), nil
+	}
+	return Cgroup{}, errors.New("no cgroup")
+}
+
+// cgroupV2 walks from the cgroup up to the root, keeping the tightest
+// memory.max and cpu.max.
+func cgroupV2(p string) Cgroup {
+	cg := Cgroup{Path: p}
+	dir := filepath.Join(cgroupRoot, p)
+	cg.MemoryUsage, _ = readUint(filepath.Join(dir, "memory.current"))
+	stat := readKeyValues(filepath.Join(dir, "cpu.stat"))
+	cg.Throttled = stat["nr_throttled"]
+	cg.ThrottledTime = time.Duration(stat["throttled_usec"]) * time.Microsecond
+
+	for ; ; p = path.Dir(p) {
+		dir := filepath.Join(cgroupRoot, p)
+		if limit, err := readUint(filepath.Join(dir, "memory.max")); err == nil {
+			cg.MemoryLimit = tighter(cg.MemoryLimit, limit)
+		}
+		if data, err := os.ReadFile(filepath.Join(dir, "cpu.max")); err == nil {
+			// "max 100000" or "50000 100000"
+			if f := strings.Fields(string(data)); len(f) == 2 {
+				cg.CPUQuota = tighterQuota(cg.CPUQuota, f[0], f[1])
+			}
+		}
+		if p == "/" || p == "." {
+			return cg
+		}
+	}
+}
+
+// cgroupV1 reads the memory and cpu controllers, walking up each of them.
+func cgroupV1(paths map[string]string) Cgroup {
+	cg := Cgroup{Path: paths["memory"]}
+	if cg.Path == "" {
+		cg.Path = paths["cpu"]
+	}
+	memDir := filepath.Join(cgroupRoot, "memory")
+	cpuDir := filepath.Join(cgroupRoot, "cpu")
+	if _, err := os.Stat(cpuDir); err != nil {
+		cpuDir = filepath.Join(cgroupRoot, "cpu,cpuacct")
+	}
+
+	if p := paths["memory"]; p != "" {
+		cg.MemoryUsage, _ = readUint(filepath.Join(memDir, p, "memory.usage_in_bytes"))
+		for ; ; p = path.Dir(p) {
+			// Unlimited reads as a page-rounded maximum
+			if limit, err := readUint(filepath.Join(memDir, p, "memory.limit_in_bytes")); err == nil && limit < 1<<62 {
+				cg.MemoryLimit = tighter(cg.MemoryLimit, limit)
+			}
+			if p == "/" || p == "." {
+				break
+			}
+		}
+	}
+	if p := paths["cpu"]; p != "" {
+		stat := readKeyValues(filepath.Join(cpuDir, p, "cpu.stat"))
+		cg.Throttled = stat["nr_throttled"]
+		cg.ThrottledTime = time.Duration(stat["throttled_time"])
+		for ; ; p = path.Dir(p) {
+			quota, qerr := os.ReadFile(filepath.Join(cpuDir, p, "cpu.cfs_quota_us"))
+			period, perr := os.ReadFile(filepath.Join(cpuDir, p, "cpu.cfs_period_us"))
+			if qerr == nil && perr == nil {
+				cg.CPUQuota = tighterQuota(cg.CPUQuota, strings.TrimSpace(string(quota)), strings.TrimSpace(string(period)))
+			}
+			if p == "/" || p == "." {
+				break
+			}
+		}
+	}
+	return cg
+}
+
+// tighter returns the smaller of two limits, 0 meaning none.
+func tighter(a, b uint64) uint64 {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// tighterQuota folds a quota and period pair into the smaller CPU quota.
+// "max" and -1 mean no quota.
+func tighterQuota(cpus float64, quota, period string) float64 {
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil || q <= 0 {
+		return cpus
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil || p <= 0 {
+		return cpus
+	}
+	if c := q / p; cpus == 0 || c < cpus {
+		return c
+	}
+	return cpus
+}
+
+// readUint reads a file holding a single number. "max" is an error.
+func readUint(name string) (uint64, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// readKeyValues reads a flat keyed file such as cpu.stat.
+func readKeyValues(name string) map[string]uint64 {
+	values := make(map[string]uint64)
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return values
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if f := strings.Fields(line); len(f) == 2 {
+			values[f[0]], _ = strconv.ParseUint(f[1], 10, 64)
+		}
+	}
+	return values
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package scanner
+
+import "errors"
+
+// CgroupOf is only supported on Linux.
+func CgroupOf(pid int32) (Cgroup, error) {
+	return Cgroup{}, errors.New("cgroups are Linux only")
+}
@@ -0,0 +1,51 @@
+package scanner
+
+import (
+	"maps"
+	"time"
+)
+
+type cpuSample struct {
+	started time.Time // Tells a reused PID from the process seen before
+	total   float64   // Cumulative user and system seconds
+	at      time.Time
+}
+
+// cpuPercent returns the CPU p used since the previous scan, in percent of
+// one core, and its cumulative time to remember for the next one. A process
+// seen for the first time gets its average since it started.
+func (s *Scanner) cpuPercent(p Process, started, now time.Time) (pct float64, curr cpuSample, ok bool) {
+	total, err := p.CPUTime()
+	if err != nil {
+		return 0, cpuSample{}, false
+	}
+	curr = cpuSample{started: started, total: total, at: now}
+
+	s.cpuMu.Lock()
+	prev, seen := s.cpuPrev[p.PID()]
+	s.cpuMu.Unlock()
+
+	since, used := started, total
+	if seen && prev.started.Equal(started) && total >= prev.total {
+		since, used = prev.at, total-prev.total
+	}
+	if since.IsZero() {
+		return 0, curr, true
+	}
+	if wall := now.Sub(since).Seconds(); wall > 0 {
+		pct = used / wall * 100
+	}
+	return pct, curr, true
+}
+
+// rememberCPU replaces the previous scan's CPU times, dropping exited
+// processes. A narrow scan only saw some processes and adds to them.
+func (s *Scanner) rememberCPU(samples map[int32]cpuSample, narrow bool) {
+	s.cpuMu.Lock()
+	if narrow {
+		maps.Copy(s.cpuPrev, samples)
+	} else {
+		s.cpuPrev = samples
+	}
+	s.cpuMu.Unlock()
+}
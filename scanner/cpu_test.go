@@ -0,0 +1,41 @@
+package scanner
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCPUPercent(t *testing.T) {
+	s := New(Options{})
+	started := time.Unix(1000, 0)
+	now := started.Add(100 * time.Second)
+
+	// First sight: the average since the process started
+	p := fakeProcess{pid: 7, cpu: 50}
+	pct, sample, ok := s.cpuPercent(p, started, now)
+	if !ok || !near(pct, 50) {
+		t.Fatalf("first scan = %v, %v, want 50%%", pct, ok)
+	}
+	s.rememberCPU(map[int32]cpuSample{7: sample}, false)
+
+	// Then the use since the previous scan: 3s of CPU over 2s is 1.5 cores
+	p.cpu = 53
+	now = now.Add(2 * time.Second)
+	pct, sample, _ = s.cpuPercent(p, started, now)
+	if !near(pct, 150) {
+		t.Errorf("second scan = %v, want 150%%", pct)
+	}
+	s.rememberCPU(map[int32]cpuSample{7: sample}, false)
+
+	// A new process reusing the PID starts over
+	p.cpu = 1
+	pct, _, _ = s.cpuPercent(p, now.Add(-time.Second), now.Add(time.Second))
+	if !near(pct, 50) {
+		t.Errorf("reused PID = %v, want 50%%", pct)
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,73 @@
+package scanner
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Debug servers that can be told apart by their command line flags.
+const (
+	NodeInspector = "node inspector"
+	Delve         = "delve"
+	Debugpy       = "debugpy"
+	JDWP          = "jdwp"
+)
+
+var (
+	inspectRe     = regexp.MustCompile(`--inspect(?:-brk|-wait|-port)?(?:=(\S+))?(?:\s|$)`)
+	inspectPortRe = regexp.MustCompile(`--inspect-port=(\S+)`)
+	listenRe      = regexp.MustCompile(`--listen[= ](\S+)`)
+	jdwpRe        = regexp.MustCompile(`-agentlib:jdwp=\S*address=([^,\s]+)`)
+)
+
+// DebugServerOn names the debugger listening on port of a process with this
+// name and command line: the node inspector (--inspect, 9229 by default),
+// a headless delve, debugpy (--listen, 5678 by default) or a JVM's JDWP
+// agent. It returns "" for any other listener.
+func DebugServerOn(name, cmdline string, port uint32) string {
+	switch {
+	case name == "dlv" || strings.HasPrefix(name, "dlv."):
+		// Delve only listens for clients, the debuggee is its child
+		if strings.Contains(cmdline, "--headless") || strings.Contains(cmdline, "--listen") || strings.Contains(cmdline, " dap") {
+			return Delve
+		}
+	case strings.Contains(cmdline, "debugpy"):
+		want := uint32(5678)
+		if m := listenRe.FindStringSubmatch(cmdline); m != nil {
+			want = debugPort(m[1], want)
+		}
+		if port == want {
+			return Debugpy
+		}
+	case strings.Contains(cmdline, "-agentlib:jdwp="):
+		if m := jdwpRe.FindStringSubmatch(cmdline); m != nil && port == debugPort(m[1], 0) {
+			return JDWP
+		}
+	default:
+		m := inspectRe.FindStringSubmatch(cmdline)
+		if m == nil {
+			return ""
+		}
+		want := debugPort(m[1], 9229)
+		if m := inspectPortRe.FindStringSubmatch(cmdline); m != nil {
+			want = debugPort(m[1], want)
+		}
+		if port == want {
+			return NodeInspector
+		}
+	}
+	return ""
+}
+
+// debugPort parses the port of a [host:]port debugger address.
+func debugPort(addr string, fallback uint32) uint32 {
+	if i := strings.LastIndex(addr, ":"); i >= 0 {
+		addr = addr[i+1:]
+	}
+	port, err := strconv.ParseUint(addr, 10, 16)
+	if err != nil {
+		return fallback
+	}
+	return uint32(port)
+}
@@ -0,0 +1,120 @@
+package scanner
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+const (
+	sockDestroy        = 21 // SOCK_DESTROY netlink message type
+	inetDiagReqV2Len   = 56 // sizeof(struct inet_diag_req_v2)
+	inetDiagNoCookie   = ^uint32(0)
+	inetDiagAllStates  = ^uint32(0)
+	nlmsgHdrLen        = syscall.NLMSG_HDRLEN
+	nlmsgErrPayloadLen = 4
+)
+
+// DestroyConnection forcibly closes a single TCP socket through the
+// sock_diag netlink interface, like `ss -K`. The owning process keeps
+// running. Needs CAP_NET_ADMIN and a kernel built with CONFIG_INET_DIAG_DESTROY.
+func DestroyConnection(c Connection) error {
+	if c.Protocol != "tcp" && c.Protocol != "tcp6" {
+		return fmt.Errorf("only TCP connections can be destroyed, got %s", c.Protocol)
+	}
+	if c.Status == "LISTEN" {
+		return errors.New("refusing to destroy a listening socket, kill the process instead")
+	}
+
+	req, err := destroyRequest(c)
+	if err != nil {
+		return err
+	}
+
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_INET_DIAG)
+	if err != nil {
+		return fmt.Errorf("failed to open netlink socket: %w", err)
+	}
+	defer syscall.Close(fd)
+
+	if err := syscall.Sendto(fd, req, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return fmt.Errorf("failed to send destroy request: %w", err)
+	}
+
+	buf := make([]byte, 4096)
+	n, _, err := syscall.Recvfrom(fd, buf, 0)
+	if err != nil {
+		return fmt.Errorf("failed to read destroy reply: %w", err)
+	}
+	msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+	if err != nil {
+		return fmt.Errorf("failed to parse destroy reply: %w", err)
+	}
+	for _, msg := range msgs {
+		if msg.Header.Type != syscall.NLMSG_ERROR || len(msg.Data) < nlmsgErrPayloadLen {
+			continue
+		}
+		if errno := -int32(binary.NativeEndian.Uint32(msg.Data)); errno != 0 {
+			return fmt.Errorf("kernel refused to destroy socket: %w", syscall.Errno(errno))
+		}
+		return nil
+	}
+	return errors.New("no acknowledgement from kernel")
+}
+
+// destroyRequest builds an nlmsghdr followed by an inet_diag_req_v2
+// identifying the socket by its local and remote address.
+func destroyRequest(c Connection) ([]byte, error) {
+	family := byte(syscall.AF_INET)
+	if c.Protocol == "tcp6" {
+		family = syscall.AF_INET6
+	}
+
+	src, err := diagAddr(c.LocalAddr, family)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := diagAddr(c.RemoteAddr, family)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, nlmsgHdrLen+inetDiagReqV2Len)
+	ne := binary.NativeEndian
+
+	// struct nlmsghdr
+	ne.PutUint32(b[0:], uint32(len(b)))
+	ne.PutUint16(b[4:], sockDestroy)
+	ne.PutUint16(b[6:], syscall.NLM_F_REQUEST|syscall.NLM_F_ACK)
+	ne.PutUint32(b[8:], 1) // seq
+
+	// struct inet_diag_req_v2
+	r := b[nlmsgHdrLen:]
+	r[0] = family
+	r[1] = syscall.IPPROTO_TCP
+	ne.PutUint32(r[4:], inetDiagAllStates)
+
+	// struct inet_diag_sockid
+	id := r[8:]
+	binary.BigEndian.PutUint16(id[0:], uint16(c.Port))
+	binary.BigEndian.PutUint16(id[2:], uint16(c.RemotePort))
+	copy(id[4:20], src)
+	copy(id[20:36], dst)
+	ne.PutUint32(id[40:], inetDiagNoCookie)
+	ne.PutUint32(id[44:], inetDiagNoCookie)
+
+	return b, nil
+}
+
+func diagAddr(s string, family byte) ([]byte, error) {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid address %q", s)
+	}
+	if family == syscall.AF_INET {
+		return ip.To4(), nil
+	}
+	return ip.To16(), nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package scanner
+
+import "errors"
+
+// DestroyConnection is only supported on Linux.
+func DestroyConnection(c Connection) error {
+	return errors.New("destroying single connections is only supported on Linux")
+}
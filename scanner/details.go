@@ -0,0 +1,103 @@
+package scanner
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// Details are the parts of a process a scan skips because they are rarely
+// needed: its environment and resource limits.
+type Details struct {
+	Env    []string // KEY=value, with secrets redacted
+	Limits []Limit
+}
+
+// Limit is a resource limit, "unlimited" or a number in the resource's
+// unit.
+type Limit struct {
+	Resource string
+	Soft     string
+	Hard     string
+}
+
+var limitNames = map[int32]string{
+	process.RLIMIT_CPU:        "cpu",
+	process.RLIMIT_FSIZE:      "fsize",
+	process.RLIMIT_DATA:       "data",
+	process.RLIMIT_STACK:      "stack",
+	process.RLIMIT_CORE:       "core",
+	process.RLIMIT_RSS:        "rss",
+	process.RLIMIT_NPROC:      "nproc",
+	process.RLIMIT_NOFILE:     "nofile",
+	process.RLIMIT_MEMLOCK:    "memlock",
+	process.RLIMIT_AS:         "as",
+	process.RLIMIT_LOCKS:      "locks",
+	process.RLIMIT_SIGPENDING: "sigpending",
+	process.RLIMIT_MSGQUEUE:   "msgqueue",
+	process.RLIMIT_NICE:       "nice",
+	process.RLIMIT_RTPRIO:     "rtprio",
+	process.RLIMIT_RTTIME:     "rttime",
+}
+
+// secretKeys are substrings of environment variable names whose values
+// DetailsOf redacts.
+var secretKeys = []string{"TOKEN", "SECRET", "PASSWORD", "PASSWD", "KEY", "CREDENTIAL", "AUTH", "COOKIE", "SESSION"}
+
+// DetailsOf reads the environment and limits of pid on this machine. Parts
+// that can't be read, for lack of permission or platform support, are
+// left empty.
+func DetailsOf(pid int32) (Details, error) {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return Details{}, err
+	}
+	var d Details
+	if env, err := p.Environ(); err == nil {
+		for _, kv := range env {
+			if kv == "" {
+				continue
+			}
+			d.Env = append(d.Env, redact(kv))
+		}
+	}
+	if limits, err := p.Rlimit(); err == nil {
+		for _, l := range limits {
+			name, ok := limitNames[l.Resource]
+			if !ok {
+				name = strconv.Itoa(int(l.Resource))
+			}
+			d.Limits = append(d.Limits, Limit{Resource: name, Soft: limitValue(l.Soft), Hard: limitValue(l.Hard)})
+		}
+	}
+	return d, nil
+}
+
+// redact hides the value of a KEY=value pair whose key looks secret, and
+// the password of any URL in other values (DATABASE_URL and the like).
+func redact(kv string) string {
+	key, value, _ := strings.Cut(kv, "=")
+	upper := strings.ToUpper(key)
+	for _, s := range secretKeys {
+		if value != "" && strings.Contains(upper, s) {
+			return key + "=<redacted>"
+		}
+	}
+	if !urlPassword.MatchString(value) {
+		return kv
+	}
+	return key + "=" + urlPassword.ReplaceAllString(value, "${1}<redacted>@")
+}
+
+// urlPassword matches the user:password@ part of a URL, keeping the user.
+var urlPassword = regexp.MustCompile(`(://[^/:@\s]*:)[^/@\s]*@`)
+
+func limitValue(v uint64) string {
+	if v == math.MaxUint64 || v == math.MaxInt64 {
+		return "unlimited"
+	}
+	return strconv.FormatUint(v, 10)
+}
@@ -0,0 +1,24 @@
+package scanner
+
+import "testing"
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		kv   string
+		want string
+	}{
+		{"PATH=/usr/bin", "PATH=/usr/bin"},
+		{"GITHUB_TOKEN=ghp_abc", "GITHUB_TOKEN=<redacted>"},
+		{"db_password=hunter2", "db_password=<redacted>"},
+		{"EMPTY_SECRET=", "EMPTY_SECRET="},
+		{"DATABASE_URL=postgres://app:hunter2@db:5432/app", "DATABASE_URL=postgres://app:<redacted>@db:5432/app"},
+		{"PROXY=http://user@proxy:3128", "PROXY=http://user@proxy:3128"},
+		{"HOME_URL=https://example.com/a:b@c", "HOME_URL=https://example.com/a:b@c"},
+		{"NO_EQUALS", "NO_EQUALS"},
+	}
+	for _, tt := range tests {
+		if got := redact(tt.kv); got != tt.want {
+			t.Errorf("redact(%q) = %q, want %q", tt.kv, got, tt.want)
+		}
+	}
+}
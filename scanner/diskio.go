@@ -0,0 +1,39 @@
+package scanner
+
+import "maps"
+
+type ioSample struct {
+	read, write uint64
+}
+
+// diskIO returns the bytes p read and wrote since the previous scan, and
+// its cumulative counters to remember for the next one.
+func (s *Scanner) diskIO(p Process) (read, write uint64, curr ioSample, ok bool) {
+	r, w, err := p.IO()
+	if err != nil {
+		return 0, 0, ioSample{}, false
+	}
+	curr = ioSample{read: r, write: w}
+
+	s.ioMu.Lock()
+	prev, seen := s.ioPrev[p.PID()]
+	s.ioMu.Unlock()
+
+	// A lower counter means the PID was reused by a new process
+	if seen && curr.read >= prev.read && curr.write >= prev.write {
+		read, write = curr.read-prev.read, curr.write-prev.write
+	}
+	return read, write, curr, true
+}
+
+// rememberIO replaces the previous scan's counters, dropping exited
+// processes. A narrow scan only saw some processes and adds to them.
+func (s *Scanner) rememberIO(samples map[int32]ioSample, narrow bool) {
+	s.ioMu.Lock()
+	if narrow {
+		maps.Copy(s.ioPrev, samples)
+	} else {
+		s.ioPrev = samples
+	}
+	s.ioMu.Unlock()
+}
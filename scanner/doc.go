@@ -0,0 +1,25 @@
+// Package scanner lists the processes on this machine together with their
+// sockets, users, resource usage and where they came from (runtime, process
+// manager, service unit, git checkout).
+//
+// It has no dependency on the port-monitor TUI and can be embedded in other
+// programs:
+//
+//	s := scanner.New(scanner.Options{ListenOnly: true})
+//	procs, err := s.Scan(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	for _, p := range procs {
+//		for _, c := range p.Connections {
+//			if c.Status == "LISTEN" {
+//				fmt.Println(c.Port, p.Name, p.PID)
+//			}
+//		}
+//	}
+//
+// Fields that couldn't be read for lack of permission are listed in
+// ProcessInfo.Denied rather than reported as errors; run as root to fill
+// them in. ScanPartial also tells what else a scan missed, such as every
+// socket when listing them failed. Diff turns two snapshots into Events.
+package scanner
@@ -0,0 +1,64 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// QuitProcess sends SIGQUIT, which makes Go and Java processes print the
+// stacks of all goroutines/threads. It returns where the dump goes: the
+// file, terminal or pipe behind the process's stderr (stdout for Java),
+// or "" when that can't be read. Go programs exit after the dump, Java
+// keeps running.
+func QuitProcess(p ProcessInfo) (string, error) {
+	if runtime.GOOS == "windows" {
+		return "", fmt.Errorf("SIGQUIT is not supported on Windows")
+	}
+	proc, err := process.NewProcess(p.PID)
+	if err != nil {
+		return "", err
+	}
+	fd := 2
+	if p.Runtime == "java" {
+		fd = 1
+	}
+	target := fdTarget(p.PID, fd)
+	if err := proc.SendSignal(syscall.SIGQUIT); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// CoreDumpCommand builds a gcore invocation writing a core file of pid to
+// dir without stopping the process for longer than the dump takes. It
+// returns the command and the file it creates. gcore needs the terminal
+// when sudo asks for a password.
+func CoreDumpCommand(pid int32, dir string, asRoot bool) (*exec.Cmd, string, error) {
+	if runtime.GOOS == "windows" {
+		return nil, "", fmt.Errorf("core dumps are not supported on Windows, try procdump")
+	}
+	if _, err := exec.LookPath("gcore"); err != nil {
+		return nil, "", fmt.Errorf("core dumps need gcore (part of gdb on Linux): %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, "", fmt.Errorf("failed to create dump dir: %w", err)
+	}
+
+	prefix := filepath.Join(dir, fmt.Sprintf("core-%s", time.Now().Format("20060102-150405")))
+	file := prefix
+	if runtime.GOOS == "linux" {
+		file = fmt.Sprintf("%s.%d", prefix, pid) // gdb's gcore appends the PID
+	}
+	args := []string{"gcore", "-o", prefix, fmt.Sprint(pid)}
+	if asRoot && os.Geteuid() != 0 {
+		args = append([]string{"sudo"}, args...)
+	}
+	return exec.Command(args[0], args[1:]...), file, nil
+}
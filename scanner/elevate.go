@@ -0,0 +1,43 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ElevatedKillCommand builds a command that kills pids with administrator
+// rights after asking the OS to authenticate the user: the admin prompt
+// (password or Touch ID) via osascript on macOS, polkit via pkexec on
+// Linux, UAC on Windows. It needs the terminal while it runs.
+func ElevatedKillCommand(pids []int32) (*exec.Cmd, error) {
+	if len(pids) == 0 {
+		return nil, errors.New("nothing to kill")
+	}
+	ids := make([]string, len(pids))
+	for i, pid := range pids {
+		ids[i] = fmt.Sprint(pid)
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf(`do shell script "kill -9 %s" with administrator privileges`, strings.Join(ids, " "))
+		return exec.Command("osascript", "-e", script), nil
+	case "linux":
+		if _, err := exec.LookPath("pkexec"); err != nil {
+			return nil, errors.New("pkexec (polkit) is not installed")
+		}
+		return exec.Command("pkexec", append([]string{"kill", "-9"}, ids...)...), nil
+	case "windows":
+		args := make([]string, 0, 2*len(ids)+1)
+		args = append(args, "'/F'")
+		for _, id := range ids {
+			args = append(args, "'/PID'", "'"+id+"'")
+		}
+		ps := fmt.Sprintf("Start-Process taskkill -Verb RunAs -Wait -WindowStyle Hidden -ArgumentList %s", strings.Join(args, ","))
+		return exec.Command("powershell", "-NoProfile", "-Command", ps), nil
+	}
+	return nil, fmt.Errorf("elevated kills are not supported on %s", runtime.GOOS)
+}
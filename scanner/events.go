@@ -0,0 +1,143 @@
+package scanner
+
+import (
+	"fmt"
+	"time"
+)
+
+type EventType string
+
+const (
+	ProcessAdded   EventType = "process_added"
+	ProcessRemoved EventType = "process_removed"
+	ProcessUpdated EventType = "process_updated"
+	PortOpened     EventType = "port_opened"
+	PortClosed     EventType = "port_closed"
+	ProcessKilled  EventType = "process_killed"
+)
+
+// Event is a single change between two scans.
+type Event struct {
+	Type     EventType `json:"type"`
+	Time     time.Time `json:"time"`
+	PID      int32     `json:"pid"`
+	Name     string    `json:"name"`
+	Port     uint32    `json:"port,omitempty"`
+	Protocol string    `json:"protocol,omitempty"`
+
+	// Process is the new state of an added or updated process. Only set
+	// on events from Watch.
+	Process *ProcessInfo `json:"process,omitempty"`
+}
+
+func (e Event) String() string {
+	switch e.Type {
+	case PortOpened:
+		return fmt.Sprintf("%s (pid %d) opened %s port %d", e.Name, e.PID, e.Protocol, e.Port)
+	case PortClosed:
+		return fmt.Sprintf("%s (pid %d) closed %s port %d", e.Name, e.PID, e.Protocol, e.Port)
+	case ProcessAdded:
+		return fmt.Sprintf("%s (pid %d) started", e.Name, e.PID)
+	case ProcessRemoved:
+		return fmt.Sprintf("%s (pid %d) exited", e.Name, e.PID)
+	case ProcessUpdated:
+		return fmt.Sprintf("%s (pid %d) changed", e.Name, e.PID)
+	case ProcessKilled:
+		return fmt.Sprintf("%s (pid %d) killed", e.Name, e.PID)
+	}
+	return string(e.Type)
+}
+
+type listenKey struct {
+	pid      int32
+	port     uint32
+	protocol string
+}
+
+// Diff lists processes that appeared, disappeared or changed identity
+// (see updated) between two scans and listening ports that were opened or
+// closed.
+func Diff(prev, curr []ProcessInfo, now time.Time) []Event {
+	var events []Event
+
+	prevProcs := make(map[int32]ProcessInfo, len(prev))
+	for _, p := range prev {
+		prevProcs[p.PID] = p
+	}
+	currProcs := make(map[int32]ProcessInfo, len(curr))
+	for _, p := range curr {
+		currProcs[p.PID] = p
+	}
+
+	for _, p := range curr {
+		if old, ok := prevProcs[p.PID]; !ok {
+			events = append(events, Event{Type: ProcessAdded, Time: now, PID: p.PID, Name: p.Name})
+		} else if updated(old, p) {
+			events = append(events, Event{Type: ProcessUpdated, Time: now, PID: p.PID, Name: p.Name})
+		}
+	}
+	for _, p := range prev {
+		if _, ok := currProcs[p.PID]; !ok {
+			events = append(events, Event{Type: ProcessRemoved, Time: now, PID: p.PID, Name: p.Name})
+		}
+	}
+
+	prevPorts := listening(prev)
+	currPorts := listening(curr)
+	for _, p := range curr {
+		for _, k := range listeningKeys(p) {
+			if _, ok := prevPorts[k]; !ok {
+				events = append(events, Event{Type: PortOpened, Time: now, PID: p.PID, Name: p.Name, Port: k.port, Protocol: k.protocol})
+			}
+		}
+	}
+	for _, p := range prev {
+		for _, k := range listeningKeys(p) {
+			if _, ok := currPorts[k]; !ok {
+				events = append(events, Event{Type: PortClosed, Time: now, PID: p.PID, Name: p.Name, Port: k.port, Protocol: k.protocol})
+			}
+		}
+	}
+
+	return events
+}
+
+// updated reports whether a process changed what it is rather than just its
+// usage: an exec, a chdir, or a change of user or service unit. Kernel
+// worker threads rename themselves all the time and don't count.
+func updated(old, curr ProcessInfo) bool {
+	if curr.KernelThread {
+		return false
+	}
+	return old.Name != curr.Name || old.Command != curr.Command || old.Cwd != curr.Cwd ||
+		old.User != curr.User || old.Unit != curr.Unit
+}
+
+func listening(procs []ProcessInfo) map[listenKey]struct{} {
+	keys := make(map[listenKey]struct{})
+	for _, p := range procs {
+		for _, k := range listeningKeys(p) {
+			keys[k] = struct{}{}
+		}
+	}
+	return keys
+}
+
+// listeningKeys returns the distinct sockets a process accepts traffic on:
+// TCP listeners and bound UDP sockets without a peer.
+func listeningKeys(p ProcessInfo) []listenKey {
+	seen := make(map[listenKey]struct{})
+	var keys []listenKey
+	for _, c := range p.Connections {
+		if !c.IsListener() {
+			continue
+		}
+		k := listenKey{p.PID, c.Port, c.Protocol}
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,45 @@
+package scanner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiffPortEvents(t *testing.T) {
+	base := ProcessInfo{PID: 10, Name: "svc"}
+	tests := []struct {
+		name string
+		conn Connection
+		want bool
+	}{
+		{"tcp listener", Connection{Protocol: "tcp", LocalAddr: "0.0.0.0", Port: 8080, Status: "LISTEN"}, true},
+		{"udp with 0.0.0.0 peer", Connection{Protocol: "udp", LocalAddr: "127.0.0.1", Port: 45300, RemoteAddr: "0.0.0.0", Status: "NONE"}, true},
+		{"udp6 with :: peer", Connection{Protocol: "udp6", LocalAddr: "::", Port: 5353, RemoteAddr: "::", Status: "NONE"}, true},
+		{"udp without peer", Connection{Protocol: "udp", LocalAddr: "0.0.0.0", Port: 68}, true},
+		{"connected udp", Connection{Protocol: "udp", LocalAddr: "10.0.0.2", Port: 40000, RemoteAddr: "1.1.1.1", RemotePort: 53, Status: "NONE"}, false},
+		{"tcp client", Connection{Protocol: "tcp", LocalAddr: "10.0.0.2", Port: 40001, RemoteAddr: "1.1.1.1", RemotePort: 443, Status: "ESTABLISHED"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := []ProcessInfo{base}
+			curr := base
+			curr.Connections = []Connection{tt.conn}
+			now := time.Now()
+
+			opened := hasEvent(Diff(prev, []ProcessInfo{curr}, now), PortOpened, tt.conn.Port)
+			closed := hasEvent(Diff([]ProcessInfo{curr}, prev, now), PortClosed, tt.conn.Port)
+			if opened != tt.want || closed != tt.want {
+				t.Errorf("opened %v, closed %v, want %v", opened, closed, tt.want)
+			}
+		})
+	}
+}
+
+func hasEvent(events []Event, typ EventType, port uint32) bool {
+	for _, e := range events {
+		if e.Type == typ && e.Port == port {
+			return true
+		}
+	}
+	return false
+}
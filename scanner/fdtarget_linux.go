@@ -0,0 +1,16 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+)
+
+// fdTarget returns what file descriptor fd of pid points to: a path,
+// a terminal, or pipe:[inode] / socket:[inode].
+func fdTarget(pid int32, fd int) string {
+	target, err := os.Readlink(fmt.Sprintf("/proc/%d/fd/%d", pid, fd))
+	if err != nil {
+		return ""
+	}
+	return target
+}
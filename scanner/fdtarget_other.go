@@ -0,0 +1,26 @@
+//go:build !linux
+
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// fdTarget returns what file descriptor fd of pid points to, from lsof.
+func fdTarget(pid int32, fd int) string {
+	out, err := exec.Command("lsof", "-a", "-p", fmt.Sprint(pid), "-d", fmt.Sprint(fd), "-Fn").Output()
+	if err != nil {
+		return ""
+	}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		if name, ok := strings.CutPrefix(sc.Text(), "n"); ok {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// gitRepo finds the git work tree containing dir and returns its name and
+// checked out branch (or short commit when detached). It reads .git
+// directly instead of running git, since it's called for every process
+// with a connection on each scan.
+func gitRepo(dir string) (name, branch string) {
+	for dir != "" {
+		dotGit := filepath.Join(dir, ".git")
+		info, err := os.Stat(dotGit)
+		if err == nil {
+			gitDir := dotGit
+			if !info.IsDir() {
+				// Worktrees and submodules: "gitdir: /path/to/repo/.git/worktrees/x"
+				data, err := os.ReadFile(dotGit)
+				if err != nil {
+					return "", ""
+				}
+				gitDir = strings.TrimSpace(strings.TrimPrefix(string(data), "gitdir:"))
+				if !filepath.IsAbs(gitDir) {
+					gitDir = filepath.Join(dir, gitDir)
+				}
+			}
+			return filepath.Base(dir), headBranch(gitDir)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return "", ""
+}
+
+func headBranch(gitDir string) string {
+	data, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return ""
+	}
+	head := strings.TrimSpace(string(data))
+	if ref, ok := strings.CutPrefix(head, "ref: "); ok {
+		return strings.TrimPrefix(ref, "refs/heads/")
+	}
+	if len(head) > 7 {
+		head = head[:7]
+	}
+	return head
+}
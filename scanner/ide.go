@@ -0,0 +1,60 @@
+package scanner
+
+import "strings"
+
+// IDEHelper is a process of an IDE's remote development setup.
+type IDEHelper struct {
+	IDE string // VS Code, Cursor or JetBrains
+
+	// Server is the backend running on the remote machine. The other side
+	// is the desktop client, whose loopback listeners are the ports it
+	// forwards from there.
+	Server bool
+}
+
+// ideServers are command line fragments of remote development backends.
+var ideServers = []struct{ fragment, ide string }{
+	{".vscode-server", "VS Code"},
+	{"vscode-remote-containers", "VS Code"},
+	{".cursor-server", "Cursor"},
+	{"remote-dev-server", "JetBrains"},
+	{"cwmhostnolobby", "JetBrains"},
+}
+
+// ideClients are command line fragments of the desktop clients, which
+// forward the ports.
+var ideClients = []struct{ fragment, ide string }{
+	{"visual studio code.app", "VS Code"},
+	{"/code/code", "VS Code"},
+	{"microsoft vs code", "VS Code"},
+	{"cursor.app", "Cursor"},
+	{"jetbrainsgateway", "JetBrains"},
+	{"jetbrains gateway", "JetBrains"},
+	{"jetbrains client", "JetBrains"},
+	{"jetbrainsclient", "JetBrains"},
+	{"jetbrains_client", "JetBrains"},
+}
+
+// IDEHelperOf recognizes VS Code Remote and JetBrains Gateway processes
+// from their name and command line, which otherwise pass for plain node or
+// java ones.
+func IDEHelperOf(name, cmdline string) (IDEHelper, bool) {
+	lower := strings.ToLower(cmdline)
+	for _, s := range ideServers {
+		if strings.Contains(lower, s.fragment) {
+			return IDEHelper{IDE: s.ide, Server: true}, true
+		}
+	}
+	for _, c := range ideClients {
+		if strings.Contains(lower, c.fragment) {
+			return IDEHelper{IDE: c.ide}, true
+		}
+	}
+	switch {
+	case strings.HasPrefix(name, "Code Helper"), name == "code", name == "Code.exe":
+		return IDEHelper{IDE: "VS Code"}, true
+	case strings.HasPrefix(name, "Cursor Helper"), name == "cursor", name == "Cursor.exe":
+		return IDEHelper{IDE: "Cursor"}, true
+	}
+	return IDEHelper{}, false
+}
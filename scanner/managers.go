@@ -0,0 +1,128 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// maxParentDepth bounds the parent chain walk.
+const maxParentDepth = 16
+
+var containerIDRe = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// containerServices caches `docker inspect` lookups, container ID -> "manager:service".
+var containerServices sync.Map
+
+// detectManagers fills Manager and Service for processes holding sockets
+// that were launched by a process manager (foreman, overmind, hivemind,
+// pm2, docker compose), found by walking the parent chain.
+func detectManagers(procs []ProcessInfo) {
+	byPID := make(map[int32]*ProcessInfo, len(procs))
+	for i := range procs {
+		byPID[procs[i].PID] = &procs[i]
+	}
+
+	for i := range procs {
+		p := &procs[i]
+		if len(p.Connections) == 0 {
+			continue
+		}
+
+		manager := ""
+		parent := byPID[p.PPID]
+		for depth := 0; parent != nil && depth < maxParentDepth; depth++ {
+			if manager = managerOf(parent); manager != "" {
+				break
+			}
+			parent = byPID[parent.PPID]
+		}
+
+		switch manager {
+		case "foreman", "overmind", "hivemind":
+			p.Manager = manager
+			p.Service = environ(p.PID, "PS")
+		case "pm2":
+			p.Manager = manager
+			p.Service = environ(p.PID, "name")
+		case "container":
+			p.Manager, p.Service = containerService(p.PID)
+		default:
+			// Overmind runs processes inside tmux, which detaches from it
+			if ps := environ(p.PID, "PS"); ps != "" && environ(p.PID, "OVERMIND_SOCKET") != "" {
+				p.Manager, p.Service = "overmind", ps
+			}
+		}
+	}
+}
+
+// managerOf names the process manager a process is, if any.
+func managerOf(p *ProcessInfo) string {
+	cmd := p.Command
+	switch {
+	case p.Name == "overmind" || p.Name == "hivemind" || p.Name == "foreman":
+		return p.Name
+	case strings.Contains(cmd, "/foreman start") || strings.HasPrefix(cmd, "foreman start"):
+		return "foreman"
+	case strings.HasPrefix(p.Name, "PM2") || strings.HasPrefix(cmd, "PM2 "):
+		return "pm2"
+	case strings.HasPrefix(p.Name, "containerd-shim"):
+		return "container"
+	}
+	return ""
+}
+
+func environ(pid int32, key string) string {
+	proc, err := process.NewProcess(pid)
+	if err != nil {
+		return ""
+	}
+	env, err := proc.Environ()
+	if err != nil {
+		return ""
+	}
+	prefix := key + "="
+	for _, kv := range env {
+		if strings.HasPrefix(kv, prefix) {
+			return strings.TrimPrefix(kv, prefix)
+		}
+	}
+	return ""
+}
+
+// containerService resolves the docker compose service of a containerized
+// process through its cgroup's container ID.
+func containerService(pid int32) (string, string) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return "docker", ""
+	}
+	id := containerIDRe.FindString(string(data))
+	if id == "" {
+		return "docker", ""
+	}
+
+	if cached, ok := containerServices.Load(id); ok {
+		manager, service, _ := strings.Cut(cached.(string), ":")
+		return manager, service
+	}
+
+	out, err := exec.Command("docker", "inspect", "--format",
+		`{{index .Config.Labels "com.docker.compose.service"}}|{{.Name}}`, id).Output()
+	manager, service := "docker", id[:12]
+	if err == nil {
+		compose, name, _ := strings.Cut(strings.TrimSpace(string(out)), "|")
+		if compose != "" {
+			manager, service = "compose", compose
+		} else if name != "" {
+			service = strings.TrimPrefix(name, "/")
+		}
+	}
+	containerServices.Store(id, manager+":"+service)
+	return manager, service
+}
@@ -0,0 +1,80 @@
+package scanner
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// PackageOf returns the package that installed the executable at exe, as
+// "brew postgresql@16 16.2", "dpkg postgresql-16" or "rpm nginx-1.24.0",
+// or "" when no package manager claims it. dpkg and rpm are asked through
+// their query tools, which take tens of milliseconds, so callers should
+// cache the result per path.
+func PackageOf(exe string) string {
+	if exe == "" {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	if pkg := brewPackage(exe); pkg != "" {
+		return pkg
+	}
+	if _, err := exec.LookPath("dpkg-query"); err == nil {
+		for _, path := range usrMergeAliases(exe) {
+			out, err := exec.Command("dpkg-query", "-S", path).Output()
+			if err != nil {
+				continue
+			}
+			// "postgresql-16: /usr/lib/postgresql/16/bin/postgres", possibly
+			// with an :arch suffix and several comma separated packages
+			line, _, _ := strings.Cut(string(out), "\n")
+			if i := strings.Index(line, ": "); i > 0 {
+				pkg, _, _ := strings.Cut(line[:i], ",")
+				pkg, _, _ = strings.Cut(pkg, ":")
+				return "dpkg " + pkg
+			}
+		}
+	}
+	if _, err := exec.LookPath("rpm"); err == nil {
+		out, err := exec.Command("rpm", "-qf", "--queryformat", "%{NAME}-%{VERSION}", exe).Output()
+		if err == nil {
+			return "rpm " + strings.TrimSpace(string(out))
+		}
+	}
+	return ""
+}
+
+// brewPackage reads the formula or cask from Homebrew's Cellar/Caskroom
+// layout, e.g. /opt/homebrew/Cellar/postgresql@16/16.2/bin/postgres.
+func brewPackage(exe string) string {
+	parts := strings.Split(filepath.ToSlash(exe), "/")
+	for i, part := range parts {
+		if (part != "Cellar" && part != "Caskroom") || i+1 >= len(parts) {
+			continue
+		}
+		pkg := "brew " + parts[i+1]
+		if part == "Caskroom" {
+			pkg = "brew cask " + parts[i+1]
+		}
+		if i+2 < len(parts) {
+			pkg += " " + parts[i+2]
+		}
+		return pkg
+	}
+	return ""
+}
+
+// usrMergeAliases returns exe and, on merged-/usr systems where /bin links
+// to /usr/bin, the path dpkg may have recorded it under instead.
+func usrMergeAliases(exe string) []string {
+	paths := []string{exe}
+	for _, dir := range []string{"/usr/bin/", "/usr/sbin/", "/usr/lib/"} {
+		if strings.HasPrefix(exe, dir) {
+			paths = append(paths, strings.TrimPrefix(exe, "/usr"))
+		}
+	}
+	return paths
+}
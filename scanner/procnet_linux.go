@@ -0,0 +1,169 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"strings"
+
+	stdnet "net"
+)
+
+// procNetTables are the /proc/net socket tables and the protocol each holds.
+var procNetTables = []struct {
+	file     string
+	protocol string
+}{
+	{"/proc/net/tcp", "tcp"},
+	{"/proc/net/tcp6", "tcp6"},
+	{"/proc/net/udp", "udp"},
+	{"/proc/net/udp6", "udp6"},
+}
+
+var tcpStates = map[string]string{
+	"01": "ESTABLISHED",
+	"02": "SYN_SENT",
+	"03": "SYN_RECV",
+	"04": "FIN_WAIT1",
+	"05": "FIN_WAIT2",
+	"06": "TIME_WAIT",
+	"07": "CLOSE",
+	"08": "CLOSE_WAIT",
+	"09": "LAST_ACK",
+	"0A": "LISTEN",
+	"0B": "CLOSING",
+}
+
+// listConnections reads the socket tables straight from /proc, falling
+// back to gopsutil when /proc isn't usable. denied holds the PIDs whose
+// sockets couldn't be inspected for lack of permission.
+func listConnections() (connMap map[int32][]Connection, denied map[int32]bool, err error) {
+	connMap, denied, err = procConnections()
+	if err != nil {
+		connMap, err = gopsutilConnections()
+		return connMap, nil, err
+	}
+	return connMap, denied, nil
+}
+
+// procConnections parses /proc/net/{tcp,udp}{,6} and matches socket inodes
+// against /proc/[pid]/fd links. It reads each table once and never opens
+// per-process files other than the fd directory, which keeps it fast on
+// machines with thousands of sockets.
+func procConnections() (map[int32][]Connection, map[int32]bool, error) {
+	byInode := make(map[string][]Connection)
+	for _, t := range procNetTables {
+		data, err := os.ReadFile(t.file)
+		if err != nil {
+			if strings.HasSuffix(t.file, "6") && os.IsNotExist(err) {
+				continue // IPv6 disabled
+			}
+			return nil, nil, err
+		}
+		parseProcNet(data, t.protocol, byInode)
+	}
+
+	owners, denied, err := socketOwners()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	connMap := make(map[int32][]Connection)
+	for inode, conns := range byInode {
+		if pid, ok := owners[inode]; ok {
+			connMap[pid] = append(connMap[pid], conns...)
+		}
+	}
+	return connMap, denied, nil
+}
+
+// parseProcNet adds the sockets of one /proc/net table to byInode.
+func parseProcNet(data []byte, protocol string, byInode map[string][]Connection) {
+	lines := bytes.Split(data, []byte("\n"))
+	for _, line := range lines[1:] { // header
+		f := strings.Fields(string(line))
+		if len(f) < 10 {
+			continue
+		}
+		local, lport, ok := decodeProcAddr(f[1])
+		if !ok {
+			continue
+		}
+		remote, rport, ok := decodeProcAddr(f[2])
+		if !ok {
+			continue
+		}
+		status := "NONE"
+		if strings.HasPrefix(protocol, "tcp") {
+			status = tcpStates[f[3]]
+		}
+		byInode[f[9]] = append(byInode[f[9]], Connection{
+			Protocol:   protocol,
+			LocalAddr:  local,
+			Port:       lport,
+			RemoteAddr: remote,
+			RemotePort: rport,
+			Status:     status,
+		})
+	}
+}
+
+// decodeProcAddr decodes "0100007F:1F90" into 127.0.0.1, 8080. Addresses
+// are stored as host-order 32-bit words.
+func decodeProcAddr(s string) (string, uint32, bool) {
+	addr, portHex, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", 0, false
+	}
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return "", 0, false
+	}
+	raw, err := hex.DecodeString(addr)
+	if err != nil || (len(raw) != 4 && len(raw) != 16) {
+		return "", 0, false
+	}
+	for i := 0; i < len(raw); i += 4 {
+		raw[i], raw[i+1], raw[i+2], raw[i+3] = raw[i+3], raw[i+2], raw[i+1], raw[i]
+	}
+	return stdnet.IP(raw).String(), uint32(port), true
+}
+
+// socketOwners maps socket inodes to the lowest PID holding them, so
+// sockets shared by forked workers are attributed to their parent. It also
+// returns the PIDs whose fd tables are not readable.
+func socketOwners() (map[string]int32, map[int32]bool, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	owners := make(map[string]int32)
+	denied := make(map[int32]bool)
+	for _, e := range entries {
+		pid, err := strconv.ParseInt(e.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		fdDir := "/proc/" + e.Name() + "/fd/"
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			if os.IsPermission(err) {
+				denied[int32(pid)] = true
+			}
+			continue // exited, or not ours to inspect
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(fdDir + fd.Name())
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode := link[len("socket:[") : len(link)-1]
+			if owner, ok := owners[inode]; !ok || int32(pid) < owner {
+				owners[inode] = int32(pid)
+			}
+		}
+	}
+	return owners, denied, nil
+}
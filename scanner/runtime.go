@@ -0,0 +1,129 @@
+package scanner
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// RuntimeRule tags processes whose executable name or command line matches
+// with a language runtime. Either pattern may be empty.
+type RuntimeRule struct {
+	Name    string `json:"name"`
+	Exe     string `json:"exe"`     // Regexp on the executable's base name
+	Cmdline string `json:"cmdline"` // Regexp on the full command line
+}
+
+type compiledRule struct {
+	name         string
+	exe, cmdline *regexp.Regexp
+}
+
+var defaultRuntimeRules = []RuntimeRule{
+	{Name: "node", Exe: `^(node|nodejs)$`},
+	{Name: "bun", Exe: `^bun$`},
+	{Name: "deno", Exe: `^deno$`},
+	{Name: "python", Exe: `^(python[0-9.]*|pypy[0-9.]*|uwsgi|gunicorn)$`},
+	{Name: "java", Exe: `^java$`},
+	{Name: "ruby", Exe: `^(ruby[0-9.]*|puma|unicorn)$`},
+	{Name: "php", Exe: `^php(-fpm)?[0-9.]*(:.*)?$`},
+	{Name: "dotnet", Exe: `^dotnet$`},
+	{Name: "erlang", Exe: `^beam(\.smp)?$`},
+	{Name: "perl", Exe: `^perl[0-9.]*$`},
+}
+
+var (
+	runtimeMu    sync.RWMutex
+	runtimeRules = mustCompileRules(defaultRuntimeRules)
+
+	// goBinaries caches whether an executable path is a Go binary
+	goBinaries sync.Map
+)
+
+// SetRuntimeRules adds custom rules, checked before the built-in ones.
+func SetRuntimeRules(custom []RuntimeRule) error {
+	compiled, err := compileRules(custom)
+	if err != nil {
+		return err
+	}
+	runtimeMu.Lock()
+	runtimeRules = append(compiled, mustCompileRules(defaultRuntimeRules)...)
+	runtimeMu.Unlock()
+	return nil
+}
+
+func compileRules(rules []RuntimeRule) ([]compiledRule, error) {
+	var out []compiledRule
+	for _, r := range rules {
+		c := compiledRule{name: r.Name}
+		var err error
+		if r.Exe != "" {
+			if c.exe, err = regexp.Compile(r.Exe); err != nil {
+				return nil, fmt.Errorf("runtime %q: invalid exe pattern: %w", r.Name, err)
+			}
+		}
+		if r.Cmdline != "" {
+			if c.cmdline, err = regexp.Compile(r.Cmdline); err != nil {
+				return nil, fmt.Errorf("runtime %q: invalid cmdline pattern: %w", r.Name, err)
+			}
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func mustCompileRules(rules []RuntimeRule) []compiledRule {
+	c, err := compileRules(rules)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// RuntimeOf names the language runtime of a process from its executable
+// and command line, or "" if no rule matches.
+func RuntimeOf(exe, cmdline string) string {
+	if exe == "" {
+		exe, _, _ = strings.Cut(cmdline, " ")
+	}
+	base := filepath.Base(exe)
+
+	runtimeMu.RLock()
+	defer runtimeMu.RUnlock()
+	for _, r := range runtimeRules {
+		if r.exe == nil && r.cmdline == nil {
+			continue
+		}
+		if r.exe != nil && !r.exe.MatchString(base) {
+			continue
+		}
+		if r.cmdline != nil && !r.cmdline.MatchString(cmdline) {
+			continue
+		}
+		return r.name
+	}
+	return ""
+}
+
+// detectRuntime is RuntimeOf plus a look inside local executables for the
+// build info Go embeds.
+func detectRuntime(exe, cmdline string) string {
+	if rt := RuntimeOf(exe, cmdline); rt != "" || exe == "" {
+		return rt
+	}
+	if isGo, ok := goBinaries.Load(exe); ok {
+		if isGo.(bool) {
+			return "go"
+		}
+		return ""
+	}
+	_, err := buildinfo.ReadFile(exe)
+	goBinaries.Store(exe, err == nil)
+	if err == nil {
+		return "go"
+	}
+	return ""
+}
@@ -1,11 +1,22 @@
 package scanner
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	stdnet "net"
+	"os"
+	"os/exec"
 	"os/user"
-	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/shirou/gopsutil/v3/mem"
 	"github.com/shirou/gopsutil/v3/net"
 	"github.com/shirou/gopsutil/v3/process"
 )
@@ -18,75 +29,328 @@ const (
 )
 
 type ProcessInfo struct {
-	PID         int32
-	Name        string
-	User        string
-	Type        ProcessType
-	Connections []Connection
-	Cwd         string
-	Command     string
-	AppType     string // GUI, CLI, Daemon (heuristic)
-	IsSelected  bool   // For UI selection
-	CPUPercent  float64
-	MemoryUsage uint64 // RSS in bytes
+	PID           int32
+	PPID          int32
+	Name          string
+	User          string
+	Type          ProcessType
+	Connections   []Connection
+	Cwd           string
+	GitRepo       string // Name of the git work tree containing Cwd
+	GitBranch     string // Its checked out branch, or short commit when detached
+	Command       string
+	Exe           string    // Executable path
+	AppType       string    // GUI App, CLI, Daemon, Service... (see Classifier)
+	Runtime       string    // Language runtime: node, python, java, go, ruby...
+	IsSelected    bool      // For UI selection
+	State         string    // running, sleeping, stopped, zombie, idle...
+	Started       time.Time // Zero when unknown
+	Threads       int32
+	CPUPercent    float64
+	MemoryUsage   uint64   // RSS in bytes
+	MemoryPercent float64  // RSS as a percentage of total RAM
+	SwapUsage     uint64   // Swapped out bytes (Linux only)
+	DiskRead      uint64   // Bytes read from disk since the previous scan
+	DiskWrite     uint64   // Bytes written to disk since the previous scan
+	KernelThread  bool     // Linux kernel thread (shown as [name] by ps)
+	Manager       string   // Process manager that launched it: foreman, overmind, pm2, compose...
+	Service       string   // Procfile entry / compose service name
+	Unit          string   // systemd unit or launchd job label
+	UserUnit      bool     // Unit belongs to the user's service manager, not the system's
+	Denied        []string // Fields that couldn't be read for lack of permission: user, cwd, connections
 }
 
 type Connection struct {
-	Port   uint32
-	Status string
+	Protocol   string // tcp, tcp6, udp, udp6
+	LocalAddr  string
+	Port       uint32
+	RemoteAddr string
+	RemotePort uint32
+	Status     string
 }
 
-func ScanProcesses() ([]ProcessInfo, error) {
-	currentUser, err := user.Current()
+// IsListener reports whether the socket accepts traffic on its port: a TCP
+// socket in LISTEN, or a UDP socket bound without a peer. Unconnected UDP
+// peers read as 0.0.0.0 or :: on some platforms, so the port is checked.
+func (c Connection) IsListener() bool {
+	return c.Status == "LISTEN" || (strings.HasPrefix(c.Protocol, "udp") && c.RemotePort == 0)
+}
+
+// IsExposed reports whether a listening socket is reachable from other
+// machines, i.e. bound to a wildcard (0.0.0.0, ::) or non-loopback address.
+func (c Connection) IsExposed() bool {
+	if c.Status != "LISTEN" {
+		return false
+	}
+	ip := stdnet.ParseIP(c.LocalAddr)
+	return ip == nil || !ip.IsLoopback()
+}
+
+// Options configures a scan.
+//
+// Users, Mine, Ports and ConnectedOnly limit its scope. They are pushed
+// down into the scan: processes out of scope are skipped before their
+// details are read, and with Ports or ConnectedOnly only the socket owners
+// are looked at in the first place. Process managers are then only found
+// among the ancestors that are in scope too.
+type Options struct {
+	// ListenOnly resolves only processes that own a listening socket, and
+	// skips their cwd, command line, CPU, memory and IO. Much faster.
+	ListenOnly bool
+
+	// Users keeps only processes owned by one of these accounts. Empty
+	// means everyone. Mine keeps only the current user's.
+	Users []string
+	Mine  bool
+
+	// Ports keeps only processes with a socket on one of these local
+	// ports. Empty means any. ConnectedOnly keeps only processes with a
+	// socket at all.
+	Ports         []uint32
+	ConnectedOnly bool
+
+	// Interval is the time between scans of Watch, DefaultWatchInterval
+	// when zero.
+	Interval time.Duration
+
+	// Processes and Conns replace the live system as what is scanned, e.g.
+	// with fixture data in tests. Details read from the system directly
+	// (swap, service units, process manager environment, git checkouts,
+	// AppTypes found from /proc or the parent process) are only filled in
+	// when both are nil.
+	Processes ProcessSource
+	Conns     ConnSource
+}
+
+// sources returns what to scan, and whether that is the live system.
+func (o Options) sources() (ProcessSource, ConnSource, bool) {
+	procs, conns := o.Processes, o.Conns
+	live := procs == nil && conns == nil
+	if procs == nil {
+		procs = liveProcesses{}
+	}
+	if conns == nil {
+		conns = liveConns{}
+	}
+	return procs, conns, live
+}
+
+// keep reports whether p is within the scope of o.
+func (o Options) keep(p ProcessInfo) bool {
+	return o.keepUser(p.User, p.Type) && o.keepConns(p.Connections)
+}
+
+func (o Options) keepUser(name string, pType ProcessType) bool {
+	if o.Mine && pType != UserProcess {
+		return false
+	}
+	return len(o.Users) == 0 || slices.ContainsFunc(o.Users, func(u string) bool { return sameAccount(u, name) })
+}
+
+func (o Options) keepConns(conns []Connection) bool {
+	if o.ConnectedOnly && len(conns) == 0 {
+		return false
+	}
+	return len(o.Ports) == 0 || slices.ContainsFunc(conns, func(c Connection) bool { return slices.Contains(o.Ports, c.Port) })
+}
+
+// bySocket reports whether only socket owners can be in scope.
+func (o Options) bySocket() bool {
+	return o.ConnectedOnly || len(o.Ports) > 0
+}
+
+// narrow reports whether a scan skips some processes.
+func (o Options) narrow() bool {
+	return o.bySocket() || o.Mine || len(o.Users) > 0
+}
+
+// Scanner takes snapshots of the processes on this machine and their
+// sockets. It remembers IO counters between scans to report per-scan
+// deltas, so keep one Scanner around rather than creating one per scan.
+// A Scanner is safe for concurrent use.
+type Scanner struct {
+	opts Options
+
+	ioMu   sync.Mutex
+	ioPrev map[int32]ioSample // Cumulative IO counters from the previous scan
+
+	cpuMu   sync.Mutex
+	cpuPrev map[int32]cpuSample // Cumulative CPU times from the previous scan
+
+	appMu   sync.Mutex
+	appPrev map[int32]appTypeEntry // AppTypes from the previous scan
+}
+
+// New returns a Scanner configured by opts.
+func New(opts Options) *Scanner {
+	return &Scanner{opts: opts, ioPrev: map[int32]ioSample{}, cpuPrev: map[int32]cpuSample{}, appPrev: map[int32]appTypeEntry{}}
+}
+
+// Scan returns a snapshot of the processes selected by the Scanner's
+// Options. It gives up with ctx's error once ctx is done.
+func (s *Scanner) Scan(ctx context.Context) ([]ProcessInfo, error) {
+	procs, _, err := s.scan(ctx, s.opts)
+	return procs, err
+}
+
+// Warnings is what a scan couldn't read. The scan still returns the rest,
+// so a process without connections may just have unknown ones.
+type Warnings struct {
+	Connections error // Listing sockets failed, no process has any
+	Skipped     int   // Processes that couldn't be read, not counting exited ones
+}
+
+func (w Warnings) String() string {
+	var parts []string
+	if w.Connections != nil {
+		parts = append(parts, fmt.Sprintf("connections couldn't be listed (%v), ports are unknown", w.Connections))
+	}
+	if w.Skipped > 0 {
+		parts = append(parts, fmt.Sprintf("%d process(es) couldn't be read and are missing", w.Skipped))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ScanPartial is Scan, also telling what the scan couldn't read.
+func (s *Scanner) ScanPartial(ctx context.Context) ([]ProcessInfo, Warnings, error) {
+	return s.scan(ctx, s.opts)
+}
+
+func (s *Scanner) scan(ctx context.Context, opts Options) ([]ProcessInfo, Warnings, error) {
+	var procs []ProcessInfo
+	var warn Warnings
+	var err error
+	if opts.ListenOnly {
+		procs, err = scanListeners(ctx, opts)
+	} else {
+		procs, warn, err = s.scanAll(ctx, opts)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get current user: %w", err)
+		return nil, Warnings{}, err
 	}
+	return slices.DeleteFunc(procs, func(p ProcessInfo) bool { return !opts.keep(p) }), warn, nil
+}
+
+// Kill forcefully terminates the process pid.
+func (s *Scanner) Kill(pid int32) error {
+	return KillProcess(pid)
+}
 
-	procs, err := process.Processes()
+// defaultScanner backs Scan.
+var defaultScanner = New(Options{})
+
+// Scan returns a snapshot of the processes selected by opts, using a
+// Scanner shared by all callers of Scan. It gives up with ctx's error once
+// ctx is done.
+func Scan(ctx context.Context, opts Options) ([]ProcessInfo, error) {
+	procs, _, err := defaultScanner.scan(ctx, opts)
+	return procs, err
+}
+
+// vanished reports whether err comes from a process that exited, which
+// happens all the time during a scan and isn't worth a warning.
+func vanished(err error) bool {
+	return IsGone(err) || errors.Is(err, fs.ErrNotExist)
+}
+
+func (s *Scanner) scanAll(ctx context.Context, opts Options) ([]ProcessInfo, Warnings, error) {
+	currentUser, err := user.Current()
 	if err != nil {
-		return nil, fmt.Errorf("failed to list processes: %w", err)
+		return nil, Warnings{}, fmt.Errorf("failed to get current user: %w", err)
 	}
 
-	var results []ProcessInfo
+	procSource, connSource, live := opts.sources()
 
-	// Get all network connections once to map them to PIDs
-	connections, err := net.Connections("inet")
-	connMap := make(map[int32][]Connection)
-	if err == nil {
-		for _, conn := range connections {
-			// We capture all, but maybe we want to group or filter by interesting ones?
-			// The user just said "distinguish".
-			c := Connection{
-				Port:   conn.Laddr.Port,
-				Status: conn.Status,
+	// Get all network connections once to map them to PIDs. Processes are
+	// still worth showing when that fails, with a warning.
+	var warn Warnings
+	connMap, deniedConns, err := connSource.Connections()
+	if err != nil {
+		warn.Connections = err
+	}
+
+	var procs []Process
+	if opts.bySocket() {
+		// Look up the owners only instead of enumerating every process
+		for pid, conns := range connMap {
+			if pid == 0 || !opts.keepConns(conns) {
+				continue
 			}
-			connMap[conn.Pid] = append(connMap[conn.Pid], c)
+			p, err := procSource.Process(pid)
+			if err != nil {
+				if !vanished(err) {
+					warn.Skipped++
+				}
+				continue
+			}
+			procs = append(procs, p)
+		}
+	} else {
+		procs, err = procSource.Processes(ctx)
+		if err != nil {
+			return nil, Warnings{}, fmt.Errorf("failed to list processes: %w", err)
 		}
 	}
 
+	var results []ProcessInfo
+	ioSamples := make(map[int32]ioSample, len(procs))
+	cpuSamples := make(map[int32]cpuSample, len(procs))
+	appTypes := make(map[int32]appTypeEntry, len(procs))
+	repos := make(map[string][2]string) // cwd -> git repo, branch
+
+	// Total RAM once per scan, so memory percentages are consistent
+	var totalMem uint64
+	if vm, err := mem.VirtualMemory(); err == nil {
+		totalMem = vm.Total
+	}
+
 	for _, p := range procs {
+		if err := ctx.Err(); err != nil {
+			return nil, Warnings{}, err
+		}
+
 		// Basic info
 		name, err := p.Name()
 		if err != nil {
-			continue // Process might have terminated
+			if !vanished(err) {
+				warn.Skipped++
+			}
+			continue
 		}
 
+		var denied []string
+
 		// User
 		username, err := p.Username()
 		if err != nil {
 			username = "unknown"
+			if isPermission(err) {
+				denied = append(denied, "user")
+			}
 		}
 
 		// Type
 		pType := SystemProcess
-		if username == currentUser.Username {
+		if sameAccount(username, currentUser.Username) {
 			pType = UserProcess
 		}
+		if !opts.keepUser(username, pType) {
+			continue
+		}
+
+		// Parent
+		ppid, err := p.Ppid()
+		if err != nil {
+			ppid = 0
+		}
 
 		// Cwd
 		cwd, err := p.Cwd()
 		if err != nil {
 			cwd = ""
+			if isPermission(err) {
+				denied = append(denied, "cwd")
+			}
 		}
 
 		// Command line
@@ -95,52 +359,262 @@ func ScanProcesses() ([]ProcessInfo, error) {
 			cmdline = ""
 		}
 
+		pid := p.PID()
+
 		// Connections
-		conns := connMap[p.Pid]
+		conns := connMap[pid]
+		if deniedConns[pid] {
+			denied = append(denied, "connections")
+		}
+
+		// State, empty where the platform doesn't report it
+		state, _ := p.State()
+		var started time.Time
+		if ms, err := p.CreateTime(); err == nil && ms > 0 {
+			started = time.UnixMilli(ms)
+		}
+		threads, _ := p.NumThreads()
 
 		// CPU & Mem
-		cpuPct, err := p.Percent(0)
+		cpuPct, cpuNow, ok := s.cpuPercent(p, started, time.Now())
+		if ok {
+			cpuSamples[pid] = cpuNow
+		}
+
+		memUsage, err := p.RSS()
 		if err != nil {
-			cpuPct = 0
+			memUsage = 0
+		}
+		var memPct float64
+		if totalMem > 0 {
+			memPct = float64(memUsage) / float64(totalMem) * 100
+		}
+
+		// Disk IO
+		diskRead, diskWrite, sample, ok := s.diskIO(p)
+		if ok {
+			ioSamples[pid] = sample
 		}
 
-		memInfo, err := p.MemoryInfo()
-		var memUsage uint64
-		if err == nil {
-			memUsage = memInfo.RSS
+		// Kernel threads have no cwd or files to read in the first place
+		kernelThread := isKernelThread(pid, ppid, cmdline)
+		if kernelThread {
+			denied = nil
 		}
 
-		// App Type Heuristic (Very basic)
-		appType := "Unknown"
-		if strings.HasPrefix(cwd, "/Applications") || strings.HasSuffix(name, ".app") {
-			appType = "GUI App"
-		} else if strings.Contains(cmdline, " go run ") || strings.HasPrefix(filepath.Base(cwd), "apps") {
-			appType = "Dev Tool"
-		} else {
-			appType = "Binary"
+		// Executable & Runtime
+		var exe, runtimeName string
+		if !kernelThread {
+			exe, _ = p.Exe()
+			runtimeName = detectRuntime(exe, cmdline)
+		}
+
+		info := ProcessInfo{
+			PID:           pid,
+			PPID:          ppid,
+			Name:          name,
+			User:          username,
+			Type:          pType,
+			Connections:   conns,
+			Cwd:           cwd,
+			Command:       cmdline,
+			Exe:           exe,
+			Runtime:       runtimeName,
+			State:         state,
+			Started:       started,
+			Threads:       threads,
+			CPUPercent:    cpuPct,
+			MemoryUsage:   memUsage,
+			MemoryPercent: memPct,
+			DiskRead:      diskRead,
+			DiskWrite:     diskWrite,
+			KernelThread:  kernelThread,
+			Denied:        denied,
+		}
+		info.AppType, appTypes[pid] = s.appType(info, live)
+		if live {
+			info.SwapUsage = swapUsage(pid)
+		}
+		// Only for processes with connections: walking up to / for every
+		// process on the system would dominate the scan
+		if live && cwd != "" && len(conns) > 0 {
+			repo, ok := repos[cwd]
+			if !ok {
+				repo[0], repo[1] = gitRepo(cwd)
+				repos[cwd] = repo
+			}
+			info.GitRepo, info.GitBranch = repo[0], repo[1]
+		}
+		results = append(results, info)
+	}
+
+	s.rememberIO(ioSamples, opts.narrow())
+	s.rememberCPU(cpuSamples, opts.narrow())
+	s.rememberAppTypes(appTypes, opts.narrow())
+	if live {
+		detectManagers(results)
+		detectUnits(results)
+	}
+
+	return results, warn, nil
+}
+
+// scanListeners is a fast scan resolving only processes that own a
+// listening socket. Cwd, command line, CPU and memory are not collected.
+func scanListeners(ctx context.Context, opts Options) ([]ProcessInfo, error) {
+	currentUser, err := user.Current()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current user: %w", err)
+	}
+
+	procSource, connSource, _ := opts.sources()
+	connMap, _, err := connSource.Connections()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list connections: %w", err)
+	}
+
+	var results []ProcessInfo
+	for pid, conns := range connMap {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		listening := false
+		for _, c := range conns {
+			if c.Status == "LISTEN" {
+				listening = true
+				break
+			}
+		}
+		if pid == 0 || !listening || !opts.keepConns(conns) {
+			continue
 		}
 
+		p, err := procSource.Process(pid)
+		if err != nil {
+			continue // Process might have terminated
+		}
+		name, err := p.Name()
+		if err != nil {
+			continue
+		}
+		username, err := p.Username()
+		if err != nil {
+			username = "unknown"
+		}
+		pType := SystemProcess
+		if sameAccount(username, currentUser.Username) {
+			pType = UserProcess
+		}
+		if !opts.keepUser(username, pType) {
+			continue
+		}
+		ppid, _ := p.Ppid()
+
 		results = append(results, ProcessInfo{
-			PID:         p.Pid,
+			PID:         pid,
+			PPID:        ppid,
 			Name:        name,
 			User:        username,
 			Type:        pType,
 			Connections: conns,
-			Cwd:         cwd,
-			Command:     cmdline,
-			AppType:     appType,
-			CPUPercent:  cpuPct,
-			MemoryUsage: memUsage,
 		})
 	}
 
 	return results, nil
 }
 
+// gopsutilConnections maps every inet socket to its owning PID through
+// gopsutil, the portable path.
+func gopsutilConnections() (map[int32][]Connection, error) {
+	connections, err := net.Connections("inet")
+	if err != nil {
+		return nil, err
+	}
+	connMap := make(map[int32][]Connection)
+	for _, conn := range connections {
+		// We capture all, but maybe we want to group or filter by interesting ones?
+		// The user just said "distinguish".
+		c := Connection{
+			Protocol:   protocol(conn),
+			LocalAddr:  conn.Laddr.IP,
+			Port:       conn.Laddr.Port,
+			RemoteAddr: conn.Raddr.IP,
+			RemotePort: conn.Raddr.Port,
+			Status:     conn.Status,
+		}
+		connMap[conn.Pid] = append(connMap[conn.Pid], c)
+	}
+	return connMap, nil
+}
+
+// isPermission reports whether err means the data exists but we may not
+// read it (as opposed to the process having exited).
+func isPermission(err error) bool {
+	return errors.Is(err, fs.ErrPermission)
+}
+
+func protocol(conn net.ConnectionStat) string {
+	proto := "tcp"
+	if conn.Type == syscall.SOCK_DGRAM {
+		proto = "udp"
+	}
+	if conn.Family == syscall.AF_INET6 {
+		proto += "6"
+	}
+	return proto
+}
+
+// isKernelThread reports whether a process is a Linux kernel thread.
+// Kernel threads have no command line and are children of kthreadd (PID 2).
+// On Windows the System Idle Process (0) and System (4) are treated alike.
+func isKernelThread(pid, ppid int32, cmdline string) bool {
+	if runtime.GOOS == "windows" {
+		return pid == 0 || pid == 4
+	}
+	if runtime.GOOS != "linux" || cmdline != "" {
+		return false
+	}
+	return pid == 2 || ppid == 2
+}
+
+// IsGone reports whether err from killing a process means it had already
+// exited. It recognizes the errors of remote kills too, which only carry
+// the message.
+func IsGone(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, process.ErrorProcessNotRunning) || errors.Is(err, os.ErrProcessDone) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "no such process") || strings.Contains(msg, process.ErrorProcessNotRunning.Error())
+}
+
+// Alive reports whether pid still runs on this machine. Zombies count as
+// gone: they have released their sockets and only wait to be reaped.
+func Alive(pid int32) bool {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return false
+	}
+	st, err := p.Status()
+	return err != nil || len(st) == 0 || st[0] != process.Zombie
+}
+
 func KillProcess(pid int32) error {
 	p, err := process.NewProcess(pid)
 	if err != nil {
 		return err
 	}
-	return p.Kill()
+	err = p.Kill()
+	if err != nil && runtime.GOOS == "windows" {
+		// TerminateProcess is refused for some protected and service
+		// processes that taskkill can still end when elevated
+		if out, terr := exec.Command("taskkill", "/F", "/PID", fmt.Sprint(pid)).CombinedOutput(); terr != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	return err
 }
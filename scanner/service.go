@@ -0,0 +1,68 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"runtime"
+	"strconv"
+)
+
+// ServiceCommand builds the systemctl or launchctl invocation that
+// restarts or stops (action "restart" or "stop") the unit p runs in.
+// User units are addressed in their owner's manager, not ours. System
+// units, and user units of someone else, get sudo when not running as
+// root; the command may prompt, so it needs the terminal.
+func ServiceCommand(p ProcessInfo, action string) (*exec.Cmd, error) {
+	if p.Unit == "" {
+		return nil, fmt.Errorf("%s (%d) is not managed by a service manager", p.Name, p.PID)
+	}
+	if action != "restart" && action != "stop" {
+		return nil, fmt.Errorf("unknown service action %q", action)
+	}
+
+	// Another user's manager is only reachable as root
+	other := false
+	if p.UserUnit && p.User != "" {
+		me, err := user.Current()
+		other = err != nil || me.Username != p.User
+	}
+
+	var args []string
+	switch runtime.GOOS {
+	case "linux":
+		args = []string{"systemctl", action, p.Unit}
+		if p.UserUnit {
+			args = []string{"systemctl", "--user", action, p.Unit}
+			if other {
+				args = []string{"systemctl", "--user", "-M", p.User + "@", action, p.Unit}
+			}
+		}
+	case "darwin":
+		target := "system/" + p.Unit
+		if p.UserUnit {
+			uid := strconv.Itoa(os.Getuid())
+			if other {
+				owner, err := user.Lookup(p.User)
+				if err != nil {
+					return nil, fmt.Errorf("failed to look up owner of %s (%d): %w", p.Name, p.PID, err)
+				}
+				uid = owner.Uid
+			}
+			target = fmt.Sprintf("gui/%s/%s", uid, p.Unit)
+		}
+		// kickstart -k kills and restarts; bootout unloads so KeepAlive jobs stay down
+		args = []string{"launchctl", "kickstart", "-k", target}
+		if action == "stop" {
+			args = []string{"launchctl", "bootout", target}
+		}
+	default:
+		return nil, fmt.Errorf("service control is not supported on %s", runtime.GOOS)
+	}
+
+	if (!p.UserUnit || other) && os.Geteuid() != 0 {
+		args = append([]string{"sudo"}, args...)
+	}
+	return exec.Command(args[0], args[1:]...), nil
+}
@@ -0,0 +1,83 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Signature identifies the executable of a process.
+type Signature struct {
+	SHA256 string
+
+	// Signer is the code signing identity on macOS: the leaf authority,
+	// e.g. "Developer ID Application: Docker Inc (9BNSXJN65R)", "ad-hoc"
+	// or "unsigned". Empty elsewhere.
+	Signer string
+}
+
+// SignatureOf hashes the executable pid runs from exe and, on macOS, reads
+// its code signature with codesign. On Linux the image is read through
+// /proc/<pid>/exe, so a binary replaced or deleted on disk since the
+// process started is still the one hashed. Hashing reads the whole file,
+// so callers should cache the result.
+func SignatureOf(pid int32, exe string) (Signature, error) {
+	f, err := openExe(pid, exe)
+	if err != nil {
+		return Signature{}, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return Signature{}, err
+	}
+	sig := Signature{SHA256: hex.EncodeToString(h.Sum(nil))}
+	if runtime.GOOS == "darwin" {
+		sig.Signer = codeSigner(exe)
+	}
+	return sig, nil
+}
+
+func openExe(pid int32, exe string) (*os.File, error) {
+	if runtime.GOOS == "linux" {
+		if f, err := os.Open(fmt.Sprintf("/proc/%d/exe", pid)); err == nil {
+			return f, nil
+		}
+	}
+	return os.Open(exe)
+}
+
+// codeSigner parses the first Authority line `codesign -dv` prints, which
+// is the signing certificate.
+func codeSigner(exe string) string {
+	// codesign writes the details to stderr
+	out, err := exec.Command("codesign", "-dv", "--verbose=2", exe).CombinedOutput()
+	if bytes.Contains(out, []byte("not signed at all")) {
+		return "unsigned"
+	}
+	if err != nil {
+		return ""
+	}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	adhoc := false
+	for sc.Scan() {
+		line := sc.Text()
+		if authority, ok := strings.CutPrefix(line, "Authority="); ok {
+			return authority
+		}
+		if line == "Signature=adhoc" {
+			adhoc = true
+		}
+	}
+	if adhoc {
+		return "ad-hoc"
+	}
+	return ""
+}
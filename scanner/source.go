@@ -0,0 +1,116 @@
+package scanner
+
+import (
+	"context"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// Process is what a scan reads about one process. Errors mean the field is
+// unknown; errors wrapping fs.ErrPermission mark it as denied.
+type Process interface {
+	PID() int32
+	Name() (string, error)
+	Username() (string, error)
+	Ppid() (int32, error)
+	Cwd() (string, error)
+	Cmdline() (string, error)
+	Exe() (string, error)
+	CPUTime() (float64, error) // Cumulative user and system seconds
+	RSS() (uint64, error)
+	IO() (read, write uint64, err error) // Cumulative bytes read and written
+	State() (string, error)              // running, sleeping, stopped, zombie...
+	CreateTime() (int64, error)          // Start time in Unix milliseconds
+	NumThreads() (int32, error)
+}
+
+// ProcessSource lists the processes a scan reads.
+type ProcessSource interface {
+	Processes(ctx context.Context) ([]Process, error)
+	Process(pid int32) (Process, error)
+}
+
+// ConnSource maps sockets to the PIDs owning them. denied holds PIDs whose
+// sockets couldn't be read.
+type ConnSource interface {
+	Connections() (conns map[int32][]Connection, denied map[int32]bool, err error)
+}
+
+// liveProcesses reads this machine's processes through gopsutil.
+type liveProcesses struct{}
+
+func (liveProcesses) Processes(ctx context.Context) ([]Process, error) {
+	procs, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Process, len(procs))
+	for i, p := range procs {
+		out[i] = liveProcess{p}
+	}
+	return out, nil
+}
+
+func (liveProcesses) Process(pid int32) (Process, error) {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return nil, err
+	}
+	return liveProcess{p}, nil
+}
+
+type liveProcess struct {
+	*process.Process
+}
+
+func (p liveProcess) PID() int32 { return p.Pid }
+
+func (p liveProcess) CPUTime() (float64, error) {
+	t, err := p.Times()
+	if err != nil {
+		return 0, err
+	}
+	return t.User + t.System, nil
+}
+
+func (p liveProcess) RSS() (uint64, error) {
+	m, err := p.MemoryInfo()
+	if err != nil {
+		return 0, err
+	}
+	return m.RSS, nil
+}
+
+func (p liveProcess) IO() (uint64, uint64, error) {
+	c, err := p.IOCounters()
+	if err != nil {
+		return 0, 0, err
+	}
+	return c.ReadBytes, c.WriteBytes, nil
+}
+
+// stateNames spells out gopsutil's abbreviated states.
+var stateNames = map[string]string{
+	process.Sleep: "sleeping",
+	process.Stop:  "stopped",
+	process.Wait:  "waiting",
+	process.Lock:  "locked",
+}
+
+func (p liveProcess) State() (string, error) {
+	st, err := p.Status()
+	if err != nil || len(st) == 0 {
+		return "", err
+	}
+	if name, ok := stateNames[st[0]]; ok {
+		return name, nil
+	}
+	return st[0], nil
+}
+
+// liveConns reads this machine's socket tables.
+type liveConns struct{}
+
+func (liveConns) Connections() (map[int32][]Connection, map[int32]bool, error) {
+	return listConnections()
+}
@@ -0,0 +1,138 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os/user"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fakeProcess is a Process with fixed fields.
+type fakeProcess struct {
+	pid                  int32
+	ppid                 int32
+	name, user, cwd, exe string
+	cmdline, state       string
+	created              int64
+	cpu                  float64
+	cwdErr               error
+}
+
+func (p fakeProcess) PID() int32                  { return p.pid }
+func (p fakeProcess) Name() (string, error)       { return p.name, nil }
+func (p fakeProcess) Username() (string, error)   { return p.user, nil }
+func (p fakeProcess) Ppid() (int32, error)        { return p.ppid, nil }
+func (p fakeProcess) Cwd() (string, error)        { return p.cwd, p.cwdErr }
+func (p fakeProcess) Cmdline() (string, error)    { return p.cmdline, nil }
+func (p fakeProcess) Exe() (string, error)        { return p.exe, nil }
+func (p fakeProcess) CPUTime() (float64, error)   { return p.cpu, nil }
+func (p fakeProcess) RSS() (uint64, error)        { return 1 << 20, nil }
+func (p fakeProcess) IO() (uint64, uint64, error) { return 0, 0, errors.New("no IO") }
+func (p fakeProcess) State() (string, error)      { return p.state, nil }
+func (p fakeProcess) CreateTime() (int64, error)  { return p.created, nil }
+func (p fakeProcess) NumThreads() (int32, error)  { return 4, nil }
+
+type fakeProcesses []fakeProcess
+
+func (f fakeProcesses) Processes(ctx context.Context) ([]Process, error) {
+	out := make([]Process, len(f))
+	for i, p := range f {
+		out[i] = p
+	}
+	return out, nil
+}
+
+func (f fakeProcesses) Process(pid int32) (Process, error) {
+	for _, p := range f {
+		if p.pid == pid {
+			return p, nil
+		}
+	}
+	return nil, fs.ErrNotExist
+}
+
+type fakeConns map[int32][]Connection
+
+func (f fakeConns) Connections() (map[int32][]Connection, map[int32]bool, error) {
+	return f, nil, nil
+}
+
+func TestScanFakeSources(t *testing.T) {
+	me, err := user.Current()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// Host classifiers must not run on fixtures, whatever PIDs they use
+	hostCalls := 0
+	saved := platformClassifiers
+	platformClassifiers = []Classifier{hostClassifier{ClassifierFunc(func(p ProcessInfo) string {
+		hostCalls++
+		return "Host"
+	})}}
+	t.Cleanup(func() { platformClassifiers = saved })
+
+	procs := fakeProcesses{
+		{pid: 100, ppid: 1, name: "web", user: me.Username, cwd: "/srv/web", exe: "/usr/bin/web", cmdline: "web --port 8080", state: "running", created: 1700000000000},
+		{pid: 101, ppid: 100, name: "worker", user: "someone-else", exe: "/usr/bin/worker", state: "sleeping", cwdErr: fs.ErrPermission},
+		{pid: 102, ppid: 1, name: "idle", user: me.Username, exe: "/usr/bin/idle", state: "stopped"},
+	}
+	conns := fakeConns{
+		100: {
+			{Protocol: "tcp", LocalAddr: "0.0.0.0", Port: 8080, Status: "LISTEN"},
+			{Protocol: "tcp", LocalAddr: "127.0.0.1", Port: 8080, RemoteAddr: "127.0.0.1", RemotePort: 50000, Status: "ESTABLISHED"},
+		},
+		101: {{Protocol: "udp", LocalAddr: "127.0.0.1", Port: 5353}},
+	}
+
+	s := New(Options{Processes: procs, Conns: conns})
+	got, err := s.Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d processes, want 3", len(got))
+	}
+	slices.SortFunc(got, func(a, b ProcessInfo) int { return int(a.PID - b.PID) })
+
+	web, worker, idle := got[0], got[1], got[2]
+	if web.Name != "web" || web.PPID != 1 || web.Cwd != "/srv/web" || web.Command != "web --port 8080" {
+		t.Errorf("web = %+v", web)
+	}
+	if web.Type != UserProcess || worker.Type != SystemProcess {
+		t.Errorf("types = %v, %v, want user, system", web.Type, worker.Type)
+	}
+	if len(web.Connections) != 2 || len(worker.Connections) != 1 || len(idle.Connections) != 0 {
+		t.Errorf("connections = %d, %d, %d, want 2, 1, 0", len(web.Connections), len(worker.Connections), len(idle.Connections))
+	}
+	if !web.Started.Equal(time.UnixMilli(1700000000000)) || web.Threads != 4 || web.State != "running" {
+		t.Errorf("web started %v with %d threads, %s", web.Started, web.Threads, web.State)
+	}
+	if !slices.Contains(worker.Denied, "cwd") {
+		t.Errorf("worker denied = %v, want cwd", worker.Denied)
+	}
+	if hostCalls != 0 {
+		t.Errorf("host classifiers ran %d times on fixtures", hostCalls)
+	}
+	for _, p := range got {
+		if p.AppType != "Binary" {
+			t.Errorf("%s AppType = %q, want Binary", p.Name, p.AppType)
+		}
+		if p.SwapUsage != 0 || p.GitRepo != "" {
+			t.Errorf("%s has live-only details: swap %d, repo %q", p.Name, p.SwapUsage, p.GitRepo)
+		}
+	}
+
+	// Scopes apply to fixtures too
+	s = New(Options{Processes: procs, Conns: conns, Ports: []uint32{8080}})
+	got, err = s.Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].PID != 100 {
+		t.Errorf("scan of port 8080 = %v, want web only", got)
+	}
+}
@@ -0,0 +1,85 @@
+package scanner
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultWatchInterval is the time between scans of Watch when
+// Options.Interval is zero.
+const DefaultWatchInterval = 3 * time.Second
+
+// Watch streams changes to the processes on this machine, see
+// Scanner.Watch.
+func Watch(ctx context.Context) (<-chan Event, error) {
+	return New(Options{}).Watch(ctx)
+}
+
+// Watch scans every Options.Interval, right away on Linux when a listening
+// socket opens or closes, and sends what changed since the previous scan.
+// The current state comes first, as a ProcessAdded and PortOpened event
+// per process and port, so a consumer can keep its own copy up to date
+// from the stream alone. Scans that fail are skipped. The channel is closed
+// once ctx is done.
+func (s *Scanner) Watch(ctx context.Context) (<-chan Event, error) {
+	prev, err := s.Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	interval := s.opts.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+
+		var changes <-chan struct{}
+		if w, err := NewWatcher(); err == nil {
+			defer w.Close()
+			changes = w.Changes()
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		send := func(prev, curr []ProcessInfo) bool {
+			procs := make(map[int32]*ProcessInfo, len(curr))
+			for i := range curr {
+				procs[curr[i].PID] = &curr[i]
+			}
+			for _, e := range Diff(prev, curr, time.Now()) {
+				if e.Type == ProcessAdded || e.Type == ProcessUpdated {
+					e.Process = procs[e.PID]
+				}
+				select {
+				case events <- e:
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+
+		if !send(nil, prev) {
+			return
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-changes:
+			}
+			curr, err := s.Scan(ctx)
+			if err != nil {
+				continue
+			}
+			if !send(prev, curr) {
+				return
+			}
+			prev = curr
+		}
+	}()
+	return events, nil
+}
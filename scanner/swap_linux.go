@@ -0,0 +1,30 @@
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// swapUsage reads VmSwap from /proc/[pid]/status.
+func swapUsage(pid int32) uint64 {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return 0
+	}
+	_, rest, ok := bytes.Cut(data, []byte("VmSwap:"))
+	if !ok {
+		return 0
+	}
+	line, _, _ := bytes.Cut(rest, []byte("\n"))
+	fields := bytes.Fields(line) // "123 kB"
+	if len(fields) == 0 {
+		return 0
+	}
+	kb, err := strconv.ParseUint(string(fields[0]), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return kb * 1024
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package scanner
+
+// swapUsage is not exposed per process outside Linux.
+func swapUsage(pid int32) uint64 {
+	return 0
+}
@@ -0,0 +1,319 @@
+package scanner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TCP states as numbered by the kernel's inet_sock_set_state tracepoint.
+const (
+	tcpEstablished = 1
+	tcpSynSent     = 2
+	tcpSynRecv     = 3
+	tcpClose       = 7
+	tcpListen      = 10
+)
+
+// traceExpiry drops half-tracked connections whose close was never seen,
+// and listeners neither traced nor scanned for as long. Connections open
+// longer than that show up in scans anyway.
+const traceExpiry = 5 * time.Minute
+
+// traceStartTimeout is how long bpftrace gets to compile and attach.
+const traceStartTimeout = 30 * time.Second
+
+// traceStderrMax caps how much of bpftrace's stderr is kept for errors.
+const traceStderrMax = 4096
+
+// traceScript prints every TCP state change with the task it ran in and
+// the socket's address, which identifies a connection before its local
+// port is bound. Comm goes last since it may contain spaces. BEGIN runs
+// once every probe is attached.
+const traceScript = `BEGIN { printf("ready\n"); }
+tracepoint:sock:inet_sock_set_state /args->protocol == 6/ {
+	printf("%d %d %d %d %d %s %s %s %s %d %d %llu %s\n", pid, uid, args->family, args->oldstate, args->newstate,
+		ntop(args->saddr), ntop(args->saddr_v6), ntop(args->daddr), ntop(args->daddr_v6),
+		args->sport, args->dport, (uint64)args->skaddr, comm);
+}`
+
+// TracedConn is a connection seen by the tracer from open to close.
+type TracedConn struct {
+	PID    int32
+	Comm   string
+	UID    uint32
+	Conn   Connection
+	Opened time.Time
+	Closed time.Time
+}
+
+type traceOwner struct {
+	pid  int32
+	uid  uint32
+	comm string
+	seen time.Time // Last traced or scanned listening
+}
+
+// Tracer attributes short-lived TCP connections to processes by tracing
+// socket state changes with eBPF (through bpftrace), catching connections
+// that open and close between two scans. Needs root and bpftrace.
+type Tracer struct {
+	cmd    *exec.Cmd
+	stderr tailWriter
+
+	mu        sync.Mutex
+	pending   map[string]*TracedConn // outbound, by socket until established
+	open      map[string]*TracedConn // by socket
+	listeners map[uint32]traceOwner  // by port, for attributing accepted connections
+	closed    []TracedConn
+	swept     time.Time
+	stopping  bool
+	err       error // Why bpftrace exited
+}
+
+// NewTracer starts tracing. Close stops it.
+func NewTracer() (*Tracer, error) {
+	path, err := exec.LookPath("bpftrace")
+	if err != nil {
+		return nil, fmt.Errorf("eBPF tracing needs bpftrace: %w", err)
+	}
+
+	t := &Tracer{
+		cmd:       exec.Command(path, "-q", "-e", traceScript),
+		pending:   make(map[string]*TracedConn),
+		open:      make(map[string]*TracedConn),
+		listeners: make(map[uint32]traceOwner),
+	}
+	t.stderr.max = traceStderrMax
+	t.cmd.Stderr = &t.stderr
+	out, err := t.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := t.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start bpftrace: %w", err)
+	}
+
+	ready := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		sc := bufio.NewScanner(out)
+		started := false
+		for sc.Scan() {
+			if !started && sc.Text() == "ready" {
+				started = true
+				close(ready)
+				continue
+			}
+			t.handle(sc.Text(), time.Now())
+		}
+		err := t.cmd.Wait()
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.stopping {
+			return
+		}
+		// bpftrace explains failures (not root, no BTF...) on stderr
+		if msg := strings.TrimSpace(t.stderr.String()); msg != "" {
+			err = errors.New(msg)
+		} else if err == nil {
+			err = errors.New("exited")
+		}
+		t.err = fmt.Errorf("bpftrace: %w", err)
+	}()
+
+	select {
+	case <-ready:
+		return t, nil
+	case <-exited:
+		return nil, t.Err()
+	case <-time.After(traceStartTimeout):
+		t.Close()
+		return nil, errors.New("bpftrace: timed out attaching probes")
+	}
+}
+
+func (t *Tracer) Close() {
+	t.mu.Lock()
+	t.stopping = true
+	t.mu.Unlock()
+	if t.cmd.Process != nil {
+		t.cmd.Process.Kill()
+	}
+}
+
+// Err returns why tracing stopped, or nil while it runs.
+func (t *Tracer) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+// tailWriter keeps the last max bytes written to it.
+type tailWriter struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	if len(w.buf) > w.max {
+		w.buf = w.buf[len(w.buf)-w.max:]
+	}
+	return len(p), nil
+}
+
+func (w *tailWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return string(w.buf)
+}
+
+// handle applies one state change line from the trace script.
+func (t *Tracer) handle(line string, now time.Time) {
+	f := strings.SplitN(line, " ", 13)
+	if len(f) < 13 {
+		return
+	}
+	n := make([]int, 11)
+	for i := range n {
+		if i >= 5 && i <= 8 {
+			continue // addresses
+		}
+		v, err := strconv.Atoi(f[i])
+		if err != nil {
+			return
+		}
+		n[i] = v
+	}
+	owner := traceOwner{pid: int32(n[0]), uid: uint32(n[1]), comm: f[12], seen: now}
+	sk := f[11]
+	oldState, newState := n[3], n[4]
+	proto, saddr, daddr := "tcp", f[5], f[7]
+	if n[2] == 10 { // AF_INET6
+		proto, saddr, daddr = "tcp6", f[6], f[8]
+	}
+	sport, dport := uint32(n[9]), uint32(n[10])
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch {
+	case newState == tcpListen:
+		t.listeners[sport] = owner
+	case oldState == tcpListen && newState == tcpClose:
+		delete(t.listeners, sport)
+
+	case newState == tcpSynSent:
+		// connect() runs in the caller's context, before the local port is
+		// bound, so the connection is known by its socket until established
+		t.pending[sk] = &TracedConn{
+			PID: owner.pid, Comm: owner.comm, UID: owner.uid, Opened: now,
+			Conn: Connection{Protocol: proto, RemoteAddr: daddr, RemotePort: dport},
+		}
+	case oldState == tcpSynSent && newState == tcpEstablished:
+		// Runs in softirq, in no particular task, but on the same socket
+		if c, ok := t.pending[sk]; ok {
+			c.Conn.LocalAddr, c.Conn.Port = saddr, sport
+			t.open[sk] = c
+			delete(t.pending, sk)
+		}
+	case oldState == tcpSynRecv && newState == tcpEstablished:
+		// Accepted connections belong to whoever listens on the port
+		if l, ok := t.listeners[sport]; ok {
+			t.open[sk] = &TracedConn{
+				PID: l.pid, Comm: l.comm, UID: l.uid, Opened: now,
+				Conn: Connection{Protocol: proto, LocalAddr: saddr, Port: sport, RemoteAddr: daddr, RemotePort: dport},
+			}
+		}
+
+	case newState == tcpClose:
+		delete(t.pending, sk)
+		if c, ok := t.open[sk]; ok {
+			c.Closed = now
+			c.Conn.Status = "CLOSED"
+			t.closed = append(t.closed, *c)
+			delete(t.open, sk)
+		}
+	}
+
+	if now.Sub(t.swept) > traceExpiry/5 {
+		t.sweep(now)
+	}
+}
+
+// sweep drops connections and listeners older than traceExpiry. Called
+// with t.mu held.
+func (t *Tracer) sweep(now time.Time) {
+	t.swept = now
+	for _, m := range []map[string]*TracedConn{t.pending, t.open} {
+		for key, c := range m {
+			if now.Sub(c.Opened) > traceExpiry {
+				delete(m, key)
+			}
+		}
+	}
+	for port, l := range t.listeners {
+		if now.Sub(l.seen) > traceExpiry {
+			delete(t.listeners, port)
+		}
+	}
+}
+
+// Attribute adds the connections closed since the last call to their
+// processes, with status CLOSED. Processes that already exited are added
+// back so their connections aren't lost.
+func (t *Tracer) Attribute(procs []ProcessInfo) []ProcessInfo {
+	now := time.Now()
+	t.mu.Lock()
+	closed := t.closed
+	t.closed = nil
+	// Listeners still in the scan don't expire, however quiet they are
+	for _, p := range procs {
+		for _, c := range p.Connections {
+			if l, ok := t.listeners[c.Port]; ok && c.Status == "LISTEN" {
+				l.seen = now
+				t.listeners[c.Port] = l
+			}
+		}
+	}
+	t.mu.Unlock()
+
+	if len(closed) == 0 {
+		return procs
+	}
+
+	byPID := make(map[int32]int, len(procs))
+	for i, p := range procs {
+		byPID[p.PID] = i
+	}
+	current, _ := user.Current()
+
+	for _, c := range closed {
+		i, ok := byPID[c.PID]
+		if !ok {
+			p := ProcessInfo{PID: c.PID, Name: c.Comm, User: "unknown", Type: SystemProcess, AppType: "Exited"}
+			if u, err := user.LookupId(strconv.FormatUint(uint64(c.UID), 10)); err == nil {
+				p.User = u.Username
+			}
+			if current != nil && sameAccount(p.User, current.Username) {
+				p.Type = UserProcess
+			}
+			procs = append(procs, p)
+			i = len(procs) - 1
+			byPID[c.PID] = i
+		}
+		procs[i].Connections = append(procs[i].Connections, c.Conn)
+	}
+	return procs
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package scanner
+
+import "errors"
+
+// Tracer is only available on Linux.
+type Tracer struct{}
+
+func NewTracer() (*Tracer, error) {
+	return nil, errors.New("eBPF tracing is only supported on Linux")
+}
+
+func (t *Tracer) Close() {}
+
+func (t *Tracer) Err() error { return nil }
+
+func (t *Tracer) Attribute(procs []ProcessInfo) []ProcessInfo { return procs }
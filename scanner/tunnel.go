@@ -0,0 +1,134 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// TunnelKind is the ssh option that set up a port forward.
+type TunnelKind string
+
+const (
+	LocalTunnel   TunnelKind = "local"   // -L: listens here, connects from the server
+	RemoteTunnel  TunnelKind = "remote"  // -R: listens on the server, connects from here
+	DynamicTunnel TunnelKind = "dynamic" // -D: a SOCKS proxy listening here
+)
+
+// Tunnel is a port forward of an ssh client.
+type Tunnel struct {
+	Kind     TunnelKind
+	BindAddr string // Empty when not given
+	Port     uint32 // Forwarded port, on this machine for local and dynamic forwards
+	Host     string // Destination; empty for dynamic forwards
+	HostPort uint32
+}
+
+// sshArgFlags are the ssh options that take an argument.
+const sshArgFlags = "BbcDEeFIiJLlmOopQRSWw"
+
+// SSHTunnels parses the -L, -R and -D port forwards from an ssh command
+// line. Forwards to or from Unix sockets are left out.
+func SSHTunnels(cmdline string) []Tunnel {
+	args := strings.Fields(cmdline)
+	if len(args) == 0 {
+		return nil
+	}
+	if name := strings.TrimSuffix(filepath.Base(args[0]), ".exe"); name != "ssh" {
+		return nil
+	}
+
+	var tunnels []Tunnel
+	destination := false
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if len(arg) < 2 || arg[0] != '-' {
+			// Options may follow the destination, but not the remote command
+			if destination {
+				break
+			}
+			destination = true
+			continue
+		}
+		// Flags combine, as in -fNL 9000:db:5432, until one takes an argument
+		for j := 1; j < len(arg); j++ {
+			flag := arg[j]
+			if !strings.ContainsRune(sshArgFlags, rune(flag)) {
+				continue
+			}
+			value := arg[j+1:]
+			if value == "" && i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+			if t, ok := parseForward(flag, value); ok {
+				tunnels = append(tunnels, t)
+			}
+			break
+		}
+	}
+	return tunnels
+}
+
+// parseForward parses the argument of -L, -R or -D:
+// [bind_address:]port[:host:hostport].
+func parseForward(flag byte, spec string) (Tunnel, bool) {
+	var t Tunnel
+	switch flag {
+	case 'L':
+		t.Kind = LocalTunnel
+	case 'R':
+		t.Kind = RemoteTunnel
+	case 'D':
+		t.Kind = DynamicTunnel
+	default:
+		return t, false
+	}
+
+	fields := splitForward(spec)
+	if len(fields) == 1 || len(fields) == 3 {
+		// No bind address
+		fields = append([]string{""}, fields...)
+	}
+	switch {
+	case len(fields) == 2 && t.Kind != LocalTunnel:
+		// -D, and -R acting as a SOCKS proxy on the server
+	case len(fields) == 4 && t.Kind != DynamicTunnel:
+		port, err := strconv.ParseUint(fields[3], 10, 16)
+		if err != nil {
+			return t, false
+		}
+		t.Host, t.HostPort = fields[2], uint32(port)
+	default:
+		return t, false
+	}
+
+	port, err := strconv.ParseUint(fields[1], 10, 16)
+	if err != nil {
+		return t, false
+	}
+	t.BindAddr, t.Port = fields[0], uint32(port)
+	return t, true
+}
+
+// splitForward splits a forward spec at its colons, except those inside
+// the brackets around IPv6 addresses, and removes the brackets.
+func splitForward(spec string) []string {
+	var fields []string
+	var field strings.Builder
+	inBrackets := false
+	for _, r := range spec {
+		switch {
+		case r == '[':
+			inBrackets = true
+		case r == ']':
+			inBrackets = false
+		case r == ':' && !inBrackets:
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteRune(r)
+		}
+	}
+	return append(fields, field.String())
+}
@@ -0,0 +1,43 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// detectUnits fills Unit with the launchd job label of processes started
+// by launchd, from `launchctl list`. Run as root it lists system daemons,
+// otherwise the current user's agents.
+func detectUnits(procs []ProcessInfo) {
+	out, err := exec.Command("launchctl", "list").Output()
+	if err != nil {
+		return
+	}
+
+	labels := make(map[int32]string)
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		// PID  Status  Label
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		pid, err := strconv.ParseInt(fields[0], 10, 32)
+		if err != nil {
+			continue // "-" for jobs that aren't running
+		}
+		labels[int32(pid)] = fields[2]
+	}
+
+	user := os.Geteuid() != 0
+	for i := range procs {
+		if label, ok := labels[procs[i].PID]; ok {
+			procs[i].Unit = label
+			procs[i].UserUnit = user
+		}
+	}
+}
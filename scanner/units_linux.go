@@ -0,0 +1,44 @@
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// detectUnits fills Unit for processes running in a systemd service,
+// read from their cgroup path, e.g.
+// /system.slice/nginx.service or /user.slice/user-1000.slice/user@1000.service/app.slice/vite.service.
+func detectUnits(procs []ProcessInfo) {
+	for i := range procs {
+		p := &procs[i]
+		if p.KernelThread {
+			continue
+		}
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", p.PID))
+		if err != nil {
+			continue
+		}
+		// cgroup v2 has a single "0::<path>" line; with v1 the name=systemd
+		// hierarchy carries the unit
+		var path string
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			if bytes.HasPrefix(line, []byte("0::")) || bytes.Contains(line, []byte(":name=systemd:")) {
+				path = string(line[bytes.LastIndexByte(line, ':')+1:])
+				break
+			}
+		}
+
+		user := strings.Contains(path, "/user@")
+		parts := strings.Split(path, "/")
+		for j := len(parts) - 1; j >= 0; j-- {
+			unit := parts[j]
+			if strings.HasSuffix(unit, ".service") && !strings.HasPrefix(unit, "user@") {
+				p.Unit = unit
+				p.UserUnit = user
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !linux && !darwin
+
+package scanner
+
+// detectUnits is a no-op where there is no systemd or launchd.
+func detectUnits(procs []ProcessInfo) {}
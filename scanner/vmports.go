@@ -0,0 +1,140 @@
+package scanner
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// VMForwarder is a host process forwarding the published ports of
+// containers running inside a VM, which would otherwise look like the
+// owner of all of them.
+type VMForwarder struct {
+	Runtime  string // podman, lima, colima or docker (Docker Desktop)
+	Instance string // Lima instance name
+}
+
+// PublishedPort is a container port published on the host.
+type PublishedPort struct {
+	Container     string
+	HostPort      uint32
+	ContainerPort uint32
+	Protocol      string // tcp or udp
+}
+
+// VMForwarderOf recognizes the port forwarders of podman machine
+// (gvproxy), Lima and Colima (limactl hostagent) and Docker Desktop.
+func VMForwarderOf(name, cmdline string) (VMForwarder, bool) {
+	name = strings.TrimSuffix(name, ".exe")
+	switch name {
+	case "gvproxy":
+		return VMForwarder{Runtime: "podman"}, true
+	case "com.docker.backend", "com.docker.vpnkit", "vpnkit", "vpnkit-bridge":
+		return VMForwarder{Runtime: "docker"}, true
+	case "limactl":
+		args := strings.Fields(cmdline)
+		if len(args) < 3 || filepath.Base(args[1]) != "hostagent" {
+			return VMForwarder{}, false
+		}
+		// The instance is the last argument
+		instance := args[len(args)-1]
+		if strings.HasPrefix(instance, "colima") {
+			return VMForwarder{Runtime: "colima", Instance: instance}, true
+		}
+		return VMForwarder{Runtime: "lima", Instance: instance}, true
+	}
+	return VMForwarder{}, false
+}
+
+// PublishedPorts lists the published ports of the running containers of
+// f, through the podman or docker CLI, or nerdctl inside a Lima VM.
+// Colima instances other than the default one need the matching docker
+// context to be active.
+func PublishedPorts(f VMForwarder) ([]PublishedPort, error) {
+	format := "{{.Names}}\t{{.Ports}}"
+	var cmd *exec.Cmd
+	switch f.Runtime {
+	case "podman":
+		cmd = exec.Command("podman", "ps", "--format", format)
+	case "docker", "colima":
+		cmd = exec.Command("docker", "ps", "--format", format)
+	case "lima":
+		cmd = exec.Command("limactl", "shell", f.Instance, "nerdctl", "ps", "--format", format)
+	default:
+		return nil, fmt.Errorf("unknown container runtime %q", f.Runtime)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s ps failed: %w", f.Runtime, err)
+	}
+
+	var ports []PublishedPort
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		name, spec, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		ports = append(ports, parsePublished(name, spec)...)
+	}
+	return ports, nil
+}
+
+// parsePublished parses the Ports column of `docker ps`, e.g.
+// "0.0.0.0:8080->80/tcp, :::8080->80/tcp, 0.0.0.0:7000-7001->7000-7001/udp".
+// Exposed but unpublished ports ("5432/tcp") are skipped.
+func parsePublished(container, spec string) []PublishedPort {
+	var ports []PublishedPort
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		host, target, ok := strings.Cut(strings.TrimSpace(entry), "->")
+		if !ok {
+			continue
+		}
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[i+1:]
+		}
+		target, proto, _ := strings.Cut(target, "/")
+		if proto == "" {
+			proto = "tcp"
+		}
+		hostFrom, hostTo, ok1 := portRange(host)
+		targetFrom, _, ok2 := portRange(target)
+		if !ok1 || !ok2 {
+			continue
+		}
+		for port := hostFrom; port <= hostTo; port++ {
+			key := fmt.Sprintf("%d/%s", port, proto)
+			if seen[key] {
+				// Listed again for IPv6
+				continue
+			}
+			seen[key] = true
+			ports = append(ports, PublishedPort{
+				Container:     container,
+				HostPort:      port,
+				ContainerPort: targetFrom + port - hostFrom,
+				Protocol:      proto,
+			})
+		}
+	}
+	return ports
+}
+
+// portRange parses "80" or "7000-7001".
+func portRange(s string) (uint32, uint32, bool) {
+	from, to, isRange := strings.Cut(s, "-")
+	a, err := strconv.ParseUint(from, 10, 16)
+	if err != nil {
+		return 0, 0, false
+	}
+	if !isRange {
+		return uint32(a), uint32(a), true
+	}
+	b, err := strconv.ParseUint(to, 10, 16)
+	if err != nil || b < a {
+		return 0, 0, false
+	}
+	return uint32(a), uint32(b), true
+}
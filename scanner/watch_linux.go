@@ -0,0 +1,283 @@
+package scanner
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	sockDiagByFamily = 20 // SOCK_DIAG_BY_FAMILY netlink message type
+	tcpListenState   = 10 // TCP_LISTEN
+	tcpCloseState    = 7  // TCP_CLOSE, the state of UDP sockets without a peer
+	inetDiagMsgInode = 68 // offset of idiag_inode in struct inet_diag_msg
+
+	netlinkConnector  = 11 // NETLINK_CONNECTOR
+	cnIdxProc         = 1  // CN_IDX_PROC
+	cnValProc         = 1  // CN_VAL_PROC
+	procCnMcastListen = 1  // PROC_CN_MCAST_LISTEN
+	procEventExit     = 0x80000000
+	cnMsgLen          = 20 // sizeof(struct cn_msg)
+)
+
+// listenerPoll is how often the listening socket set is compared. A
+// sock_diag dump of listeners costs far less than a full scan.
+const listenerPoll = 100 * time.Millisecond
+
+// minChangeGap is the least time between two signals on Changes, so a
+// program opening and closing sockets in a loop can't trigger a full scan
+// every poll. Changes within the gap are delivered together at its end.
+const minChangeGap = time.Second
+
+// Watcher signals on Changes as soon as a listening socket opens or closes,
+// or a watched process exits, so a scan can run right away instead of
+// waiting for the next poll. Listeners are tracked through netlink
+// sock_diag; process exits through the proc connector, which needs root
+// and is skipped otherwise.
+type Watcher struct {
+	changes chan struct{}
+	done    chan struct{}
+
+	mu      sync.Mutex
+	watched map[int32]bool
+	pending bool      // A change waits for minChangeGap to pass
+	last    time.Time // When Changes was last signaled
+
+	procFD int
+}
+
+// NewWatcher starts watching. Close stops it.
+func NewWatcher() (*Watcher, error) {
+	w := &Watcher{
+		changes: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		procFD:  -1,
+	}
+
+	initial, err := listenerSet()
+	if err != nil {
+		return nil, err
+	}
+	go w.pollListeners(initial)
+
+	if fd, err := procConnector(); err == nil {
+		w.procFD = fd
+		go w.readProcEvents()
+	}
+	return w, nil
+}
+
+// Changes delivers a signal per burst of changes, at most one per
+// minChangeGap; signals are coalesced while nobody is receiving.
+func (w *Watcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+// Watch sets the PIDs whose exit counts as a change, typically those
+// owning sockets in the latest scan.
+func (w *Watcher) Watch(pids []int32) {
+	set := make(map[int32]bool, len(pids))
+	for _, pid := range pids {
+		set[pid] = true
+	}
+	w.mu.Lock()
+	w.watched = set
+	w.mu.Unlock()
+}
+
+func (w *Watcher) Close() {
+	close(w.done)
+}
+
+// signal records a change, delivered by flush.
+func (w *Watcher) signal() {
+	w.mu.Lock()
+	w.pending = true
+	w.mu.Unlock()
+}
+
+// flush signals Changes of a pending change once minChangeGap has passed
+// since the last signal.
+func (w *Watcher) flush(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.pending || now.Sub(w.last) < minChangeGap {
+		return
+	}
+	w.pending = false
+	w.last = now
+	select {
+	case w.changes <- struct{}{}:
+	default:
+	}
+}
+
+func (w *Watcher) pollListeners(last []string) {
+	t := time.NewTicker(listenerPoll)
+	defer t.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-t.C:
+		}
+		if curr, err := listenerSet(); err == nil && !slices.Equal(curr, last) {
+			last = curr
+			w.signal()
+		}
+		// Also delivers the process exits seen by readProcEvents
+		w.flush(time.Now())
+	}
+}
+
+func (w *Watcher) readProcEvents() {
+	defer syscall.Close(w.procFD)
+
+	buf := make([]byte, 4096)
+	ne := binary.NativeEndian
+	for {
+		select {
+		case <-w.done:
+			return
+		default:
+		}
+		n, _, err := syscall.Recvfrom(w.procFD, buf, 0)
+		if err != nil {
+			// EAGAIN is the receive timeout, giving Close a chance to stop us
+			if err == syscall.EAGAIN || err == syscall.EINTR || err == syscall.ENOBUFS {
+				continue
+			}
+			return
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			continue
+		}
+		for _, msg := range msgs {
+			// struct cn_msg, then struct proc_event: what, cpu, timestamp, exit{pid, tgid}
+			ev := msg.Data
+			if len(ev) < cnMsgLen+24 || ne.Uint32(ev[cnMsgLen:]) != procEventExit {
+				continue
+			}
+			pid := int32(ne.Uint32(ev[cnMsgLen+16:]))
+			tgid := int32(ne.Uint32(ev[cnMsgLen+20:]))
+			if pid != tgid {
+				continue // a thread, not the process
+			}
+			w.mu.Lock()
+			hit := w.watched[pid]
+			w.mu.Unlock()
+			if hit {
+				w.signal()
+			}
+		}
+	}
+}
+
+// listenerSet returns a sorted fingerprint of every listening TCP socket
+// and every UDP socket without a peer, from sock_diag dumps. Connected UDP
+// sockets, like those of DNS lookups, come and go too often to matter.
+func listenerSet() ([]string, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_INET_DIAG)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.Close(fd)
+
+	var set []string
+	for _, family := range []byte{syscall.AF_INET, syscall.AF_INET6} {
+		for _, proto := range []byte{syscall.IPPROTO_TCP, syscall.IPPROTO_UDP} {
+			states := uint32(1 << tcpCloseState)
+			if proto == syscall.IPPROTO_TCP {
+				states = 1 << tcpListenState
+			}
+			inodes, err := diagDump(fd, family, proto, states)
+			if err != nil {
+				return nil, err
+			}
+			set = append(set, inodes...)
+		}
+	}
+	slices.Sort(set)
+	return set, nil
+}
+
+// diagDump lists the "proto/port/inode" of sockets in the given states.
+func diagDump(fd int, family, proto byte, states uint32) ([]string, error) {
+	ne := binary.NativeEndian
+	req := make([]byte, nlmsgHdrLen+inetDiagReqV2Len)
+	ne.PutUint32(req[0:], uint32(len(req)))
+	ne.PutUint16(req[4:], sockDiagByFamily)
+	ne.PutUint16(req[6:], syscall.NLM_F_REQUEST|syscall.NLM_F_DUMP)
+	r := req[nlmsgHdrLen:]
+	r[0] = family
+	r[1] = proto
+	ne.PutUint32(r[4:], states)
+
+	if err := syscall.Sendto(fd, req, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return nil, err
+	}
+
+	var out []string
+	buf := make([]byte, 32*1024)
+	for {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return nil, err
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range msgs {
+			switch msg.Header.Type {
+			case syscall.NLMSG_DONE:
+				return out, nil
+			case syscall.NLMSG_ERROR:
+				return nil, syscall.EINVAL
+			}
+			if len(msg.Data) < inetDiagMsgInode+4 {
+				continue
+			}
+			port := binary.BigEndian.Uint16(msg.Data[4:])
+			inode := ne.Uint32(msg.Data[inetDiagMsgInode:])
+			out = append(out, fmt.Sprintf("%d/%d/%d", proto, port, inode))
+		}
+	}
+}
+
+// procConnector subscribes to process events from the kernel.
+func procConnector() (int, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, netlinkConnector)
+	if err != nil {
+		return -1, err
+	}
+	tv := syscall.Timeval{Sec: 1}
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		syscall.Close(fd)
+		return -1, err
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: cnIdxProc}); err != nil {
+		syscall.Close(fd)
+		return -1, err
+	}
+
+	ne := binary.NativeEndian
+	msg := make([]byte, nlmsgHdrLen+cnMsgLen+4)
+	ne.PutUint32(msg[0:], uint32(len(msg)))
+	ne.PutUint16(msg[4:], syscall.NLMSG_DONE)
+	cn := msg[nlmsgHdrLen:]
+	ne.PutUint32(cn[0:], cnIdxProc)
+	ne.PutUint32(cn[4:], cnValProc)
+	ne.PutUint16(cn[16:], 4) // len
+	ne.PutUint32(cn[cnMsgLen:], procCnMcastListen)
+
+	if err := syscall.Sendto(fd, msg, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		syscall.Close(fd)
+		return -1, err
+	}
+	return fd, nil
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package scanner
+
+import "errors"
+
+// Watcher is only available on Linux; elsewhere scans are poll-driven.
+type Watcher struct{}
+
+func NewWatcher() (*Watcher, error) {
+	return nil, errors.New("event-driven updates are only supported on Linux")
+}
+
+func (w *Watcher) Changes() <-chan struct{} { return nil }
+
+func (w *Watcher) Watch(pids []int32) {}
+
+func (w *Watcher) Close() {}
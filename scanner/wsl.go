@@ -0,0 +1,113 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// WSL describes the Windows Subsystem for Linux environment the scanner
+// runs in.
+type WSL struct {
+	Version    int    // 1 or 2
+	Distro     string // WSL_DISTRO_NAME
+	Networking string // "nat" or "mirrored"; WSL 1 shares the Windows stack and reports "mirrored"
+}
+
+// DetectWSL returns the WSL environment, or nil when not running under WSL.
+func DetectWSL() *WSL {
+	release, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil || !strings.Contains(strings.ToLower(string(release)), "microsoft") {
+		return nil
+	}
+
+	w := &WSL{Version: 2, Distro: os.Getenv("WSL_DISTRO_NAME"), Networking: "nat"}
+	if !strings.Contains(strings.ToLower(string(release)), "wsl2") && !strings.Contains(string(release), "microsoft-standard") {
+		w.Version = 1
+		w.Networking = "mirrored"
+		return w
+	}
+
+	// wslinfo ships with WSL 2.0.9 and later; older releases only have NAT
+	if out, err := exec.Command("wslinfo", "--networking-mode").Output(); err == nil {
+		if mode := strings.TrimSpace(string(out)); mode != "" {
+			w.Networking = mode
+		}
+	}
+	return w
+}
+
+// Forwarded reports whether a Linux listener is reachable from the Windows
+// side. Mirrored networking shares every listener; NAT mode forwards those
+// bound to loopback or a wildcard address to Windows localhost.
+func (w *WSL) Forwarded(c Connection) bool {
+	if w == nil || c.Status != "LISTEN" {
+		return false
+	}
+	if w.Networking == "mirrored" {
+		return true
+	}
+	switch c.LocalAddr {
+	case "", "0.0.0.0", "::", "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
+
+// WindowsListeners lists the Windows processes listening on TCP ports,
+// keyed by port, through WSL interop (netstat.exe and tasklist.exe).
+func WindowsListeners() (map[uint32]string, error) {
+	out, err := exec.Command("netstat.exe", "-ano", "-p", "TCP").Output()
+	if err != nil {
+		return nil, err
+	}
+	pids := parseNetstat(out)
+
+	names := make(map[string]string)
+	if out, err := exec.Command("tasklist.exe", "/FO", "CSV", "/NH").Output(); err == nil {
+		records, _ := csv.NewReader(bytes.NewReader(out)).ReadAll()
+		for _, r := range records {
+			if len(r) >= 2 {
+				names[r[1]] = r[0]
+			}
+		}
+	}
+
+	owners := make(map[uint32]string, len(pids))
+	for port, pid := range pids {
+		owner := "pid " + pid
+		if name, ok := names[pid]; ok {
+			owner = name + " " + pid
+		}
+		owners[port] = owner
+	}
+	return owners, nil
+}
+
+// parseNetstat maps listening ports to owning PIDs from `netstat -ano`
+// output lines like "TCP  0.0.0.0:135  0.0.0.0:0  LISTENING  1234".
+func parseNetstat(out []byte) map[uint32]string {
+	pids := make(map[uint32]string)
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 5 || fields[0] != "TCP" || fields[3] != "LISTENING" {
+			continue
+		}
+		local := fields[1]
+		i := strings.LastIndex(local, ":")
+		if i < 0 {
+			continue
+		}
+		port, err := strconv.ParseUint(local[i+1:], 10, 16)
+		if err != nil {
+			continue
+		}
+		pids[uint32(port)] = fields[4]
+	}
+	return pids
+}
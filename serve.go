@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"port-monitor/api"
+	"port-monitor/scanner"
+	"port-monitor/web"
+)
+
+// runServe exposes the scanner as a local REST API for other tooling, plus
+// a WebSocket stream of scan changes on /events.
+func runServe(args []string) error {
+	return serve("serve", args, false)
+}
+
+// runWeb is serve plus a small browser dashboard on /.
+func runWeb(args []string) error {
+	return serve("web", args, true)
+}
+
+func serve(name string, args []string, withUI bool) error {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	addr := fs.String("api", "localhost:7070", "address to serve on")
+	interval := fs.Duration("interval", 3*time.Second, "time between scans for /events")
+	fs.Parse(args)
+
+	token, tokenPath, err := writeAPIToken()
+	if err != nil {
+		return err
+	}
+
+	hub := api.NewHub()
+	go publishScans(hub, *interval)
+
+	mux := http.NewServeMux()
+	mux.Handle("/", api.NewHandler(newLocalSource(scanner.Options{})))
+	mux.Handle("GET /events", hub)
+	if withUI {
+		mux.Handle("GET /{$}", web.Handler())
+		log.Printf("dashboard on http://%s/?token=%s", *addr, token)
+	}
+
+	log.Printf("serving API on http://%s (events on ws://%s/events)", *addr, *addr)
+	log.Printf("requests need \"Authorization: Bearer <token>\", the token is in %s", tokenPath)
+	return http.ListenAndServe(*addr, api.Guard(mux, token))
+}
+
+// writeAPIToken makes a token for this session of the API and saves it
+// where only this user can read it.
+func writeAPIToken() (string, string, error) {
+	token, err := api.NewToken()
+	if err != nil {
+		return "", "", err
+	}
+	path, err := logPath("api-token")
+	if err != nil {
+		return "", "", err
+	}
+	os.Remove(path) // WriteFile keeps the mode of an existing file
+	if err := os.WriteFile(path, []byte(token+"\n"), 0o600); err != nil {
+		return "", "", err
+	}
+	return token, path, nil
+}
+
+// publishScans scans forever and publishes what changed between scans.
+func publishScans(hub *api.Hub, interval time.Duration) {
+	s := scanner.New(scanner.Options{Interval: interval})
+	for {
+		events, err := s.Watch(context.Background())
+		if err != nil {
+			log.Printf("scan failed: %v", err)
+			time.Sleep(interval)
+			continue
+		}
+		for e := range events {
+			hub.Publish([]scanner.Event{e})
+		}
+	}
+}
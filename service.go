@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"port-monitor/scanner"
+)
+
+// serviceAction is a pending restart or stop of the unit a process runs in.
+type serviceAction struct {
+	unit   string
+	action string // restart or stop
+	args   []string
+}
+
+type serviceDoneMsg struct {
+	serviceAction
+	err error
+}
+
+// newServiceAction prepares action for the unit of p. Supervised daemons
+// are respawned when killed, so they are restarted or stopped through
+// systemctl/launchctl instead.
+func newServiceAction(p scanner.ProcessInfo, action string) (*serviceAction, error) {
+	cmd, err := scanner.ServiceCommand(p, action)
+	if err != nil {
+		return nil, err
+	}
+	return &serviceAction{unit: p.Unit, action: action, args: cmd.Args}, nil
+}
+
+// run suspends the TUI while the service manager runs, since sudo or
+// polkit may ask for a password.
+func (a serviceAction) run() tea.Cmd {
+	cmd := exec.Command(a.args[0], a.args[1:]...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return serviceDoneMsg{serviceAction: a, err: err}
+	})
+}
+
+func (a serviceAction) String() string {
+	return strings.Join(a.args, " ")
+}
+
+// unitLabel describes the service manager unit of p for the detail pane.
+func unitLabel(p scanner.ProcessInfo) string {
+	if p.Unit == "" {
+		return ""
+	}
+	if p.UserUnit {
+		return fmt.Sprintf("unit %s, user", p.Unit)
+	}
+	return "unit " + p.Unit
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"port-monitor/scanner"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type signaturesResolvedMsg struct{}
+
+type signatureEntry struct {
+	sig     scanner.Signature
+	ok      bool
+	size    int64
+	modTime time.Time
+}
+
+// signatureCache holds the hash and code signature of executables, keyed
+// by path. An executable replaced in place is hashed again.
+type signatureCache struct {
+	mu      sync.Mutex
+	entries map[string]signatureEntry
+	pending map[string]bool
+}
+
+func newSignatureCache() *signatureCache {
+	return &signatureCache{
+		entries: make(map[string]signatureEntry),
+		pending: make(map[string]bool),
+	}
+}
+
+// of returns the signature of exe if it was computed.
+func (c *signatureCache) of(exe string) (scanner.Signature, bool) {
+	if c == nil {
+		return scanner.Signature{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entries[exe]
+	return e.sig, e.ok
+}
+
+// resolveCmd hashes the executables of processes with connections that
+// are new or changed on disk since they were hashed, one at a time.
+func (c *signatureCache) resolveCmd(procs []scanner.ProcessInfo) tea.Cmd {
+	var exes []string
+	pids := make(map[string]int32) // A process running each exe
+	c.mu.Lock()
+	for _, p := range procs {
+		if p.Exe == "" || len(p.Connections) == 0 || c.pending[p.Exe] {
+			continue
+		}
+		if e, ok := c.entries[p.Exe]; ok {
+			info, err := os.Stat(p.Exe)
+			if err != nil || (info.Size() == e.size && info.ModTime().Equal(e.modTime)) {
+				continue
+			}
+		}
+		c.pending[p.Exe] = true
+		exes = append(exes, p.Exe)
+		pids[p.Exe] = p.PID
+	}
+	c.mu.Unlock()
+
+	if len(exes) == 0 {
+		return nil
+	}
+
+	return func() tea.Msg {
+		for _, exe := range exes {
+			var e signatureEntry
+			if info, err := os.Stat(exe); err == nil {
+				e.size, e.modTime = info.Size(), info.ModTime()
+			}
+			sig, err := scanner.SignatureOf(pids[exe], exe)
+			e.sig, e.ok = sig, err == nil
+			c.mu.Lock()
+			c.entries[exe] = e
+			delete(c.pending, exe)
+			c.mu.Unlock()
+		}
+		return signaturesResolvedMsg{}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+
+	"port-monitor/scanner"
+)
+
+// processSource is where scans come from and kills go to: this machine,
+// or a remote host.
+type processSource interface {
+	ScanProcesses() ([]scanner.ProcessInfo, error)
+	KillProcess(pid int32) error
+}
+
+// partialSource is a source telling what its scans couldn't read.
+type partialSource interface {
+	ScanPartial() ([]scanner.ProcessInfo, scanner.Warnings, error)
+}
+
+// scanSource scans src, with warnings when it can tell.
+func scanSource(src processSource) ([]scanner.ProcessInfo, scanner.Warnings, error) {
+	if ps, ok := src.(partialSource); ok {
+		return ps.ScanPartial()
+	}
+	procs, err := src.ScanProcesses()
+	return procs, scanner.Warnings{}, err
+}
+
+// localSource scans this machine.
+type localSource struct {
+	scanner *scanner.Scanner
+}
+
+// newLocalSource returns a source scanning this machine. ListenOnly trades
+// process details for a much faster scan of listening processes only.
+func newLocalSource(opts scanner.Options) localSource {
+	return localSource{scanner: scanner.New(opts)}
+}
+
+func (s localSource) ScanProcesses() ([]scanner.ProcessInfo, error) {
+	return s.scanner.Scan(context.Background())
+}
+
+func (s localSource) ScanPartial() ([]scanner.ProcessInfo, scanner.Warnings, error) {
+	return s.scanner.ScanPartial(context.Background())
+}
+
+func (s localSource) KillProcess(pid int32) error {
+	return s.scanner.Kill(pid)
+}
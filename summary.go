@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"port-monitor/filter"
+	"port-monitor/scanner"
+)
+
+const defaultSummaryFormat = `LISTEN:{{.Listen}} dev:{{.DevPorts}} top:{{.TopName}}({{printf "%.0f" .TopCPU}}%)`
+
+// summaryData is what --format templates can use.
+type summaryData struct {
+	Listen      int    // Listening sockets
+	Established int    // Established connections
+	Ports       string // All listening ports, comma separated
+	DevPorts    string // Listening ports of the current user's processes
+	TopName     string // Process using the most CPU
+	TopPID      int32
+	TopCPU      float64
+}
+
+// runSummary prints a single line for tmux status bars and shell prompts.
+func runSummary(args []string) error {
+	fs := flag.NewFlagSet("summary", flag.ExitOnError)
+	format := fs.String("format", defaultSummaryFormat, "Go text/template for the line")
+	filterExpr := fs.String("filter", "", "only count processes matching an expression")
+	fs.Parse(args)
+
+	match, err := filter.Parse(*filterExpr)
+	if err != nil {
+		return withCode(exitUsage, fmt.Errorf("invalid filter: %w", err))
+	}
+
+	tmpl, err := template.New("summary").Parse(*format)
+	if err != nil {
+		return fmt.Errorf("invalid format: %w", err)
+	}
+
+	procs, err := scanner.Scan(context.Background(), scanner.Options{})
+	if err != nil {
+		return err
+	}
+	procs = slices.DeleteFunc(procs, func(p scanner.ProcessInfo) bool { return !match(p) })
+
+	if err := tmpl.Execute(os.Stdout, summarize(procs)); err != nil {
+		return err
+	}
+	fmt.Println()
+	return nil
+}
+
+func summarize(procs []scanner.ProcessInfo) summaryData {
+	var d summaryData
+	var ports, devPorts []uint32
+	var top *scanner.ProcessInfo
+
+	for i, p := range procs {
+		for _, c := range p.Connections {
+			switch c.Status {
+			case "LISTEN":
+				d.Listen++
+				ports = appendUnique(ports, c.Port)
+				if p.Type == scanner.UserProcess {
+					devPorts = appendUnique(devPorts, c.Port)
+				}
+			case "ESTABLISHED":
+				d.Established++
+			}
+		}
+		if top == nil || p.CPUPercent > top.CPUPercent {
+			top = &procs[i]
+		}
+	}
+
+	d.Ports = joinPorts(ports)
+	d.DevPorts = joinPorts(devPorts)
+	if top != nil {
+		d.TopName, d.TopPID, d.TopCPU = top.Name, top.PID, top.CPUPercent
+	}
+	return d
+}
+
+func appendUnique(ports []uint32, port uint32) []uint32 {
+	if slices.Contains(ports, port) {
+		return ports
+	}
+	return append(ports, port)
+}
+
+func joinPorts(ports []uint32) string {
+	slices.Sort(ports)
+	s := make([]string, len(ports))
+	for i, p := range ports {
+		s[i] = strconv.FormatUint(uint64(p), 10)
+	}
+	return strings.Join(s, ",")
+}
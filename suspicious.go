@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"port-monitor/scanner"
+)
+
+// heuristic is a red flag the security view checks listening processes
+// for. check returns why p is suspicious, or "".
+type heuristic struct {
+	id    string // Config key
+	name  string
+	check func(m *model, p scanner.ProcessInfo) string
+}
+
+var heuristics = []heuristic{
+	{"unsigned-exposed", "Unsigned or unpackaged binary on an external interface", (*model).unsignedExposed},
+	{"temp-dir", "Running from a temp directory", tempDirExe},
+	{"name-mismatch", "Name doesn't match the executable", nameMismatch},
+	{"deleted-exe", "Executable deleted from disk", deletedExe},
+}
+
+// unsignedExposed flags listeners on external interfaces whose executable
+// isn't code signed on macOS, or isn't owned by any package elsewhere.
+func (m *model) unsignedExposed(p scanner.ProcessInfo) string {
+	exposed := false
+	for _, c := range p.Connections {
+		exposed = exposed || c.IsExposed()
+	}
+	if !exposed || p.Exe == "" {
+		return ""
+	}
+	if runtime.GOOS == "darwin" {
+		if sig, ok := m.signatures.of(p.Exe); ok && sig.Signer == "unsigned" {
+			return "unsigned and listening on an external interface"
+		}
+		return ""
+	}
+	if pkg, ok := m.packages.lookup(p.Exe); ok && pkg == "" {
+		return "not installed by a package and listening on an external interface"
+	}
+	return ""
+}
+
+// tempDirExe flags executables under world-writable temp directories.
+func tempDirExe(_ *model, p scanner.ProcessInfo) string {
+	for _, dir := range []string{os.TempDir(), "/tmp", "/var/tmp", "/dev/shm"} {
+		if dir != "" && strings.HasPrefix(p.Exe, filepath.Clean(dir)+string(filepath.Separator)) {
+			return "runs from " + dir
+		}
+	}
+	return ""
+}
+
+// nameMismatch flags processes whose name isn't the base name of their
+// executable, a way to pass for something else. Linux truncates names to
+// 15 bytes, so a prefix either way is a match.
+func nameMismatch(_ *model, p scanner.ProcessInfo) string {
+	if p.Exe == "" || p.Name == "" || p.KernelThread {
+		return ""
+	}
+	exe := strings.TrimSuffix(filepath.Base(strings.TrimSuffix(p.Exe, " (deleted)")), ".exe")
+	name := strings.TrimSuffix(p.Name, ".exe")
+	if strings.HasPrefix(exe, name) || strings.HasPrefix(name, exe) {
+		return ""
+	}
+	return fmt.Sprintf("named %q but runs %s", p.Name, exe)
+}
+
+// deletedExe flags processes whose executable was removed or replaced
+// after they started, as droppers tend to do.
+func deletedExe(_ *model, p scanner.ProcessInfo) string {
+	if strings.HasSuffix(p.Exe, " (deleted)") {
+		return "executable deleted: " + strings.TrimSuffix(p.Exe, " (deleted)")
+	}
+	return ""
+}
+
+// securityReport lists the listening processes each enabled heuristic
+// flags, with the keys toggling them.
+func (m *model) securityReport() string {
+	var b strings.Builder
+	b.WriteString("Suspicious listeners\n")
+	flagged := 0
+	for i, h := range heuristics {
+		state := "on"
+		if m.heuristicsOff[h.id] {
+			state = "off"
+		}
+		fmt.Fprintf(&b, "\n[%d] %s (%s)\n", i+1, h.name, state)
+		if m.heuristicsOff[h.id] {
+			continue
+		}
+		found := false
+		for _, p := range m.processes {
+			if !listensAnywhere(p) {
+				continue
+			}
+			if reason := h.check(m, p); reason != "" {
+				fmt.Fprintf(&b, "  %s (PID %d): %s\n", p.Name, p.PID, reason)
+				found = true
+				flagged++
+			}
+		}
+		if !found {
+			b.WriteString("  none\n")
+		}
+	}
+	if flagged == 0 {
+		b.WriteString("\nNothing flagged. These are hints, not proof either way.")
+	} else {
+		fmt.Fprintf(&b, "\n%d flagged. These are hints, not proof either way.", flagged)
+	}
+	return b.String()
+}
+
+func listensAnywhere(p scanner.ProcessInfo) bool {
+	for _, c := range p.Connections {
+		if c.Status == "LISTEN" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package main
+
+import "port-monitor/filter"
+
+// tabState is what a view tab keeps while the other one is shown: its
+// filters, search, sort and cursor.
+type tabState struct {
+	filterPorts       bool
+	filterExposed     bool
+	filterStalled     bool
+	hideKernelThreads bool
+	hideRootDaemons   bool
+	search            string
+	exprText          string
+	expr              filter.Predicate
+	sortBy            int
+	sortDesc          bool
+	cursorItem        *rowItem // Row under the cursor, nil when the table was empty
+	cursor            int
+}
+
+func (m *model) saveTab() *tabState {
+	st := &tabState{
+		filterPorts:       m.filterPorts,
+		filterExposed:     m.filterExposed,
+		filterStalled:     m.filterStalled,
+		hideKernelThreads: m.hideKernelThreads,
+		hideRootDaemons:   m.hideRootDaemons,
+		search:            m.textInput.Value(),
+		exprText:          m.exprInput.Value(),
+		expr:              m.expr,
+		sortBy:            m.sortBy,
+		sortDesc:          m.sortDesc,
+		cursor:            m.table.Cursor(),
+	}
+	if it := m.selectedItem(); it != nil {
+		item := *it
+		st.cursorItem = &item
+	}
+	return st
+}
+
+// switchTab shows tab with the state it was left in. A tab shown for the
+// first time starts out like the one being left.
+func (m *model) switchTab(tab int) {
+	m.tabs[m.activeTab] = m.saveTab()
+	m.activeTab = tab
+	st := m.tabs[tab]
+	if st == nil {
+		m.updateTable()
+		return
+	}
+	m.filterPorts = st.filterPorts
+	m.filterExposed = st.filterExposed
+	m.filterStalled = st.filterStalled
+	m.hideKernelThreads = st.hideKernelThreads
+	m.hideRootDaemons = st.hideRootDaemons
+	m.textInput.SetValue(st.search)
+	m.exprInput.SetValue(st.exprText)
+	m.expr = st.expr
+	m.sortBy = st.sortBy
+	m.sortDesc = st.sortDesc
+	m.updateTable()
+	m.layoutColumns()
+
+	// Back to the row the cursor was on, or where it was
+	cursor := min(st.cursor, len(m.rowItems)-1)
+	if st.cursorItem != nil {
+		if i := anchorIndex([]rowItem{*st.cursorItem}, m.rowItems, 0); i >= 0 {
+			cursor = i
+		}
+	}
+	if cursor >= 0 {
+		m.table.SetCursor(cursor)
+		m.ensureRows()
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/load"
+)
+
+const (
+	baseInterval = 3 * time.Second
+	maxInterval  = 30 * time.Second
+
+	// Keep the monitor scanning at most this fraction of the time
+	scanDutyCycle = 10
+)
+
+// nextInterval picks the time until the next scan in adaptive mode, backing
+// off when the last scan was slow or the machine is busy. The reason is shown
+// in the status bar.
+func nextInterval(took time.Duration) (time.Duration, string) {
+	interval := baseInterval
+	reason := "normal"
+
+	if slow := took * scanDutyCycle; slow > interval {
+		interval = slow
+		reason = fmt.Sprintf("slow scan %s", took.Round(time.Millisecond))
+	}
+
+	if avg, err := load.Avg(); err == nil {
+		perCPU := avg.Load1 / float64(runtime.NumCPU())
+		if perCPU > 1 {
+			interval *= 2
+			reason = fmt.Sprintf("high load %.2f", avg.Load1)
+		}
+	}
+
+	return min(interval, maxInterval), reason
+}
+
+// retryInterval is the time until the next scan after failures scans in a
+// row failed: interval, doubled for every failure up to maxInterval.
+func retryInterval(interval time.Duration, failures int) time.Duration {
+	limit := max(interval, maxInterval)
+	for range failures {
+		if interval *= 2; interval >= limit {
+			return limit
+		}
+	}
+	return interval
+}
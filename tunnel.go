@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"port-monitor/scanner"
+)
+
+// tunnelsOf returns the port forwards of an ssh client process.
+func tunnelsOf(p scanner.ProcessInfo) []scanner.Tunnel {
+	if p.Name != "ssh" && p.Name != "ssh.exe" {
+		return nil
+	}
+	return scanner.SSHTunnels(p.Command)
+}
+
+// tunnelLabel describes a port forward, e.g. "tunnel: 9000 → db.internal:5432".
+func tunnelLabel(t scanner.Tunnel) string {
+	port := fmt.Sprint(t.Port)
+	if t.BindAddr != "" {
+		port = hostPort(t.BindAddr, t.Port)
+	}
+	switch t.Kind {
+	case scanner.DynamicTunnel:
+		return "socks: " + port
+	case scanner.RemoteTunnel:
+		if t.Host == "" {
+			return "socks: remote " + port
+		}
+		port = "remote " + port
+	}
+	return fmt.Sprintf("tunnel: %s %s %s", port, glyph("→", "->"), hostPort(t.Host, t.HostPort))
+}
+
+// forwardNote labels a listener of p that forwards a port: an ssh tunnel,
+// or a loopback port of an IDE's desktop client forwarding one of the
+// remote machine.
+func forwardNote(p scanner.ProcessInfo, c scanner.Connection) string {
+	if c.Status != "LISTEN" {
+		return ""
+	}
+	for _, t := range tunnelsOf(p) {
+		if t.Kind != scanner.RemoteTunnel && t.Port == c.Port {
+			return tunnelLabel(t)
+		}
+	}
+	if h, ok := scanner.IDEHelperOf(p.Name, p.Command); ok && !h.Server && !c.IsExposed() {
+		return "forwarded by " + h.IDE
+	}
+	return ""
+}
+
+// forwarderKind is the Type column of ssh tunnels, IDE helpers and
+// container VM forwarders, which would otherwise read ssh, node, java or
+// Binary.
+func forwarderKind(p scanner.ProcessInfo) string {
+	if len(tunnelsOf(p)) > 0 {
+		return "ssh tunnel"
+	}
+	if f, ok := scanner.VMForwarderOf(p.Name, p.Command); ok {
+		return f.Runtime + " VM"
+	}
+	if h, ok := scanner.IDEHelperOf(p.Name, p.Command); ok {
+		if h.Server {
+			return h.IDE + " server"
+		}
+		return h.IDE
+	}
+	return ""
+}
+
+// tunnelSummary lists every forward of an ssh client for the detail pane.
+func tunnelSummary(tunnels []scanner.Tunnel) string {
+	labels := make([]string, len(tunnels))
+	for i, t := range tunnels {
+		labels[i] = tunnelLabel(t)
+	}
+	return strings.Join(labels, ", ")
+}
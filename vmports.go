@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"port-monitor/scanner"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// vmPortsInterval throttles the container runtime CLI calls.
+const vmPortsInterval = 10 * time.Second
+
+type vmPortsMsg struct{}
+
+// vmPortsView maps the ports a container VM's forwarder (podman machine,
+// Lima, Colima, Docker Desktop) listens on back to their containers.
+type vmPortsView struct {
+	mu      sync.Mutex
+	ports   map[string]map[uint32]scanner.PublishedPort // By runtime and host port
+	last    time.Time
+	running bool
+}
+
+func newVMPortsView() *vmPortsView {
+	return &vmPortsView{}
+}
+
+// refreshCmd asks the runtimes of the forwarders among procs for their
+// containers, at most once per vmPortsInterval.
+func (v *vmPortsView) refreshCmd(procs []scanner.ProcessInfo) tea.Cmd {
+	forwarders := make(map[scanner.VMForwarder]bool)
+	for _, p := range procs {
+		if f, ok := scanner.VMForwarderOf(p.Name, p.Command); ok && len(p.Connections) > 0 {
+			forwarders[f] = true
+		}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if len(forwarders) == 0 {
+		v.ports = nil
+		return nil
+	}
+	if v.running || time.Since(v.last) < vmPortsInterval {
+		return nil
+	}
+	v.running = true
+	return func() tea.Msg {
+		ports := make(map[string]map[uint32]scanner.PublishedPort)
+		for f := range forwarders {
+			published, err := scanner.PublishedPorts(f)
+			if err != nil {
+				continue
+			}
+			byPort := make(map[uint32]scanner.PublishedPort, len(published))
+			for _, pp := range published {
+				byPort[pp.HostPort] = pp
+			}
+			ports[vmKey(f)] = byPort
+		}
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		v.ports = ports
+		v.last = time.Now()
+		v.running = false
+		return vmPortsMsg{}
+	}
+}
+
+func vmKey(f scanner.VMForwarder) string {
+	return f.Runtime + "/" + f.Instance
+}
+
+// note names the container behind a listener of a forwarder, e.g.
+// "container: podman web 80/tcp".
+func (v *vmPortsView) note(p scanner.ProcessInfo, c scanner.Connection) string {
+	if v == nil || c.Status != "LISTEN" {
+		return ""
+	}
+	f, ok := scanner.VMForwarderOf(p.Name, p.Command)
+	if !ok {
+		return ""
+	}
+	v.mu.Lock()
+	pp, ok := v.ports[vmKey(f)][c.Port]
+	v.mu.Unlock()
+	if !ok || !strings.HasPrefix(c.Protocol, pp.Protocol) {
+		return ""
+	}
+	return fmt.Sprintf("container: %s %s %d/%s", f.Runtime, pp.Container, pp.ContainerPort, pp.Protocol)
+}
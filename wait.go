@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"port-monitor/health"
+)
+
+// runWait blocks until a port accepts connections (--state open) or stops
+// accepting them (--state closed), for scripts waiting on a database or
+// dev server. It fails when --timeout passes first.
+func runWait(args []string) error {
+	fs := flag.NewFlagSet("wait", flag.ExitOnError)
+	port := fs.Uint("port", 0, "TCP port to wait for")
+	host := fs.String("host", "127.0.0.1", "host to connect to")
+	state := fs.String("state", "open", "state to wait for: open or closed")
+	timeout := fs.Duration("timeout", time.Minute, "give up after this long, 0 waits forever")
+	interval := fs.Duration("interval", 250*time.Millisecond, "time between attempts")
+	fs.Parse(args)
+
+	if *port == 0 || *port > 65535 {
+		return usageError("usage: port-monitor wait --port PORT [--state open|closed] [--timeout 60s]")
+	}
+	if *state != "open" && *state != "closed" {
+		return usageError("invalid state %q, expected open or closed", *state)
+	}
+	addr := net.JoinHostPort(*host, strconv.FormatUint(uint64(*port), 10))
+
+	var deadline time.Time
+	if *timeout > 0 {
+		deadline = time.Now().Add(*timeout)
+	}
+	for {
+		_, err := health.ConnectTime(addr)
+		if (err == nil) == (*state == "open") {
+			return nil
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return withCode(exitTimeout, fmt.Errorf("timed out after %s waiting for %s to be %s", *timeout, addr, *state))
+		}
+		time.Sleep(*interval)
+	}
+}
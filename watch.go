@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"port-monitor/health"
+	"port-monitor/scanner"
+)
+
+// watchSamples is how many scans a watch sparkline covers.
+const watchSamples = 30
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// portWatch samples the connections to a port on every scan.
+type portWatch struct {
+	port    uint32
+	samples []int
+	addr    string // Address of the listener, "" when nothing listens
+
+	// Latest TCP connect time from localhost
+	latency    time.Duration
+	latencyErr error
+}
+
+type latencyMsg map[uint32]latencyProbe
+
+type latencyProbe struct {
+	latency time.Duration
+	err     error
+}
+
+type latencyResultMsg struct {
+	port    uint32
+	addr    string
+	latency time.Duration
+	err     error
+}
+
+// toggleWatch adds port to the watch list, or removes it if present.
+// It reports whether the port is watched now.
+func (m *model) toggleWatch(port uint32) bool {
+	for i, w := range m.watches {
+		if w.port == port {
+			m.watches = append(m.watches[:i], m.watches[i+1:]...)
+			return false
+		}
+	}
+	m.watches = append(m.watches, portWatch{port: port})
+	return true
+}
+
+// sampleWatches records the established connections to each watched port.
+func sampleWatches(watches []portWatch, procs []scanner.ProcessInfo) {
+	for i := range watches {
+		n := 0
+		watches[i].addr = ""
+		for _, p := range procs {
+			for _, c := range p.Connections {
+				if c.Port != watches[i].port {
+					continue
+				}
+				switch c.Status {
+				case "ESTABLISHED":
+					n++
+				case "LISTEN":
+					if strings.HasPrefix(c.Protocol, "tcp") {
+						watches[i].addr = hostPort(dialAddr(c.LocalAddr), c.Port)
+					}
+				}
+			}
+		}
+		watches[i].samples = append(watches[i].samples, n)
+		if len(watches[i].samples) > watchSamples {
+			watches[i].samples = watches[i].samples[1:]
+		}
+	}
+}
+
+// latencyCmd measures the connect time of the watched ports that have a
+// listener.
+func latencyCmd(watches []portWatch) tea.Cmd {
+	addrs := make(map[uint32]string)
+	for _, w := range watches {
+		if w.addr != "" {
+			addrs[w.port] = w.addr
+		}
+	}
+	if len(addrs) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		probes := make(latencyMsg, len(addrs))
+		for port, addr := range addrs {
+			latency, err := health.ConnectTime(addr)
+			probes[port] = latencyProbe{latency: latency, err: err}
+		}
+		return probes
+	}
+}
+
+// applyLatency stores connect times in the watches.
+func applyLatency(watches []portWatch, probes latencyMsg) {
+	for i := range watches {
+		if p, ok := probes[watches[i].port]; ok {
+			watches[i].latency, watches[i].latencyErr = p.latency, p.err
+		}
+	}
+}
+
+// latencyNote describes a connect time measurement.
+func latencyNote(latency time.Duration, err error) string {
+	switch {
+	case err == nil:
+		return fmt.Sprintf("connect %s", latency.Round(10*time.Microsecond))
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		return "connect timed out, not accepting"
+	default:
+		return "connect failed"
+	}
+}
+
+// renderWatches draws one sparkline line per watched port.
+func renderWatches(watches []portWatch) string {
+	lines := make([]string, len(watches))
+	for i, w := range watches {
+		last, peak := 0, 0
+		if len(w.samples) > 0 {
+			last = w.samples[len(w.samples)-1]
+		}
+		for _, s := range w.samples {
+			peak = max(peak, s)
+		}
+		lines[i] = fmt.Sprintf("Watch :%-5d %-*s %d conns (peak %d)", w.port, watchSamples, sparkline(w.samples), last, peak)
+		if w.addr == "" {
+			lines[i] += ", not listening"
+		} else if w.latency > 0 {
+			lines[i] += ", " + latencyNote(w.latency, w.latencyErr)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sparkline scales samples to block characters relative to their peak.
+func sparkline(samples []int) string {
+	peak := 0
+	for _, s := range samples {
+		peak = max(peak, s)
+	}
+	var b strings.Builder
+	for _, s := range samples {
+		i := 0
+		if peak > 0 {
+			i = s * (len(sparkBlocks) - 1) / peak
+		}
+		b.WriteRune(sparkBlocks[i])
+	}
+	return b.String()
+}
+
+// measureLatencyCmd measures the connect time of a listener once.
+func measureLatencyCmd(c scanner.Connection) tea.Cmd {
+	addr := hostPort(dialAddr(c.LocalAddr), c.Port)
+	return func() tea.Msg {
+		latency, err := health.ConnectTime(addr)
+		return latencyResultMsg{port: c.Port, addr: addr, latency: latency, err: err}
+	}
+}
@@ -0,0 +1,19 @@
+package web
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:embed index.html
+var index []byte
+
+// Handler serves the single page dashboard. It expects the REST API and the
+// /events WebSocket on the same origin, and the API token in the token
+// query parameter of its URL.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(index)
+	})
+}
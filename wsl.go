@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"port-monitor/scanner"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type wslOwnersMsg struct{}
+
+// wslView annotates listeners that WSL makes reachable from Windows and,
+// with interop enabled, the Windows processes holding the same ports.
+type wslView struct {
+	env     *scanner.WSL
+	interop bool
+
+	mu     sync.Mutex
+	owners map[uint32]string
+}
+
+func newWSLView(interop bool) *wslView {
+	env := scanner.DetectWSL()
+	if env == nil {
+		return nil
+	}
+	return &wslView{env: env, interop: interop}
+}
+
+// status is the status line segment, e.g. "WSL2 (nat)".
+func (w *wslView) status() string {
+	return fmt.Sprintf("WSL%d (%s)", w.env.Version, w.env.Networking)
+}
+
+// note describes how a connection shows up on the Windows side.
+func (w *wslView) note(c scanner.Connection) string {
+	if w == nil || c.Status != "LISTEN" {
+		return ""
+	}
+	w.mu.Lock()
+	owner := w.owners[c.Port]
+	w.mu.Unlock()
+
+	switch {
+	case w.env.Forwarded(c) && owner != "":
+		return fmt.Sprintf("%s windows, %s", glyph("→", "->"), owner)
+	case w.env.Forwarded(c):
+		return glyph("→", "->") + " windows"
+	case owner != "":
+		return fmt.Sprintf("windows: %s", owner)
+	}
+	return ""
+}
+
+// refreshCmd reloads the Windows-side listeners through interop.
+func (w *wslView) refreshCmd() tea.Cmd {
+	if w == nil || !w.interop {
+		return nil
+	}
+	return func() tea.Msg {
+		owners, err := scanner.WindowsListeners()
+		if err != nil {
+			return nil
+		}
+		w.mu.Lock()
+		w.owners = owners
+		w.mu.Unlock()
+		return wslOwnersMsg{}
+	}
+}